@@ -27,7 +27,7 @@ func ParseNullableTime(nullTime sql.NullString) (time.Time, error) {
 // ValidateCategoryExists checks if a category exists in the database
 func ValidateCategoryExists(db *sql.DB, categoryName string) error {
 	var exists bool
-	err := db.QueryRow("SELECT EXISTS(SELECT 1 FROM categories WHERE name = ?)", categoryName).Scan(&exists)
+	err := db.QueryRow("SELECT EXISTS(SELECT 1 FROM categories WHERE name = ? AND deleted_at IS NULL)", categoryName).Scan(&exists)
 	if err != nil {
 		return fmt.Errorf("database error while validating category: %w", err)
 	}
@@ -53,8 +53,23 @@ func CreateTimeEntryInDB(db *sql.DB, req model.TimeEntryRequest) (*model.TimeEnt
 	// Get current date for compatibility with existing database schema
 	currentDate := pkgutil.GetCurrentDateForDB()
 
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Apply the configured overlap policy
+	policy, err := handler.RequestOverlapPolicy("")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := handler.ResolveOverlaps(tx, policy, startTime, endTime, 0); err != nil {
+		return nil, err
+	}
+
 	// Insert into database
-	result, err := db.Exec(`
+	result, err := tx.Exec(`
 		INSERT INTO time_entries (task, description, category, start_time, end_time, duration, date)
 		VALUES (?, ?, ?, ?, ?, ?, ?)
 	`, req.Task, req.Description, req.Category, pkgutil.FormatTimeForDB(startTime),
@@ -67,6 +82,14 @@ func CreateTimeEntryInDB(db *sql.DB, req model.TimeEntryRequest) (*model.TimeEnt
 	}
 
 	id, _ := result.LastInsertId()
+	if err := handler.AuditInsert(tx, handler.AuditEntry, int(id)); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to create time entry: %w", err)
+	}
+
 	log.Printf("INSERT: Created time entry ID %d - Task: %s, Category: %s, Duration: %d min, Start: %s, End: %s",
 		id, req.Task, req.Category, duration, startTime.Format("2006-01-02 15:04"), endTime.Format("2006-01-02 15:04"))
 
@@ -76,7 +99,8 @@ func CreateTimeEntryInDB(db *sql.DB, req model.TimeEntryRequest) (*model.TimeEnt
 		Description: req.Description,
 		Category:    req.Category,
 		StartTime:   startTime,
-		EndTime:     endTime,
+		EndTime:     &endTime,
+		Duration:    duration,
 	}, nil
 }
 
@@ -96,13 +120,31 @@ func UpdateTimeEntryInDB(db *sql.DB, id int, req model.TimeEntryRequest) (*model
 	// Get current date for compatibility with existing database schema
 	currentDate := pkgutil.GetCurrentDateForDB()
 
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Apply the configured overlap policy
+	policy, err := handler.RequestOverlapPolicy("")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := handler.ResolveOverlaps(tx, policy, startTime, endTime, id); err != nil {
+		return nil, err
+	}
+
 	// Update in database
-	_, err = db.Exec(`
-		UPDATE time_entries 
-		SET task = ?, description = ?, category = ?, start_time = ?, end_time = ?, duration = ?, date = ?
-		WHERE id = ?
-	`, req.Task, req.Description, req.Category, pkgutil.FormatTimeForDB(startTime),
-		pkgutil.FormatTimeForDB(endTime), duration, currentDate, id)
+	err = handler.AuditedWrite(tx, handler.AuditEntry, id, handler.AuditUpdate, func() error {
+		_, err := tx.Exec(`
+			UPDATE time_entries 
+			SET task = ?, description = ?, category = ?, start_time = ?, end_time = ?, duration = ?, date = ?
+			WHERE id = ?
+		`, req.Task, req.Description, req.Category, pkgutil.FormatTimeForDB(startTime),
+			pkgutil.FormatTimeForDB(endTime), duration, currentDate, id)
+		return err
+	})
 
 	if err != nil {
 		log.Printf("ERROR: Failed to update time entry ID %d - Task: %s, Category: %s, Start: %s, End: %s - Error: %v",
@@ -110,6 +152,10 @@ func UpdateTimeEntryInDB(db *sql.DB, id int, req model.TimeEntryRequest) (*model
 		return nil, fmt.Errorf("failed to update time entry: %w", err)
 	}
 
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to update time entry: %w", err)
+	}
+
 	log.Printf("UPDATE: Modified time entry ID %d - Task: %s, Category: %s, Duration: %d min, Start: %s, End: %s",
 		id, req.Task, req.Category, duration, startTime.Format("2006-01-02 15:04"), endTime.Format("2006-01-02 15:04"))
 
@@ -119,6 +165,7 @@ func UpdateTimeEntryInDB(db *sql.DB, id int, req model.TimeEntryRequest) (*model
 		Description: req.Description,
 		Category:    req.Category,
 		StartTime:   startTime,
-		EndTime:     endTime,
+		EndTime:     &endTime,
+		Duration:    duration,
 	}, nil
 }
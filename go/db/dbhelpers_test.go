@@ -3,28 +3,22 @@ package db
 import (
 	"database/sql"
 	"testing"
+	pkgglobal "timesheet/go/global"
+	"timesheet/go/handler"
 	"timesheet/go/model"
+	"timesheet/go/testdb"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
-	_ "modernc.org/sqlite"
 )
 
 // setupTestDB creates an in-memory SQLite database for testing
 func setupTestDB(t *testing.T) *sql.DB {
-	db, err := sql.Open("sqlite", ":memory:")
-	require.NoError(t, err)
-
-	// Create categories table
-	_, err = db.Exec(createTableCategories)
-	require.NoError(t, err)
-
-	// Create time_entries table
-	_, err = db.Exec(createTableTimeEntries)
-	require.NoError(t, err)
+	// The migrations create the schema and add the default categories
+	db := testdb.Open(t)
 
 	// Insert test categories
-	_, err = db.Exec("INSERT INTO categories (name) VALUES ('project work'), ('project support'), ('maintenance')")
+	_, err := db.Exec("INSERT INTO categories (name) VALUES ('maintenance')")
 	require.NoError(t, err)
 
 	return db
@@ -238,3 +232,71 @@ func TestUpdateTimeEntryInDBInvalidCategory(t *testing.T) {
 	assert.Contains(t, err.Error(), "invalid category 'invalid category': category does not exist in the system")
 	assert.Nil(t, entry)
 }
+
+func TestCreateTimeEntryInDBOverlapRejected(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	pkgglobal.SetOverlapPolicy("reject")
+	defer pkgglobal.SetOverlapPolicy("allow")
+
+	first, err := CreateTimeEntryInDB(db, model.TimeEntryRequest{
+		Task:      "Development",
+		Category:  "project work",
+		StartTime: "2025-11-09T09:00:00Z",
+		EndTime:   "2025-11-09T10:00:00Z",
+	})
+	require.NoError(t, err)
+
+	entry, err := CreateTimeEntryInDB(db, model.TimeEntryRequest{
+		Task:      "Meeting",
+		Category:  "project support",
+		StartTime: "2025-11-09T09:30:00Z",
+		EndTime:   "2025-11-09T10:30:00Z",
+	})
+	require.Error(t, err)
+	var overlapErr *handler.OverlapError
+	require.ErrorAs(t, err, &overlapErr)
+	assert.Equal(t, []int{first.ID}, overlapErr.ConflictIDs)
+	assert.Nil(t, entry)
+
+	// Adjacent entries do not overlap
+	_, err = CreateTimeEntryInDB(db, model.TimeEntryRequest{
+		Task:      "Meeting",
+		Category:  "project support",
+		StartTime: "2025-11-09T10:00:00Z",
+		EndTime:   "2025-11-09T10:30:00Z",
+	})
+	require.NoError(t, err)
+}
+
+func TestCreateTimeEntryInDBOverlapTrimmed(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	pkgglobal.SetOverlapPolicy("trim")
+	defer pkgglobal.SetOverlapPolicy("allow")
+
+	first, err := CreateTimeEntryInDB(db, model.TimeEntryRequest{
+		Task:      "Development",
+		Category:  "project work",
+		StartTime: "2025-11-09T09:00:00Z",
+		EndTime:   "2025-11-09T10:00:00Z",
+	})
+	require.NoError(t, err)
+
+	_, err = CreateTimeEntryInDB(db, model.TimeEntryRequest{
+		Task:      "Meeting",
+		Category:  "project support",
+		StartTime: "2025-11-09T09:30:00Z",
+		EndTime:   "2025-11-09T10:30:00Z",
+	})
+	require.NoError(t, err)
+
+	var endTime string
+	var duration int
+	err = db.QueryRow("SELECT end_time, duration FROM time_entries WHERE id = ?", first.ID).Scan(&endTime, &duration)
+	require.NoError(t, err)
+	assert.Equal(t, "2025-11-09T09:30:00Z", endTime)
+	assert.Equal(t, 30, duration)
+}
@@ -0,0 +1,48 @@
+-- Link time entries to their category and task by ID. The name columns stay the source
+-- the API writes and triggers keep the IDs in sync with them. Renames and new categories
+-- and tasks are propagated by the API, so every changed entry is audited.
+
+ALTER TABLE time_entries ADD COLUMN category_id INTEGER REFERENCES categories(id) ON DELETE SET NULL;
+ALTER TABLE time_entries ADD COLUMN task_id INTEGER REFERENCES tasks(id) ON DELETE SET NULL;
+
+UPDATE time_entries SET category_id = (SELECT c.id FROM categories c WHERE c.name = time_entries.category);
+
+-- Task names are not unique; prefer the task of the entry's category
+UPDATE time_entries SET task_id = COALESCE(
+	(SELECT MIN(t.id) FROM tasks t WHERE t.name = time_entries.task AND t.category_id = time_entries.category_id),
+	(SELECT MIN(t.id) FROM tasks t WHERE t.name = time_entries.task));
+
+CREATE INDEX idx_time_entries_category_id ON time_entries(category_id);
+CREATE INDEX idx_time_entries_task_id ON time_entries(task_id);
+
+-- Entries written by name are linked to the matching category and task
+CREATE TRIGGER time_entries_link_insert AFTER INSERT ON time_entries
+BEGIN
+	UPDATE time_entries SET category_id = (SELECT c.id FROM categories c WHERE c.name = NEW.category)
+	WHERE id = NEW.id;
+	UPDATE time_entries SET task_id = COALESCE(
+		(SELECT MIN(t.id) FROM tasks t WHERE t.name = NEW.task AND t.category_id = time_entries.category_id),
+		(SELECT MIN(t.id) FROM tasks t WHERE t.name = NEW.task))
+	WHERE id = NEW.id;
+END;
+
+CREATE TRIGGER time_entries_link_update AFTER UPDATE OF task, category ON time_entries
+BEGIN
+	UPDATE time_entries SET category_id = (SELECT c.id FROM categories c WHERE c.name = NEW.category)
+	WHERE id = NEW.id;
+	UPDATE time_entries SET task_id = COALESCE(
+		(SELECT MIN(t.id) FROM tasks t WHERE t.name = NEW.task AND t.category_id = time_entries.category_id),
+		(SELECT MIN(t.id) FROM tasks t WHERE t.name = NEW.task))
+	WHERE id = NEW.id;
+END;
+
+-- Foreign keys are not enforced, so deletions unlink explicitly
+CREATE TRIGGER categories_unlink AFTER DELETE ON categories
+BEGIN
+	UPDATE time_entries SET category_id = NULL WHERE category_id = OLD.id;
+END;
+
+CREATE TRIGGER tasks_unlink AFTER DELETE ON tasks
+BEGIN
+	UPDATE time_entries SET task_id = NULL WHERE task_id = OLD.id;
+END;
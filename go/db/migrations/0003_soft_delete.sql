@@ -0,0 +1,10 @@
+-- Deleting moves rows to the trash by setting deleted_at; they are purged after the
+-- retention period or explicitly from the trash.
+
+ALTER TABLE time_entries ADD COLUMN deleted_at DATETIME;
+ALTER TABLE tasks ADD COLUMN deleted_at DATETIME;
+ALTER TABLE categories ADD COLUMN deleted_at DATETIME;
+
+CREATE INDEX idx_time_entries_deleted_at ON time_entries(deleted_at);
+CREATE INDEX idx_tasks_deleted_at ON tasks(deleted_at);
+CREATE INDEX idx_categories_deleted_at ON categories(deleted_at);
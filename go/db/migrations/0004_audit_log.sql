@@ -0,0 +1,15 @@
+-- Every write of time entries, tasks and categories is recorded with the full row before
+-- and after the change. before_json is NULL for creations, after_json for purges.
+
+CREATE TABLE audit_log (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	entity_type TEXT NOT NULL,
+	entity_id INTEGER NOT NULL,
+	operation TEXT NOT NULL,
+	before_json TEXT,
+	after_json TEXT,
+	changed_at DATETIME NOT NULL
+);
+
+CREATE INDEX idx_audit_log_entity ON audit_log(entity_type, entity_id);
+CREATE INDEX idx_audit_log_changed_at ON audit_log(changed_at);
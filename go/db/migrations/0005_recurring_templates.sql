@@ -0,0 +1,21 @@
+-- Recurring templates describe entries that are booked on a schedule, like the daily standup.
+-- start_time is a wall-clock time (HH:MM); schedule is an RRULE subset, see handler.ParseRecurrence.
+-- exclude_dates is a comma-separated list of dates (YYYY-MM-DD) on which nothing is booked.
+
+CREATE TABLE recurring_templates (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	task TEXT NOT NULL,
+	description TEXT NOT NULL DEFAULT '',
+	category TEXT NOT NULL,
+	start_time TEXT NOT NULL,
+	duration INTEGER NOT NULL,
+	schedule TEXT NOT NULL,
+	starts_on TEXT NOT NULL,
+	ends_on TEXT,
+	exclude_dates TEXT NOT NULL DEFAULT ''
+);
+
+-- The daily standup formerly hardcoded in the "Add Daily" button
+INSERT INTO recurring_templates (task, category, start_time, duration, schedule, starts_on)
+SELECT 'Daily', 'project support', '09:00', 30, 'FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR', '2000-01-03'
+WHERE EXISTS (SELECT 1 FROM categories WHERE name = 'project support');
@@ -0,0 +1,15 @@
+-- Tags are free labels like "billable" or "on-call" attached to any number of time entries,
+-- independent of the category. Names are stored trimmed and in lower case.
+
+CREATE TABLE tags (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE time_entry_tags (
+	entry_id INTEGER NOT NULL REFERENCES time_entries(id),
+	tag_id INTEGER NOT NULL REFERENCES tags(id),
+	PRIMARY KEY (entry_id, tag_id)
+);
+
+CREATE INDEX idx_time_entry_tags_tag_id ON time_entry_tags(tag_id);
@@ -0,0 +1,22 @@
+-- Clients and projects carry the commercial context of the work: a client has projects, a
+-- project has tasks. Entries reference a project directly, by default the one of their task.
+-- The category stays the type of work.
+
+CREATE TABLE clients (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE projects (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	client_id INTEGER NOT NULL REFERENCES clients(id),
+	name TEXT NOT NULL,
+	UNIQUE (client_id, name)
+);
+
+ALTER TABLE tasks ADD COLUMN project_id INTEGER REFERENCES projects(id);
+ALTER TABLE time_entries ADD COLUMN project_id INTEGER REFERENCES projects(id);
+
+CREATE INDEX idx_projects_client_id ON projects(client_id);
+CREATE INDEX idx_tasks_project_id ON tasks(project_id);
+CREATE INDEX idx_time_entries_project_id ON time_entries(project_id);
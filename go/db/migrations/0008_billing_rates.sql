@@ -0,0 +1,15 @@
+-- Hourly rates of a client, project, category or task. A rate applies from its effective date
+-- until the next rate of the same scope; amounts are decimal strings so that no precision is
+-- lost. Entries are billable unless they are marked otherwise.
+
+CREATE TABLE rates (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	scope TEXT NOT NULL,
+	scope_id INTEGER NOT NULL,
+	hourly_rate TEXT NOT NULL,
+	currency TEXT NOT NULL,
+	effective_from TEXT NOT NULL,
+	UNIQUE (scope, scope_id, effective_from)
+);
+
+ALTER TABLE time_entries ADD COLUMN billable INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,14 @@
+-- The company shown in the header of printed timesheets and invoices. The table holds a
+-- single row that is edited in place.
+
+CREATE TABLE company (
+	id INTEGER PRIMARY KEY CHECK (id = 1),
+	name TEXT NOT NULL DEFAULT '',
+	address TEXT NOT NULL DEFAULT '',
+	email TEXT NOT NULL DEFAULT '',
+	phone TEXT NOT NULL DEFAULT '',
+	tax_id TEXT NOT NULL DEFAULT '',
+	bank_details TEXT NOT NULL DEFAULT ''
+);
+
+INSERT INTO company (id) VALUES (1);
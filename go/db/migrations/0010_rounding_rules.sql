@@ -0,0 +1,11 @@
+-- Rules for rounding durations in reports and exports. Client ID 0 holds the default rule of
+-- entries without a client-specific one; the stored start and end times are never rounded.
+
+CREATE TABLE rounding_rules (
+	client_id INTEGER PRIMARY KEY,
+	mode TEXT NOT NULL,
+	increment INTEGER NOT NULL DEFAULT 0,
+	per TEXT NOT NULL DEFAULT 'entry'
+);
+
+INSERT INTO rounding_rules (client_id, mode) VALUES (0, 'none');
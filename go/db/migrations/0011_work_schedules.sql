@@ -0,0 +1,14 @@
+-- Contracted working time in minutes per weekday. A schedule applies from its effective
+-- date until the next one starts.
+
+CREATE TABLE work_schedules (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	effective_from TEXT NOT NULL UNIQUE,
+	monday INTEGER NOT NULL DEFAULT 0,
+	tuesday INTEGER NOT NULL DEFAULT 0,
+	wednesday INTEGER NOT NULL DEFAULT 0,
+	thursday INTEGER NOT NULL DEFAULT 0,
+	friday INTEGER NOT NULL DEFAULT 0,
+	saturday INTEGER NOT NULL DEFAULT 0,
+	sunday INTEGER NOT NULL DEFAULT 0
+);
@@ -0,0 +1,13 @@
+-- Days off such as vacation and sick leave. An absence covers start_date to end_date
+-- inclusive; half-day absences cover half of each of these days.
+
+CREATE TABLE absences (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	type TEXT NOT NULL,
+	start_date TEXT NOT NULL,
+	end_date TEXT NOT NULL,
+	half_day INTEGER NOT NULL DEFAULT 0,
+	note TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX idx_absences_dates ON absences (start_date, end_date);
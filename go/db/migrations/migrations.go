@@ -0,0 +1,332 @@
+// Package migrations holds the schema history of the database and applies it.
+// It depends on nothing else in the application, so tests of every package can
+// build their databases from the real schema.
+package migrations
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed *.sql
+var migrationFiles embed.FS
+
+// Migration is one step of the schema history. Exactly one of SQL and Up is set; either
+// runs in the same transaction that records the migration in db_version.
+type Migration struct {
+	Version int
+	Name    string
+	// SQL is a script of one or more statements, usually loaded from NNNN_name.sql
+	SQL string
+	// Up is used for steps that need to inspect the database first
+	Up func(tx *sql.Tx) error
+}
+
+// Checksum identifies the content of a migration. Go migrations are identified by their name.
+func (m Migration) Checksum() string {
+	content := "go:" + m.Name
+	if m.Up == nil {
+		content = m.SQL
+	}
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+const createTableVersion = `
+	CREATE TABLE IF NOT EXISTS db_version (
+		id INTEGER PRIMARY KEY,
+		version INTEGER NOT NULL,
+		applied_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		name TEXT,
+		checksum TEXT
+	);`
+
+const createTableTimeEntries = `
+	CREATE TABLE IF NOT EXISTS time_entries (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		task TEXT NOT NULL,
+		description TEXT,
+		category TEXT NOT NULL DEFAULT 'other',
+		start_time DATETIME,
+		end_time DATETIME,
+		duration INTEGER NOT NULL,
+		date TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);`
+
+const createTableCategories = `
+	CREATE TABLE IF NOT EXISTS categories (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL UNIQUE,
+		color TEXT NOT NULL DEFAULT '#718096',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);`
+
+const createTableTasks = `
+	CREATE TABLE IF NOT EXISTS tasks (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		category_id INTEGER,
+		description TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE SET NULL
+	);`
+
+// goMigrations are the migrations implemented in Go, merged with the embedded SQL files
+var goMigrations = []Migration{
+	{Version: 1, Name: "initial_schema", Up: migrateInitialSchema},
+}
+
+// All is the ordered registry of all migrations
+var All = mustLoadMigrations(migrationFiles, goMigrations)
+
+// loadMigrations reads the NNNN_name.sql files and merges them with goMigrations.
+// Versions must be unique and start at 1 without gaps.
+func loadMigrations(files fs.FS, goMigrations []Migration) ([]Migration, error) {
+	all := append([]Migration{}, goMigrations...)
+
+	names, err := fs.Glob(files, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		base := strings.TrimSuffix(path.Base(name), ".sql")
+		number, title, ok := strings.Cut(base, "_")
+		version, err := strconv.Atoi(number)
+		if !ok || err != nil || title == "" {
+			return nil, fmt.Errorf("migration file '%s' must be named NNNN_name.sql", name)
+		}
+		content, err := fs.ReadFile(files, name)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, Migration{Version: version, Name: title, SQL: string(content)})
+	}
+
+	sort.Slice(all, func(i, j int) bool { return all[i].Version < all[j].Version })
+	for i, m := range all {
+		if m.Version != i+1 {
+			return nil, fmt.Errorf("migration versions must be consecutive: expected %d, found %d (%s)", i+1, m.Version, m.Name)
+		}
+		if (m.SQL == "") == (m.Up == nil) {
+			return nil, fmt.Errorf("migration %d (%s) must have either SQL or an Up function", m.Version, m.Name)
+		}
+	}
+	return all, nil
+}
+
+// mustLoadMigrations panics on an invalid registry, which is a programming error
+func mustLoadMigrations(files fs.FS, goMigrations []Migration) []Migration {
+	all, err := loadMigrations(files, goMigrations)
+	if err != nil {
+		panic(err)
+	}
+	return all
+}
+
+// ensureVersionTable creates db_version, adding the name and checksum columns to tables
+// created before migrations were checksummed
+func ensureVersionTable(db *sql.DB) error {
+	if _, err := db.Exec(createTableVersion); err != nil {
+		return fmt.Errorf("failed to create db_version table: %w", err)
+	}
+	for _, column := range []string{"name", "checksum"} {
+		exists, err := columnExists(db, "db_version", column)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			if _, err := db.Exec("ALTER TABLE db_version ADD COLUMN " + column + " TEXT"); err != nil {
+				return fmt.Errorf("failed to add column %s to db_version: %w", column, err)
+			}
+		}
+	}
+	return nil
+}
+
+// querier is satisfied by both *sql.DB and *sql.Tx
+type querier interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// columnExists reports whether table has the given column
+func columnExists(q querier, table, column string) (bool, error) {
+	rows, err := q.Query("SELECT name FROM pragma_table_info(?)", table)
+	if err != nil {
+		return false, fmt.Errorf("failed to read columns of %s: %w", table, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return false, err
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+	return false, rows.Err()
+}
+
+// verifyAppliedMigrations compares the checksums of applied migrations with the registry.
+// Migrations applied before checksums were recorded adopt the current checksum.
+func verifyAppliedMigrations(db *sql.DB, migrations []Migration) error {
+	rows, err := db.Query("SELECT version, COALESCE(checksum, '') FROM db_version ORDER BY version")
+	if err != nil {
+		return fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+	applied := make(map[int]string)
+	for rows.Next() {
+		var version int
+		var checksum string
+		if err := rows.Scan(&version, &checksum); err != nil {
+			rows.Close()
+			return err
+		}
+		applied[version] = checksum
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for version := range applied {
+		if version > len(migrations) {
+			return fmt.Errorf("database version %d is newer than this build supports (%d)", version, len(migrations))
+		}
+	}
+
+	for _, m := range migrations {
+		checksum, ok := applied[m.Version]
+		switch {
+		case !ok:
+		case checksum == "":
+			_, err := db.Exec("UPDATE db_version SET name = ?, checksum = ? WHERE version = ?", m.Name, m.Checksum(), m.Version)
+			if err != nil {
+				return fmt.Errorf("failed to record checksum of migration %d: %w", m.Version, err)
+			}
+		case checksum != m.Checksum():
+			return fmt.Errorf("migration %d (%s) has changed since it was applied", m.Version, m.Name)
+		}
+	}
+	return nil
+}
+
+// currentVersion returns the highest applied migration
+func currentVersion(db *sql.DB) (int, error) {
+	var version int
+	err := db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM db_version").Scan(&version)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read database version: %w", err)
+	}
+	return version, nil
+}
+
+// Migrate verifies the applied migrations and applies the missing ones in order. Each
+// migration runs in its own transaction, so a failing migration leaves the database at
+// the previous version.
+func Migrate(db *sql.DB, migrations []Migration) error {
+	if err := ensureVersionTable(db); err != nil {
+		return err
+	}
+	if err := verifyAppliedMigrations(db, migrations); err != nil {
+		return err
+	}
+
+	fromVersion, err := currentVersion(db)
+	if err != nil {
+		return err
+	}
+	if fromVersion == len(migrations) {
+		return nil
+	}
+
+	log.Printf("Applying database migrations from version %d to %d", fromVersion, len(migrations))
+	for _, m := range migrations[fromVersion:] {
+		if err := applyMigration(db, m); err != nil {
+			return err
+		}
+	}
+	log.Printf("Database migrations completed. Current version: %d", len(migrations))
+	return nil
+}
+
+// applyMigration runs one migration and records it in the same transaction
+func applyMigration(db *sql.DB, m Migration) error {
+	log.Printf("Applying migration %d: %s", m.Version, m.Name)
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if m.Up != nil {
+		err = m.Up(tx)
+	} else {
+		_, err = tx.Exec(m.SQL)
+	}
+	if err != nil {
+		return fmt.Errorf("migration %d (%s) failed: %w", m.Version, m.Name, err)
+	}
+
+	if err := recordMigration(tx, m); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration %d (%s): %w", m.Version, m.Name, err)
+	}
+
+	log.Printf("Migration %d applied successfully", m.Version)
+	return nil
+}
+
+// recordMigration stores the applied migration with its checksum
+func recordMigration(tx *sql.Tx, m Migration) error {
+	_, err := tx.Exec("INSERT INTO db_version (version, name, checksum) VALUES (?, ?, ?)", m.Version, m.Name, m.Checksum())
+	if err != nil {
+		return fmt.Errorf("failed to record migration %d: %w", m.Version, err)
+	}
+	return nil
+}
+
+// migrateInitialSchema creates the initial tables. Databases created before categories
+// existed get the category column added.
+func migrateInitialSchema(tx *sql.Tx) error {
+	for _, statement := range []string{createTableTimeEntries, createTableCategories, createTableTasks} {
+		if _, err := tx.Exec(statement); err != nil {
+			return err
+		}
+	}
+
+	hasCategory, err := columnExists(tx, "time_entries", "category")
+	if err != nil {
+		return err
+	}
+	if !hasCategory {
+		if _, err := tx.Exec("ALTER TABLE time_entries ADD COLUMN category TEXT DEFAULT 'other'"); err != nil {
+			return err
+		}
+	}
+
+	// Update any existing entries that have NULL category
+	if _, err := tx.Exec("UPDATE time_entries SET category = 'other' WHERE category IS NULL"); err != nil {
+		return err
+	}
+
+	// Insert default categories if they don't exist
+	_, err = tx.Exec(`INSERT OR IGNORE INTO categories (name, color) VALUES
+		('project work', '#48bb78'),
+		('project support', '#ed8936'),
+		('other', '#718096')`)
+	return err
+}
@@ -0,0 +1,227 @@
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+// openMigrationTestDB opens an empty in-memory database
+func openMigrationTestDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite", ":memory:")
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// tableExists reports whether the table exists
+func tableExists(t *testing.T, db *sql.DB, table string) bool {
+	var count int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", table).Scan(&count))
+	return count > 0
+}
+
+func TestMigrateCreatesSchema(t *testing.T) {
+	db := openMigrationTestDB(t)
+
+	require.NoError(t, Migrate(db, All))
+	// Running again is a no-op
+	require.NoError(t, Migrate(db, All))
+
+	version, err := currentVersion(db)
+	require.NoError(t, err)
+	assert.Equal(t, len(All), version)
+	assert.True(t, tableExists(t, db, "time_entries"))
+	assert.True(t, tableExists(t, db, "tasks"))
+
+	var categories, versions int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM categories").Scan(&categories))
+	assert.Equal(t, 3, categories)
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM db_version WHERE checksum IS NOT NULL").Scan(&versions))
+	assert.Equal(t, len(All), versions)
+}
+
+func TestMigrateFailureKeepsPreviousVersion(t *testing.T) {
+	db := openMigrationTestDB(t)
+	steps := []Migration{
+		{Version: 1, Name: "first", SQL: "CREATE TABLE first (id INTEGER);"},
+		{Version: 2, Name: "broken", SQL: "CREATE TABLE second (id INTEGER); INSERT INTO missing VALUES (1);"},
+	}
+
+	err := Migrate(db, steps)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "migration 2 (broken) failed")
+	version, err := currentVersion(db)
+	require.NoError(t, err)
+	assert.Equal(t, 1, version)
+	assert.True(t, tableExists(t, db, "first"))
+	assert.False(t, tableExists(t, db, "second"))
+}
+
+func TestMigrateDetectsChangedMigration(t *testing.T) {
+	db := openMigrationTestDB(t)
+	steps := []Migration{{Version: 1, Name: "first", SQL: "CREATE TABLE first (id INTEGER);"}}
+	require.NoError(t, Migrate(db, steps))
+
+	steps[0].SQL = "CREATE TABLE first (id INTEGER, name TEXT);"
+	err := Migrate(db, steps)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "migration 1 (first) has changed since it was applied")
+}
+
+func TestMigrateRejectsNewerDatabase(t *testing.T) {
+	db := openMigrationTestDB(t)
+	steps := []Migration{
+		{Version: 1, Name: "first", SQL: "CREATE TABLE first (id INTEGER);"},
+		{Version: 2, Name: "second", SQL: "CREATE TABLE second (id INTEGER);"},
+	}
+	require.NoError(t, Migrate(db, steps))
+
+	err := Migrate(db, steps[:1])
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "newer than this build")
+}
+
+func TestMigrateUpgradesUnversionedLegacyDatabase(t *testing.T) {
+	db := openMigrationTestDB(t)
+	// A database from before checksums, whose entries predate categories
+	_, err := db.Exec(`
+		CREATE TABLE db_version (id INTEGER PRIMARY KEY, version INTEGER NOT NULL, applied_at DATETIME DEFAULT CURRENT_TIMESTAMP);
+		CREATE TABLE time_entries (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			task TEXT NOT NULL,
+			description TEXT,
+			start_time DATETIME,
+			end_time DATETIME,
+			duration INTEGER NOT NULL,
+			date TEXT NOT NULL
+		);
+		INSERT INTO time_entries (task, duration, date) VALUES ('Development', 30, '2025-11-10');`)
+	require.NoError(t, err)
+
+	require.NoError(t, Migrate(db, All))
+
+	var category string
+	require.NoError(t, db.QueryRow("SELECT category FROM time_entries").Scan(&category))
+	assert.Equal(t, "other", category)
+}
+
+func TestMigrateAdoptsChecksumsOfOldVersionTable(t *testing.T) {
+	db := openMigrationTestDB(t)
+	steps := []Migration{{Version: 1, Name: "first", SQL: "CREATE TABLE first (id INTEGER);"}}
+	_, err := db.Exec(`
+		CREATE TABLE db_version (id INTEGER PRIMARY KEY, version INTEGER NOT NULL, applied_at DATETIME DEFAULT CURRENT_TIMESTAMP);
+		CREATE TABLE first (id INTEGER);
+		INSERT INTO db_version (version) VALUES (1);`)
+	require.NoError(t, err)
+
+	require.NoError(t, Migrate(db, steps))
+
+	var checksum string
+	require.NoError(t, db.QueryRow("SELECT checksum FROM db_version WHERE version = 1").Scan(&checksum))
+	assert.Equal(t, steps[0].Checksum(), checksum)
+}
+
+func TestLoadMigrations(t *testing.T) {
+	files := fstest.MapFS{
+		"0002_add_notes.sql": {Data: []byte("ALTER TABLE first ADD COLUMN notes TEXT;")},
+		"README.md":          {Data: []byte("ignored")},
+	}
+	goSteps := []Migration{{Version: 1, Name: "first", Up: func(tx *sql.Tx) error { return nil }}}
+
+	all, err := loadMigrations(files, goSteps)
+
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+	assert.Equal(t, "first", all[0].Name)
+	assert.Equal(t, Migration{Version: 2, Name: "add_notes", SQL: "ALTER TABLE first ADD COLUMN notes TEXT;"}, all[1])
+}
+
+func TestLoadMigrationsRejectsInvalidRegistry(t *testing.T) {
+	tests := map[string]fstest.MapFS{
+		"gap":        {"0003_gap.sql": {Data: []byte("SELECT 1;")}},
+		"duplicate":  {"0001_again.sql": {Data: []byte("SELECT 1;")}},
+		"bad name":   {"add_notes.sql": {Data: []byte("SELECT 1;")}},
+		"empty file": {"0002_empty.sql": {Data: []byte("")}},
+	}
+	goSteps := []Migration{{Version: 1, Name: "first", Up: func(tx *sql.Tx) error { return nil }}}
+
+	for name, files := range tests {
+		_, err := loadMigrations(files, goSteps)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestMigrateRunsGoMigrationsInTransaction(t *testing.T) {
+	db := openMigrationTestDB(t)
+	steps := []Migration{{Version: 1, Name: "go_step", Up: func(tx *sql.Tx) error {
+		if _, err := tx.Exec("CREATE TABLE first (id INTEGER)"); err != nil {
+			return err
+		}
+		return fmt.Errorf("backfill failed")
+	}}}
+
+	err := Migrate(db, steps)
+
+	require.Error(t, err)
+	assert.False(t, tableExists(t, db, "first"))
+}
+
+func TestLinkMigrationBackfillsEntryLinks(t *testing.T) {
+	db := openMigrationTestDB(t)
+	require.NoError(t, Migrate(db, All[:1]))
+	_, err := db.Exec(`
+		INSERT INTO tasks (name, category_id) VALUES ('Review', 3), ('Review', 1);
+		INSERT INTO time_entries (task, category, duration, date) VALUES
+			('Review', 'project work', 30, '2025-11-10'),
+			('Ad hoc', 'unknown', 15, '2025-11-10');`)
+	require.NoError(t, err)
+
+	require.NoError(t, Migrate(db, All))
+
+	link := func(id int) (task, category string, taskID, categoryID sql.NullInt64) {
+		err := db.QueryRow("SELECT task, category, task_id, category_id FROM time_entries WHERE id = ?", id).
+			Scan(&task, &category, &taskID, &categoryID)
+		require.NoError(t, err)
+		return
+	}
+
+	// The task of the entry's category wins over the first task of that name
+	_, _, taskID, categoryID := link(1)
+	assert.Equal(t, int64(2), taskID.Int64)
+	assert.Equal(t, int64(1), categoryID.Int64)
+	_, _, taskID, categoryID = link(2)
+	assert.False(t, taskID.Valid)
+	assert.False(t, categoryID.Valid)
+
+	// Renames are propagated by the API, which audits every changed entry
+	_, err = db.Exec("UPDATE categories SET name = 'client work' WHERE id = 1")
+	require.NoError(t, err)
+	task, category, taskID, categoryID := link(1)
+	assert.Equal(t, "Review", task)
+	assert.Equal(t, "project work", category)
+	assert.Equal(t, int64(2), taskID.Int64)
+	assert.Equal(t, int64(1), categoryID.Int64)
+
+	// Entries written by name are linked
+	_, err = db.Exec("UPDATE time_entries SET category = 'other' WHERE id = 2")
+	require.NoError(t, err)
+	_, _, _, categoryID = link(2)
+	assert.Equal(t, int64(3), categoryID.Int64)
+
+	// Deleting a category unlinks its entries
+	_, err = db.Exec("DELETE FROM categories WHERE id = 1")
+	require.NoError(t, err)
+	_, category, _, categoryID = link(1)
+	assert.Equal(t, "project work", category)
+	assert.False(t, categoryID.Valid)
+}
@@ -3,12 +3,26 @@ package global
 import (
 	"database/sql"
 	"embed"
+	"io/fs"
+	"time"
 )
 
 // Package-level variables that will be set by main
 var Db *sql.DB
 var StaticFiles embed.FS
 
+// Templates holds the HTML templates of the printable documents
+var Templates fs.FS
+
+// OverlapPolicy is the default policy for overlapping time entries (reject, allow or trim)
+var OverlapPolicy = "allow"
+
+// HolidayRegion is the region of the public holidays, e.g. DE-BY; empty for none
+var HolidayRegion = ""
+
+// TrashRetention is how long deleted rows stay in the trash; 0 keeps them until purged
+var TrashRetention = 30 * 24 * time.Hour
+
 // SetDB sets the database connection for the handlers to use
 func SetDB(database *sql.DB) {
 	Db = database
@@ -18,3 +32,23 @@ func SetDB(database *sql.DB) {
 func SetStaticFiles(files embed.FS) {
 	StaticFiles = files
 }
+
+// SetTemplates sets the document templates for the handlers to use
+func SetTemplates(files fs.FS) {
+	Templates = files
+}
+
+// SetOverlapPolicy sets the default policy for overlapping time entries
+func SetOverlapPolicy(policy string) {
+	OverlapPolicy = policy
+}
+
+// SetHolidayRegion sets the region of the public holidays
+func SetHolidayRegion(region string) {
+	HolidayRegion = region
+}
+
+// SetTrashRetention sets how long deleted rows stay in the trash
+func SetTrashRetention(retention time.Duration) {
+	TrashRetention = retention
+}
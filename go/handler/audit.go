@@ -0,0 +1,211 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	pkgutil "timesheet/go/util"
+)
+
+// Entity types recorded in the audit log
+const (
+	AuditEntry    = "entry"
+	AuditTask     = "task"
+	AuditCategory = "category"
+	AuditTemplate = "template"
+	AuditClient   = "client"
+	AuditProject  = "project"
+	AuditRate     = "rate"
+	AuditCompany  = "company"
+	AuditRounding = "rounding"
+	AuditSchedule = "schedule"
+	AuditAbsence  = "absence"
+)
+
+// Operations recorded in the audit log
+const (
+	AuditCreate  = "create"
+	AuditUpdate  = "update"
+	AuditDelete  = "delete"
+	AuditRestore = "restore"
+	AuditPurge   = "purge"
+	AuditUndo    = "undo"
+	AuditRedo    = "redo"
+)
+
+// auditTables maps the audited entity types onto their tables
+var auditTables = map[string]string{
+	AuditEntry:    "time_entries",
+	AuditTask:     "tasks",
+	AuditCategory: "categories",
+	AuditTemplate: "recurring_templates",
+	AuditClient:   "clients",
+	AuditProject:  "projects",
+	AuditRate:     "rates",
+	AuditCompany:  "company",
+	AuditRounding: "rounding_rules",
+	AuditSchedule: "work_schedules",
+	AuditAbsence:  "absences",
+}
+
+// auditKeys holds the key column of the audited tables that are not keyed by id
+var auditKeys = map[string]string{
+	AuditRounding: "client_id",
+}
+
+// auditKey returns the column identifying the rows of an entity type
+func auditKey(entityType string) string {
+	if key, ok := auditKeys[entityType]; ok {
+		return key
+	}
+	return "id"
+}
+
+// SnapshotRow returns all columns of a row, including rows in the trash, or nil if it does
+// not exist. Timestamps are formatted the way they are stored. Snapshots of entries include
+// their tags under "tags".
+func SnapshotRow(q Querier, entityType string, id int) (map[string]interface{}, error) {
+	table, ok := auditTables[entityType]
+	if !ok {
+		return nil, fmt.Errorf("unknown entity type '%s'", entityType)
+	}
+
+	rows, err := q.Query("SELECT * FROM "+table+" WHERE "+auditKey(entityType)+" = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	if !rows.Next() {
+		return nil, rows.Err()
+	}
+
+	values := make([]interface{}, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return nil, err
+	}
+
+	row := make(map[string]interface{}, len(columns)+1)
+	for i, column := range columns {
+		switch v := values[i].(type) {
+		case time.Time:
+			row[column] = pkgutil.FormatTimeForDB(v)
+		case []byte:
+			row[column] = string(v)
+		default:
+			row[column] = v
+		}
+	}
+
+	if entityType == AuditEntry {
+		rows.Close()
+		tags, err := queryEntryTags(q, []int{id})
+		if err != nil {
+			return nil, err
+		}
+		row["tags"] = append([]string{}, tags[id]...)
+	}
+	return row, nil
+}
+
+// auditJSON encodes a snapshot for the audit log; a missing row is stored as NULL
+func auditJSON(row map[string]interface{}) (interface{}, error) {
+	if row == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(row)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// RecordAudit stores one change of an entity with its state before and after the change.
+// It should run in the transaction of the change so both are committed together.
+func RecordAudit(q Querier, entityType string, id int, operation string, before, after map[string]interface{}) error {
+	beforeJSON, err := auditJSON(before)
+	if err != nil {
+		return err
+	}
+	afterJSON, err := auditJSON(after)
+	if err != nil {
+		return err
+	}
+
+	result, err := q.Exec(`
+		INSERT INTO audit_log (entity_type, entity_id, operation, before_json, after_json, changed_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		entityType, id, operation, beforeJSON, afterJSON, pkgutil.FormatTimeForDB(pkgutil.CurrentWallClockTime()))
+	if err != nil {
+		return fmt.Errorf("failed to record %s of %s %d: %w", operation, entityType, id, err)
+	}
+
+	// Changes made through a ChangeTx can be undone as a whole
+	if change, ok := q.(*ChangeTx); ok {
+		auditID, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+		change.auditIDs = append(change.auditIDs, auditID)
+	}
+	return nil
+}
+
+// AuditInsert records the creation of a row that has just been inserted
+func AuditInsert(q Querier, entityType string, id int) error {
+	after, err := SnapshotRow(q, entityType, id)
+	if err != nil {
+		return err
+	}
+	return RecordAudit(q, entityType, id, AuditCreate, nil, after)
+}
+
+// AuditedWrite runs write and records the state of the row before and after it. Nothing is
+// recorded if the row did not exist before.
+func AuditedWrite(q Querier, entityType string, id int, operation string, write func() error) error {
+	before, err := SnapshotRow(q, entityType, id)
+	if err != nil {
+		return err
+	}
+	if err := write(); err != nil {
+		return err
+	}
+	if before == nil {
+		return nil
+	}
+
+	after, err := SnapshotRow(q, entityType, id)
+	if err != nil {
+		return err
+	}
+	return RecordAudit(q, entityType, id, operation, before, after)
+}
+
+// matchingIDs returns the IDs of the rows of an entity type matching the condition
+func matchingIDs(q Querier, entityType, condition string, args ...interface{}) ([]int, error) {
+	key := auditKey(entityType)
+	rows, err := q.Query("SELECT "+key+" FROM "+auditTables[entityType]+" WHERE "+condition+" ORDER BY "+key, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
@@ -0,0 +1,253 @@
+package handler
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	pkgmodel "timesheet/go/model"
+	"timesheet/go/xlsx"
+)
+
+// exportColumn describes one column of an export
+type exportColumn struct {
+	Key    string
+	Header string
+	// value returns the cell of an entry row
+	value func(entry pkgmodel.TimeEntry) interface{}
+	// subtotal returns the cell of a per-day subtotal row, or nil to leave it empty
+	subtotal func(date string, minutes int) interface{}
+}
+
+// localTime formats a stored wall-clock time without zone suffix
+func localTime(entry pkgmodel.TimeEntry, end bool) interface{} {
+	if end {
+		if entry.EndTime == nil {
+			return ""
+		}
+		return entry.EndTime.Format("2006-01-02 15:04")
+	}
+	return entry.StartTime.Format("2006-01-02 15:04")
+}
+
+// hours converts minutes into hours rounded to two decimals
+func hours(minutes int) float64 {
+	return math.Round(float64(minutes)/60*100) / 100
+}
+
+// exportColumns lists all columns in their default order
+var exportColumns = []exportColumn{
+	{"date", "Date",
+		func(e pkgmodel.TimeEntry) interface{} { return e.StartTime.Format("2006-01-02") },
+		func(date string, _ int) interface{} { return date }},
+	{"weekday", "Weekday",
+		func(e pkgmodel.TimeEntry) interface{} { return e.StartTime.Weekday().String() },
+		nil},
+	{"task", "Task",
+		func(e pkgmodel.TimeEntry) interface{} { return e.Task },
+		func(string, int) interface{} { return "Subtotal" }},
+	{"category", "Category",
+		func(e pkgmodel.TimeEntry) interface{} { return e.Category },
+		nil},
+	{"description", "Description",
+		func(e pkgmodel.TimeEntry) interface{} { return e.Description },
+		nil},
+	{"duration_minutes", "Duration (Minutes)",
+		func(e pkgmodel.TimeEntry) interface{} { return e.Duration },
+		func(_ string, minutes int) interface{} { return minutes }},
+	{"duration_hours", "Duration (Hours)",
+		func(e pkgmodel.TimeEntry) interface{} { return hours(e.Duration) },
+		func(_ string, minutes int) interface{} { return hours(minutes) }},
+	{"start_time", "Start Time",
+		func(e pkgmodel.TimeEntry) interface{} { return localTime(e, false) },
+		nil},
+	{"end_time", "End Time",
+		func(e pkgmodel.TimeEntry) interface{} { return localTime(e, true) },
+		nil},
+}
+
+// ParseExportColumns resolves a comma separated list of column keys; empty selects all columns
+func ParseExportColumns(value string) ([]exportColumn, error) {
+	if strings.TrimSpace(value) == "" {
+		return exportColumns, nil
+	}
+
+	var columns []exportColumn
+	for _, key := range strings.Split(value, ",") {
+		key = strings.TrimSpace(key)
+		found := false
+		for _, column := range exportColumns {
+			if column.Key == key {
+				columns = append(columns, column)
+				found = true
+				break
+			}
+		}
+		if !found {
+			var keys []string
+			for _, column := range exportColumns {
+				keys = append(keys, column.Key)
+			}
+			return nil, fmt.Errorf("invalid column '%s'. Expected one of %s", key, strings.Join(keys, ", "))
+		}
+	}
+	return columns, nil
+}
+
+// exportEntryRow returns the cells of an entry row
+func exportEntryRow(columns []exportColumn, entry pkgmodel.TimeEntry) []interface{} {
+	row := make([]interface{}, len(columns))
+	for i, column := range columns {
+		row[i] = column.value(entry)
+	}
+	return row
+}
+
+// formulaPrefixes are the leading characters that make spreadsheets evaluate a cell as a formula
+const formulaPrefixes = "=+-@"
+
+// spreadsheetCell quotes text that a spreadsheet would evaluate as a formula with a leading
+// apostrophe, so exported descriptions cannot inject formulas. Numbers are left alone.
+func spreadsheetCell(value interface{}) interface{} {
+	text, ok := value.(string)
+	if ok && text != "" && strings.ContainsRune(formulaPrefixes, rune(text[0])) {
+		return "'" + text
+	}
+	return value
+}
+
+// exportSubtotalRow returns the cells of a per-day subtotal row
+func exportSubtotalRow(columns []exportColumn, date string, minutes int) []interface{} {
+	row := make([]interface{}, len(columns))
+	for i, column := range columns {
+		if column.subtotal != nil {
+			row[i] = column.subtotal(date, minutes)
+		}
+	}
+	return row
+}
+
+// exportWriter writes the rows of an export in one file format
+type exportWriter interface {
+	WriteHeader(columns []exportColumn) error
+	WriteRow(row []interface{}, subtotal bool) error
+	Close() error
+}
+
+// exportFormats maps the accepted formats onto content type, file extension and writer
+var exportFormats = map[string]struct {
+	contentType string
+	extension   string
+	newWriter   func(w io.Writer) (exportWriter, error)
+}{
+	"csv": {"text/csv; charset=utf-8", "csv", func(w io.Writer) (exportWriter, error) {
+		return &csvExportWriter{csv: csv.NewWriter(w)}, nil
+	}},
+	"json": {"application/json", "json", func(w io.Writer) (exportWriter, error) {
+		return &jsonExportWriter{w: w}, nil
+	}},
+	"xlsx": {"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", "xlsx", func(w io.Writer) (exportWriter, error) {
+		sheet, err := xlsx.NewWriter(w, "Time Entries")
+		if err != nil {
+			return nil, err
+		}
+		return &xlsxExportWriter{sheet: sheet}, nil
+	}},
+}
+
+type csvExportWriter struct {
+	csv *csv.Writer
+}
+
+func (e *csvExportWriter) WriteHeader(columns []exportColumn) error {
+	var header []string
+	for _, column := range columns {
+		header = append(header, column.Header)
+	}
+	return e.csv.Write(header)
+}
+
+func (e *csvExportWriter) WriteRow(row []interface{}, subtotal bool) error {
+	record := make([]string, len(row))
+	for i, value := range row {
+		if value != nil {
+			record[i] = fmt.Sprint(spreadsheetCell(value))
+		}
+	}
+	return e.csv.Write(record)
+}
+
+func (e *csvExportWriter) Close() error {
+	e.csv.Flush()
+	return e.csv.Error()
+}
+
+// jsonExportWriter streams a JSON array of objects keyed by column key.
+// Subtotal rows carry "subtotal": true.
+type jsonExportWriter struct {
+	w       io.Writer
+	columns []exportColumn
+	rows    int
+}
+
+func (e *jsonExportWriter) WriteHeader(columns []exportColumn) error {
+	e.columns = columns
+	_, err := io.WriteString(e.w, "[")
+	return err
+}
+
+func (e *jsonExportWriter) WriteRow(row []interface{}, subtotal bool) error {
+	object := make(map[string]interface{}, len(row)+1)
+	for i, value := range row {
+		if value != nil {
+			object[e.columns[i].Key] = value
+		}
+	}
+	if subtotal {
+		object["subtotal"] = true
+	}
+	data, err := json.Marshal(object)
+	if err != nil {
+		return err
+	}
+	if e.rows > 0 {
+		if _, err := io.WriteString(e.w, ","); err != nil {
+			return err
+		}
+	}
+	e.rows++
+	_, err = e.w.Write(data)
+	return err
+}
+
+func (e *jsonExportWriter) Close() error {
+	_, err := io.WriteString(e.w, "]\n")
+	return err
+}
+
+type xlsxExportWriter struct {
+	sheet *xlsx.Writer
+}
+
+func (e *xlsxExportWriter) WriteHeader(columns []exportColumn) error {
+	header := make([]interface{}, len(columns))
+	for i, column := range columns {
+		header[i] = column.Header
+	}
+	return e.sheet.WriteRow(header)
+}
+
+func (e *xlsxExportWriter) WriteRow(row []interface{}, subtotal bool) error {
+	cells := make([]interface{}, len(row))
+	for i, value := range row {
+		cells[i] = spreadsheetCell(value)
+	}
+	return e.sheet.WriteRow(cells)
+}
+
+func (e *xlsxExportWriter) Close() error {
+	return e.sheet.Close()
+}
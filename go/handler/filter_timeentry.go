@@ -0,0 +1,216 @@
+package handler
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxTimeEntryLimit is the largest page size accepted by the entries listing
+const MaxTimeEntryLimit = 1000
+
+// sortColumns maps the accepted sort keys onto their database columns
+var sortColumns = map[string]string{
+	"start_time": "start_time",
+	"duration":   "duration",
+}
+
+// TimeEntryFilter holds the validated query parameters of GET /api/entries
+type TimeEntryFilter struct {
+	From       time.Time
+	To         time.Time
+	Category   string
+	Task       string
+	Query      string
+	Tags       []string
+	Limit      int
+	Cursor     *TimeEntryCursor
+	SortColumn string
+	SortDesc   bool
+}
+
+// TimeEntryCursor marks the last row of a page for keyset pagination
+type TimeEntryCursor struct {
+	Value string `json:"v"`
+	ID    int    `json:"id"`
+}
+
+// EncodeCursor serializes a cursor into an opaque URL-safe token
+func EncodeCursor(c TimeEntryCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses a token created by EncodeCursor
+func DecodeCursor(token string) (*TimeEntryCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	var c TimeEntryCursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID <= 0 {
+		return nil, errors.New("invalid cursor")
+	}
+	return &c, nil
+}
+
+// ParseDateParam parses a date (YYYY-MM-DD) or an RFC3339 timestamp. Plain dates
+// are taken as UTC because the frontend stores wall-clock times with a Z suffix.
+// The returned flag reports whether the value was a plain date.
+func ParseDateParam(value string) (time.Time, bool, error) {
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("invalid date '%s'. Expected YYYY-MM-DD or ISO timestamp", value)
+	}
+	return t, false, nil
+}
+
+// ParseDateRange parses the from/to query parameters. A plain `to` date is
+// inclusive, so the returned upper bound is the start of the following day.
+func ParseDateRange(query url.Values) (from, to time.Time, err error) {
+	if v := query.Get("from"); v != "" {
+		if from, _, err = ParseDateParam(v); err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+	}
+	if v := query.Get("to"); v != "" {
+		var isDate bool
+		if to, isDate, err = ParseDateParam(v); err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		if isDate {
+			to = to.AddDate(0, 0, 1)
+		}
+	}
+	if !from.IsZero() && !to.IsZero() && !to.After(from) {
+		return time.Time{}, time.Time{}, errors.New("'to' must be after 'from'")
+	}
+	return from, to, nil
+}
+
+// ParseTimeEntryFilter validates the query parameters of the entries listing
+func ParseTimeEntryFilter(query url.Values) (TimeEntryFilter, error) {
+	f := TimeEntryFilter{
+		Category:   query.Get("category"),
+		Task:       query.Get("task"),
+		Query:      strings.TrimSpace(query.Get("q")),
+		SortColumn: "start_time",
+		SortDesc:   true,
+	}
+
+	var err error
+	if f.From, f.To, err = ParseDateRange(query); err != nil {
+		return f, err
+	}
+	if f.Tags, err = NormalizeTags(query["tag"]); err != nil {
+		return f, err
+	}
+
+	if v := query.Get("limit"); v != "" {
+		f.Limit, err = strconv.Atoi(v)
+		if err != nil || f.Limit < 1 || f.Limit > MaxTimeEntryLimit {
+			return f, fmt.Errorf("invalid limit '%s'. Expected a number between 1 and %d", v, MaxTimeEntryLimit)
+		}
+	}
+
+	if v := query.Get("sort"); v != "" {
+		key := strings.TrimPrefix(v, "-")
+		column, ok := sortColumns[key]
+		if !ok {
+			return f, fmt.Errorf("invalid sort '%s'. Expected start_time or duration, optionally prefixed with '-'", v)
+		}
+		f.SortColumn = column
+		f.SortDesc = strings.HasPrefix(v, "-")
+	}
+
+	if v := query.Get("cursor"); v != "" {
+		if f.Limit == 0 {
+			return f, errors.New("cursor requires a limit")
+		}
+		if f.Cursor, err = DecodeCursor(v); err != nil {
+			return f, err
+		}
+		if f.SortColumn == "duration" {
+			if _, err := strconv.Atoi(f.Cursor.Value); err != nil {
+				return f, errors.New("invalid cursor")
+			}
+		}
+	}
+
+	return f, nil
+}
+
+// WhereClause builds the SQL condition and arguments for the filter.
+// The cursor condition is only included when withCursor is set, so the
+// same clause can be used to count the total number of matches.
+func (f TimeEntryFilter) WhereClause(withCursor bool) (string, []interface{}) {
+	// Entries in the trash are never listed
+	conditions := []string{"deleted_at IS NULL"}
+	var args []interface{}
+
+	if !f.From.IsZero() {
+		conditions = append(conditions, "datetime(start_time) >= datetime(?)")
+		args = append(args, f.From.UTC().Format(time.RFC3339))
+	}
+	if !f.To.IsZero() {
+		conditions = append(conditions, "datetime(start_time) < datetime(?)")
+		args = append(args, f.To.UTC().Format(time.RFC3339))
+	}
+	if f.Category != "" {
+		conditions = append(conditions, "category = ?")
+		args = append(args, f.Category)
+	}
+	if f.Task != "" {
+		conditions = append(conditions, "task = ?")
+		args = append(args, f.Task)
+	}
+	if f.Query != "" {
+		pattern := likePattern(f.Query)
+		conditions = append(conditions, `(task LIKE ? ESCAPE '\' OR description LIKE ? ESCAPE '\' OR category LIKE ? ESCAPE '\')`)
+		args = append(args, pattern, pattern, pattern)
+	}
+	for _, tag := range f.Tags {
+		// Entries must carry all given tags
+		conditions = append(conditions, "id IN (SELECT et.entry_id FROM time_entry_tags et JOIN tags t ON t.id = et.tag_id WHERE t.name = ?)")
+		args = append(args, tag)
+	}
+	if withCursor && f.Cursor != nil {
+		op := ">"
+		if f.SortDesc {
+			op = "<"
+		}
+		var value interface{} = f.Cursor.Value
+		if f.SortColumn == "duration" {
+			value, _ = strconv.Atoi(f.Cursor.Value)
+		}
+		conditions = append(conditions, fmt.Sprintf("(%[1]s %[2]s ? OR (%[1]s = ? AND id %[2]s ?))", f.SortColumn, op))
+		args = append(args, value, value, f.Cursor.ID)
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// likeEscaper escapes the wildcards of LIKE so a search matches its text literally
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// likePattern returns a LIKE pattern matching values that contain text
+func likePattern(text string) string {
+	return "%" + likeEscaper.Replace(text) + "%"
+}
+
+// OrderClause returns the ORDER BY clause matching the keyset cursor
+func (f TimeEntryFilter) OrderClause() string {
+	direction := "ASC"
+	if f.SortDesc {
+		direction = "DESC"
+	}
+	return fmt.Sprintf(" ORDER BY %[1]s %[2]s, id %[2]s", f.SortColumn, direction)
+}
@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTimeEntryFilterDefaults(t *testing.T) {
+	f, err := ParseTimeEntryFilter(url.Values{})
+
+	require.NoError(t, err)
+	assert.Equal(t, "start_time", f.SortColumn)
+	assert.True(t, f.SortDesc)
+	assert.Zero(t, f.Limit)
+	assert.Nil(t, f.Cursor)
+
+	where, args := f.WhereClause(true)
+	assert.Equal(t, " WHERE deleted_at IS NULL", where)
+	assert.Empty(t, args)
+	assert.Equal(t, " ORDER BY start_time DESC, id DESC", f.OrderClause())
+}
+
+func TestParseTimeEntryFilterInclusiveToDate(t *testing.T) {
+	f, err := ParseTimeEntryFilter(url.Values{"from": {"2025-11-01"}, "to": {"2025-11-30"}})
+
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 11, 1, 0, 0, 0, 0, time.UTC), f.From)
+	assert.Equal(t, time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC), f.To)
+}
+
+func TestParseTimeEntryFilterErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    url.Values
+		errorMsg string
+	}{
+		{"invalid from", url.Values{"from": {"yesterday"}}, "invalid date 'yesterday'"},
+		{"to before from", url.Values{"from": {"2025-11-10"}, "to": {"2025-11-01"}}, "'to' must be after 'from'"},
+		{"limit zero", url.Values{"limit": {"0"}}, "invalid limit"},
+		{"limit too large", url.Values{"limit": {"5000"}}, "invalid limit"},
+		{"unknown sort", url.Values{"sort": {"task"}}, "invalid sort"},
+		{"cursor without limit", url.Values{"cursor": {EncodeCursor(TimeEntryCursor{Value: "x", ID: 1})}}, "cursor requires a limit"},
+		{"garbage cursor", url.Values{"limit": {"10"}, "cursor": {"!!"}}, "invalid cursor"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseTimeEntryFilter(tt.query)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errorMsg)
+		})
+	}
+}
+
+func TestTimeEntryFilterWhereClause(t *testing.T) {
+	cursor := EncodeCursor(TimeEntryCursor{Value: "45", ID: 7})
+	f, err := ParseTimeEntryFilter(url.Values{
+		"category": {"project work"},
+		"q":        {"review"},
+		"limit":    {"20"},
+		"sort":     {"duration"},
+		"cursor":   {cursor},
+	})
+	require.NoError(t, err)
+
+	where, args := f.WhereClause(false)
+	assert.Equal(t, ` WHERE deleted_at IS NULL AND category = ? AND (task LIKE ? ESCAPE '\' OR description LIKE ? ESCAPE '\' OR category LIKE ? ESCAPE '\')`, where)
+	assert.Equal(t, []interface{}{"project work", "%review%", "%review%", "%review%"}, args)
+
+	where, args = f.WhereClause(true)
+	assert.Contains(t, where, "(duration > ? OR (duration = ? AND id > ?))")
+	assert.Equal(t, []interface{}{45, 45, 7}, args[4:])
+	assert.Equal(t, " ORDER BY duration ASC, id ASC", f.OrderClause())
+}
+
+func TestTimeEntryFilterQueryMatchesWildcardsLiterally(t *testing.T) {
+	db := setupHandlerTestDB(t)
+	insertTestEntry(t, db, "Discount 50%", "project work", "2025-11-10T09:00:00Z", "2025-11-10T10:00:00Z", 60)
+	insertTestEntry(t, db, "Import 500 rows", "project work", "2025-11-10T10:00:00Z", "2025-11-10T11:00:00Z", 60)
+	insertTestEntry(t, db, "fix_login", "project work", "2025-11-10T11:00:00Z", "2025-11-10T12:00:00Z", 60)
+	insertTestEntry(t, db, "fix login", "project work", "2025-11-10T12:00:00Z", "2025-11-10T13:00:00Z", 60)
+
+	search := func(q string) []string {
+		f, err := ParseTimeEntryFilter(url.Values{"q": {q}})
+		require.NoError(t, err)
+		where, args := f.WhereClause(false)
+		rows, err := db.Query("SELECT task FROM time_entries"+where+" ORDER BY id", args...)
+		require.NoError(t, err)
+		defer rows.Close()
+		var tasks []string
+		for rows.Next() {
+			var task string
+			require.NoError(t, rows.Scan(&task))
+			tasks = append(tasks, task)
+		}
+		return tasks
+	}
+
+	f, err := ParseTimeEntryFilter(url.Values{"q": {"50%"}})
+	require.NoError(t, err)
+	_, args := f.WhereClause(false)
+	assert.Equal(t, `%50\%%`, args[0])
+
+	assert.Equal(t, []string{"Discount 50%"}, search("50%"))
+	assert.Equal(t, []string{"fix_login"}, search("fix_"))
+	assert.Equal(t, []string{"fix_login", "fix login"}, search("fix"))
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	original := TimeEntryCursor{Value: "2025-11-09T09:00:00Z", ID: 42}
+
+	decoded, err := DecodeCursor(EncodeCursor(original))
+
+	require.NoError(t, err)
+	assert.Equal(t, original, *decoded)
+}
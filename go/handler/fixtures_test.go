@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+
+	"timesheet/go/testdb"
+)
+
+// setupHandlerTestDB creates an in-memory database with the real schema, which includes the
+// default categories, and makes it the global connection
+func setupHandlerTestDB(t *testing.T) *sql.DB {
+	return testdb.OpenGlobal(t)
+}
+
+// insertTestEntry stores a finished time entry
+func insertTestEntry(t *testing.T, db *sql.DB, task, category, start, end string, duration int) {
+	_, err := db.Exec(`INSERT INTO time_entries (task, category, start_time, end_time, duration, date)
+		VALUES (?, ?, ?, ?, ?, '2025-11-09')`, task, category, start, end, duration)
+	require.NoError(t, err)
+}
+
+// sendJSON calls a handler in the test session with id as the {id} route variable. A string
+// body is sent as is, so malformed JSON can be tested; nil sends no body and anything else is
+// encoded as JSON.
+func sendJSON(t *testing.T, handler http.HandlerFunc, method, target, id string, body interface{}) *httptest.ResponseRecorder {
+	var data []byte
+	switch body := body.(type) {
+	case nil:
+	case string:
+		data = []byte(body)
+	default:
+		var err error
+		data, err = json.Marshal(body)
+		require.NoError(t, err)
+	}
+	req := httptest.NewRequest(method, target, bytes.NewReader(data))
+	req = mux.SetURLVars(req, map[string]string{"id": id})
+	req.Header.Set("X-Session-ID", testSession)
+	w := httptest.NewRecorder()
+	handler(w, req)
+	return w
+}
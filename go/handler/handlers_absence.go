@@ -0,0 +1,362 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	pkgglobal "timesheet/go/global"
+	"timesheet/go/holiday"
+	pkgmodel "timesheet/go/model"
+	pkgutil "timesheet/go/util"
+
+	"github.com/gorilla/mux"
+)
+
+// Absence types
+const (
+	AbsenceVacation = "vacation"
+	AbsenceSick     = "sick"
+	AbsenceOther    = "other"
+)
+
+const absenceColumns = "id, type, start_date, end_date, half_day, note"
+
+// queryAbsences reads the absences overlapping the days from first to last, ordered by start
+// date. Empty bounds are open.
+func queryAbsences(q Querier, first, last string) ([]pkgmodel.Absence, error) {
+	var conditions []string
+	var args []interface{}
+	if first != "" {
+		conditions = append(conditions, "end_date >= ?")
+		args = append(args, first)
+	}
+	if last != "" {
+		conditions = append(conditions, "start_date <= ?")
+		args = append(args, last)
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	rows, err := q.Query("SELECT "+absenceColumns+" FROM absences"+where+" ORDER BY start_date, id", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	absences := []pkgmodel.Absence{}
+	for rows.Next() {
+		var a pkgmodel.Absence
+		if err := rows.Scan(&a.ID, &a.Type, &a.StartDate, &a.EndDate, &a.HalfDay, &a.Note); err != nil {
+			return nil, err
+		}
+		absences = append(absences, a)
+	}
+	return absences, rows.Err()
+}
+
+// validateAbsence checks an absence request and returns the absence to store
+func validateAbsence(req pkgmodel.AbsenceRequest) (pkgmodel.Absence, error) {
+	a := pkgmodel.Absence{
+		Type:      req.Type,
+		StartDate: req.StartDate,
+		EndDate:   req.EndDate,
+		HalfDay:   req.HalfDay,
+		Note:      strings.TrimSpace(req.Note),
+	}
+	switch a.Type {
+	case AbsenceVacation, AbsenceSick, AbsenceOther:
+	default:
+		return a, fmt.Errorf("invalid type '%s'. Expected vacation, sick or other", a.Type)
+	}
+	if a.EndDate == "" {
+		a.EndDate = a.StartDate
+	}
+	start, err := time.Parse("2006-01-02", a.StartDate)
+	if err != nil {
+		return a, fmt.Errorf("invalid start_date '%s'. Expected YYYY-MM-DD", a.StartDate)
+	}
+	end, err := time.Parse("2006-01-02", a.EndDate)
+	if err != nil {
+		return a, fmt.Errorf("invalid end_date '%s'. Expected YYYY-MM-DD", a.EndDate)
+	}
+	if end.Before(start) {
+		return a, fmt.Errorf("end_date must not be before start_date")
+	}
+	return a, nil
+}
+
+// RequestHolidayRegion returns the region given by the `region` query parameter, falling
+// back to the configured region. Empty means no public holidays.
+func RequestHolidayRegion(value string) (string, error) {
+	if value == "" {
+		value = pkgglobal.HolidayRegion
+	}
+	if value != "" && !holiday.ValidRegion(value) {
+		return "", fmt.Errorf("invalid region '%s'. Expected one of %s", value, strings.Join(holiday.Regions(), ", "))
+	}
+	return value, nil
+}
+
+// dayOff holds the public holiday and the absences of a day
+type dayOff struct {
+	holiday  string
+	absences []string
+	// halves counts the half days of absence, a full-day absence counts two
+	halves int
+}
+
+// fullDay reports whether the day is a public holiday or a full day of absence
+func (d *dayOff) fullDay() bool {
+	return d.holiday != "" || d.halves >= 2
+}
+
+// queryDaysOff collects the public holidays of the region and the absences on the days from
+// first to last, by date
+func queryDaysOff(q Querier, region string, first, last time.Time) (map[string]*dayOff, error) {
+	days := map[string]*dayOff{}
+	day := func(date string) *dayOff {
+		if days[date] == nil {
+			days[date] = &dayOff{}
+		}
+		return days[date]
+	}
+
+	if region != "" {
+		for year := first.Year(); year <= last.Year(); year++ {
+			holidays, err := holiday.Calendar(region, year)
+			if err != nil {
+				return nil, err
+			}
+			for _, h := range holidays {
+				if !h.Date.Before(first) && !h.Date.After(last) {
+					day(h.Date.Format("2006-01-02")).holiday = h.Name
+				}
+			}
+		}
+	}
+
+	absences, err := queryAbsences(q, first.Format("2006-01-02"), last.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range absences {
+		start, _ := time.Parse("2006-01-02", a.StartDate)
+		end, _ := time.Parse("2006-01-02", a.EndDate)
+		if start.Before(first) {
+			start = first
+		}
+		if end.After(last) {
+			end = last
+		}
+		for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+			off := day(d.Format("2006-01-02"))
+			off.absences = append(off.absences, a.Type)
+			if a.HalfDay {
+				off.halves++
+			} else {
+				off.halves += 2
+			}
+		}
+	}
+	return days, nil
+}
+
+// Absence handlers
+
+// GetAbsences lists the absences overlapping the optional from/to dates
+func GetAbsences(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	query := r.URL.Query()
+	for _, param := range []string{"from", "to"} {
+		if v := query.Get(param); v != "" {
+			if _, err := time.Parse("2006-01-02", v); err != nil {
+				http.Error(w, fmt.Sprintf("invalid %s '%s'. Expected YYYY-MM-DD", param, v), http.StatusBadRequest)
+				return
+			}
+		}
+	}
+
+	absences, err := queryAbsences(pkgglobal.Db, query.Get("from"), query.Get("to"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(absences)
+}
+
+// writeAbsence validates an absence and inserts it, or updates the absence with the given ID
+func writeAbsence(w http.ResponseWriter, r *http.Request, id int) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req pkgmodel.AbsenceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	a, err := validateAbsence(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tx, err := BeginChange()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	action := "UPDATE: Modified"
+	if id == 0 {
+		action = "INSERT: Created"
+		result, err := tx.Exec("INSERT INTO absences (type, start_date, end_date, half_day, note) VALUES (?, ?, ?, ?, ?)",
+			a.Type, a.StartDate, a.EndDate, a.HalfDay, a.Note)
+		if err == nil {
+			newID, _ := result.LastInsertId()
+			id = int(newID)
+			err = AuditInsert(tx, AuditAbsence, id)
+		}
+		if err != nil {
+			log.Printf("ERROR: Failed to insert absence - Type: %s, %s to %s - Error: %v", a.Type, a.StartDate, a.EndDate, err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	} else {
+		var exists bool
+		if err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM absences WHERE id = ?)", id).Scan(&exists); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !exists {
+			http.Error(w, "Absence not found", http.StatusNotFound)
+			return
+		}
+
+		err := AuditedWrite(tx, AuditAbsence, id, AuditUpdate, func() error {
+			_, err := tx.Exec("UPDATE absences SET type = ?, start_date = ?, end_date = ?, half_day = ?, note = ? WHERE id = ?",
+				a.Type, a.StartDate, a.EndDate, a.HalfDay, a.Note, id)
+			return err
+		})
+		if err != nil {
+			log.Printf("ERROR: Failed to update absence ID %d - Error: %v", id, err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := tx.Commit(w, r); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("%s absence ID %d - Type: %s, %s to %s", action, id, a.Type, a.StartDate, a.EndDate)
+	a.ID = id
+
+	json.NewEncoder(w).Encode(a)
+}
+
+func CreateAbsence(w http.ResponseWriter, r *http.Request) {
+	writeAbsence(w, r, 0)
+}
+
+func UpdateAbsence(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || id <= 0 {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+	writeAbsence(w, r, id)
+}
+
+func DeleteAbsence(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := BeginChange()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	var absenceType string
+	err = tx.QueryRow("SELECT type FROM absences WHERE id = ?", id).Scan(&absenceType)
+	if err == sql.ErrNoRows {
+		log.Printf("WARNING: Attempted to delete non-existent absence ID %d", id)
+		http.Error(w, "Absence not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = AuditedWrite(tx, AuditAbsence, id, AuditDelete, func() error {
+		_, err := tx.Exec("DELETE FROM absences WHERE id = ?", id)
+		return err
+	})
+	if err != nil {
+		log.Printf("ERROR: Failed to delete absence ID %d - Error: %v", id, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(w, r); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("DELETE: Deleted %s absence ID %d", absenceType, id)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetHolidays lists the public holidays of a year (default: the current year) in the given
+// or configured region
+func GetHolidays(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	query := r.URL.Query()
+	region, err := RequestHolidayRegion(query.Get("region"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if region == "" {
+		http.Error(w, "region is required when no holiday region is configured", http.StatusBadRequest)
+		return
+	}
+
+	year := pkgutil.CurrentWallClockTime().Year()
+	if v := query.Get("year"); v != "" {
+		if year, err = strconv.Atoi(v); err != nil || year < 1900 || year > 2999 {
+			http.Error(w, fmt.Sprintf("invalid year '%s'", v), http.StatusBadRequest)
+			return
+		}
+	}
+
+	holidays, err := holiday.Calendar(region, year)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	result := []pkgmodel.Holiday{}
+	for _, h := range holidays {
+		result = append(result, pkgmodel.Holiday{Date: h.Date.Format("2006-01-02"), Name: h.Name})
+	}
+
+	json.NewEncoder(w).Encode(result)
+}
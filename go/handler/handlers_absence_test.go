@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pkgglobal "timesheet/go/global"
+	pkgmodel "timesheet/go/model"
+)
+
+// useHolidayRegion configures the region of the public holidays for one test
+func useHolidayRegion(t *testing.T, region string) {
+	previous := pkgglobal.HolidayRegion
+	pkgglobal.SetHolidayRegion(region)
+	t.Cleanup(func() { pkgglobal.SetHolidayRegion(previous) })
+}
+
+func createAbsence(t *testing.T, absence pkgmodel.AbsenceRequest) {
+	w := sendJSON(t, CreateAbsence, http.MethodPost, "/api/absences", "", absence)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+}
+
+func TestAbsenceCRUD(t *testing.T) {
+	setupHandlerTestDB(t)
+
+	w := sendJSON(t, CreateAbsence, http.MethodPost, "/api/absences", "", pkgmodel.AbsenceRequest{Type: AbsenceSick, StartDate: "2025-11-12"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var created pkgmodel.Absence
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&created))
+	assert.Equal(t, pkgmodel.Absence{ID: 1, Type: AbsenceSick, StartDate: "2025-11-12", EndDate: "2025-11-12"}, created)
+
+	vacation := pkgmodel.AbsenceRequest{Type: AbsenceVacation, StartDate: "2025-12-22", EndDate: "2026-01-02", Note: "Christmas"}
+	createAbsence(t, vacation)
+
+	for _, invalid := range []pkgmodel.AbsenceRequest{
+		{Type: "holiday", StartDate: "2025-11-12"},
+		{Type: AbsenceOther, StartDate: "12.11.2025"},
+		{Type: AbsenceOther, StartDate: "2025-11-12", EndDate: "2025-11-11"},
+	} {
+		assert.Equal(t, http.StatusBadRequest, sendJSON(t, CreateAbsence, http.MethodPost, "/api/absences", "", invalid).Code, invalid)
+	}
+	assert.Equal(t, http.StatusNotFound, sendJSON(t, UpdateAbsence, http.MethodPut, "/api/absences/9", "9", vacation).Code)
+
+	// Absences overlapping the range
+	w = sendJSON(t, GetAbsences, http.MethodGet, "/api/absences?from=2026-01-01&to=2026-01-31", "", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var absences []pkgmodel.Absence
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&absences))
+	require.Len(t, absences, 1)
+	assert.Equal(t, "Christmas", absences[0].Note)
+
+	w = sendJSON(t, GetAuditLog, http.MethodGet, "/api/audit?entity_type=absence", "", nil)
+	assert.Contains(t, w.Body.String(), `"entity_id":2`)
+
+	assert.Equal(t, http.StatusNoContent, sendJSON(t, DeleteAbsence, http.MethodDelete, "/api/absences/1", "1", nil).Code)
+	assert.Equal(t, http.StatusNotFound, sendJSON(t, DeleteAbsence, http.MethodDelete, "/api/absences/1", "1", nil).Code)
+}
+
+func TestGetHolidays(t *testing.T) {
+	setupHandlerTestDB(t)
+
+	w := sendJSON(t, GetHolidays, http.MethodGet, "/api/holidays?year=2025", "", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	useHolidayRegion(t, "DE-BY")
+	w = sendJSON(t, GetHolidays, http.MethodGet, "/api/holidays?year=2025", "", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var holidays []pkgmodel.Holiday
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&holidays))
+	assert.Len(t, holidays, 12)
+	assert.Contains(t, holidays, pkgmodel.Holiday{Date: "2025-06-19", Name: "Corpus Christi"})
+
+	w = sendJSON(t, GetHolidays, http.MethodGet, "/api/holidays?year=2025&region=DE-XX", "", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestBalanceWithAbsencesAndHolidays(t *testing.T) {
+	db := setupHandlerTestDB(t)
+	useHolidayRegion(t, "DE-BY")
+	schedule := pkgmodel.WorkScheduleRequest{EffectiveFrom: "2025-01-01", Monday: 480, Tuesday: 480, Wednesday: 480, Thursday: 480, Friday: 480}
+	require.Equal(t, http.StatusOK, sendJSON(t, CreateSchedule, http.MethodPost, "/api/schedules", "", schedule).Code)
+	createAbsence(t, pkgmodel.AbsenceRequest{Type: AbsenceVacation, StartDate: "2025-06-16"})
+	createAbsence(t, pkgmodel.AbsenceRequest{Type: AbsenceSick, StartDate: "2025-06-17", HalfDay: true})
+	insertTestEntry(t, db, "Development", "project work", "2025-06-17T08:00:00Z", "2025-06-17T12:00:00Z", 240)
+	insertTestEntry(t, db, "Development", "project work", "2025-06-18T08:00:00Z", "2025-06-18T16:00:00Z", 480)
+	insertTestEntry(t, db, "Development", "project work", "2025-06-20T08:00:00Z", "2025-06-20T16:20:00Z", 500)
+
+	// Corpus Christi on Thursday is a holiday in Bavaria
+	w, report := getBalanceReport(t, "from=2025-06-16&to=2025-06-20")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "DE-BY", report.Region)
+	assert.Equal(t, []pkgmodel.BalanceDay{
+		{Date: "2025-06-16", Absence: "vacation", TargetMinutes: 480, AbsenceMinutes: 480},
+		{Date: "2025-06-17", Absence: "sick", TargetMinutes: 480, BookedMinutes: 240, AbsenceMinutes: 240},
+		{Date: "2025-06-18", TargetMinutes: 480, BookedMinutes: 480},
+		{Date: "2025-06-19", Holiday: "Corpus Christi"},
+		{Date: "2025-06-20", TargetMinutes: 480, BookedMinutes: 500, DeltaMinutes: 20, BalanceMinutes: 20},
+	}, report.Days)
+	assert.Equal(t, 720, report.AbsenceMinutes)
+	assert.Equal(t, 20, report.BalanceMinutes)
+
+	// Corpus Christi is no nationwide holiday
+	_, report = getBalanceReport(t, "from=2025-06-16&to=2025-06-20&region=DE")
+	assert.Equal(t, 20-480, report.BalanceMinutes)
+
+	w, _ = getBalanceReport(t, "region=Bavaria")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestApplyTemplatesSkipsDaysOff(t *testing.T) {
+	setupHandlerTestDB(t)
+	useHolidayRegion(t, "DE")
+	require.Equal(t, http.StatusOK, sendJSON(t, CreateTemplate, http.MethodPost, "/api/templates", "", dailyTemplate).Code)
+	createAbsence(t, pkgmodel.AbsenceRequest{Type: AbsenceVacation, StartDate: "2025-12-22", EndDate: "2025-12-23"})
+	createAbsence(t, pkgmodel.AbsenceRequest{Type: AbsenceOther, StartDate: "2025-12-24", HalfDay: true})
+
+	result := applyTemplates(t, "2025-12-22")
+	assert.Empty(t, result.Created)
+	assert.Equal(t, "absent", result.Skipped[0].Reason)
+	result = applyTemplates(t, "2025-12-25")
+	assert.Empty(t, result.Created)
+	assert.Equal(t, "public holiday", result.Skipped[0].Reason)
+	// Half days off still book the template
+	assert.Len(t, applyTemplates(t, "2025-12-24").Created, 1)
+}
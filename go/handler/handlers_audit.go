@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	pkgglobal "timesheet/go/global"
+	pkgmodel "timesheet/go/model"
+
+	"github.com/gorilla/mux"
+)
+
+// queryAuditLog returns the audit records matching the condition in chronological order
+func queryAuditLog(q Querier, condition string, args ...interface{}) ([]pkgmodel.AuditRecord, error) {
+	rows, err := q.Query(`
+		SELECT id, entity_type, entity_id, operation, before_json, after_json, changed_at
+		FROM audit_log`+condition+`
+		ORDER BY datetime(changed_at), id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := []pkgmodel.AuditRecord{}
+	for rows.Next() {
+		var record pkgmodel.AuditRecord
+		var before, after sql.NullString
+		var changedAt string
+		err := rows.Scan(&record.ID, &record.EntityType, &record.EntityID, &record.Operation, &before, &after, &changedAt)
+		if err != nil {
+			return nil, err
+		}
+		if before.Valid {
+			record.Before = json.RawMessage(before.String)
+		}
+		if after.Valid {
+			record.After = json.RawMessage(after.String)
+		}
+		if record.ChangedAt, err = time.Parse(time.RFC3339, changedAt); err != nil {
+			log.Printf("Error parsing changed_at '%s': %v", changedAt, err)
+		}
+		records = append(records, record)
+	}
+	return records, rows.Err()
+}
+
+// GetTimeEntryHistory lists all recorded changes of one time entry, including entries in the
+// trash and purged ones
+func GetTimeEntryHistory(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	records, err := queryAuditLog(pkgglobal.Db, " WHERE entity_type = ? AND entity_id = ?", AuditEntry, id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if len(records) == 0 {
+		// Entries written before the audit log existed have no history yet
+		entry, err := SnapshotRow(pkgglobal.Db, AuditEntry, id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if entry == nil {
+			http.Error(w, "Time entry not found", http.StatusNotFound)
+			return
+		}
+	}
+
+	json.NewEncoder(w).Encode(records)
+}
+
+// GetAuditLog lists the changes of all entities within the optional from/to range and
+// optionally of one entity_type (entry, task, category, template, client, project, rate, company, rounding, schedule or absence)
+func GetAuditLog(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	query := r.URL.Query()
+	from, to, err := ParseDateRange(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var conditions []string
+	var args []interface{}
+	if !from.IsZero() {
+		conditions = append(conditions, "datetime(changed_at) >= datetime(?)")
+		args = append(args, from.Format(time.RFC3339))
+	}
+	if !to.IsZero() {
+		conditions = append(conditions, "datetime(changed_at) < datetime(?)")
+		args = append(args, to.Format(time.RFC3339))
+	}
+	if entityType := query.Get("entity_type"); entityType != "" {
+		if _, ok := auditTables[entityType]; !ok {
+			http.Error(w, "Invalid entity_type. Expected entry, task, category, template, client, project, rate, company, rounding, schedule or absence", http.StatusBadRequest)
+			return
+		}
+		conditions = append(conditions, "entity_type = ?")
+		args = append(args, entityType)
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	records, err := queryAuditLog(pkgglobal.Db, where, args...)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(records)
+}
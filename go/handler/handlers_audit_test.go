@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pkgmodel "timesheet/go/model"
+)
+
+// testSession is the undo session of requests sent by the tests
+const testSession = "test-session"
+
+// getHistory calls GetTimeEntryHistory for the given ID
+func getHistory(id string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/api/entries/"+id+"/history", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": id})
+	w := httptest.NewRecorder()
+	GetTimeEntryHistory(w, req)
+	return w
+}
+
+func TestTimeEntryHistoryRecordsEveryWrite(t *testing.T) {
+	setupHandlerTestDB(t)
+	entry := pkgmodel.TimeEntryRequest{Task: "Development", Category: "project work",
+		StartTime: "2025-11-10T09:00:00Z", EndTime: "2025-11-10T10:00:00Z"}
+
+	require.Equal(t, http.StatusOK, sendJSON(t, CreateTimeEntry, http.MethodPost, "/api/entries", "", entry).Code)
+	entry.Category = "project support"
+	require.Equal(t, http.StatusOK, sendJSON(t, UpdateTimeEntry, http.MethodPut, "/api/entries/1", "1", entry).Code)
+	require.Equal(t, http.StatusNoContent, deleteTimeEntry("1").Code)
+
+	w := getHistory("1")
+	require.Equal(t, http.StatusOK, w.Code)
+	var records []pkgmodel.AuditRecord
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&records))
+	require.Len(t, records, 3)
+
+	assert.Equal(t, []string{"create", "update", "delete"},
+		[]string{records[0].Operation, records[1].Operation, records[2].Operation})
+	assert.Equal(t, "null", string(records[0].Before))
+
+	var before, after map[string]interface{}
+	require.NoError(t, json.Unmarshal(records[1].Before, &before))
+	require.NoError(t, json.Unmarshal(records[1].After, &after))
+	assert.Equal(t, "project work", before["category"])
+	assert.Equal(t, "project support", after["category"])
+	assert.Equal(t, "2025-11-10T09:00:00Z", after["start_time"])
+
+	require.NoError(t, json.Unmarshal(records[2].After, &after))
+	assert.NotNil(t, after["deleted_at"])
+}
+
+func TestTimeEntryHistoryOfUnknownEntry(t *testing.T) {
+	db := setupHandlerTestDB(t)
+	insertTestEntry(t, db, "Development", "project work", "2025-11-10T09:00:00Z", "2025-11-10T10:00:00Z", 60)
+
+	// Entries written before the audit log existed have an empty history
+	w := getHistory("1")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, "[]", w.Body.String())
+
+	assert.Equal(t, http.StatusNotFound, getHistory("2").Code)
+}
+
+func TestGetAuditLogFiltersByRange(t *testing.T) {
+	db := setupHandlerTestDB(t)
+	_, err := db.Exec(`
+		INSERT INTO audit_log (entity_type, entity_id, operation, after_json, changed_at) VALUES
+			('entry', 1, 'create', '{}', '2025-10-31T23:00:00Z'),
+			('entry', 1, 'update', '{}', '2025-11-15T12:00:00Z'),
+			('task', 3, 'create', '{}', '2025-11-30T18:00:00Z'),
+			('entry', 2, 'create', '{}', '2025-12-01T08:00:00Z')`)
+	require.NoError(t, err)
+
+	query := func(params string) []pkgmodel.AuditRecord {
+		w := httptest.NewRecorder()
+		GetAuditLog(w, httptest.NewRequest(http.MethodGet, "/api/audit"+params, nil))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var records []pkgmodel.AuditRecord
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&records))
+		return records
+	}
+
+	records := query("?from=2025-11-01&to=2025-11-30")
+	require.Len(t, records, 2)
+	assert.Equal(t, "update", records[0].Operation)
+	assert.Equal(t, "task", records[1].EntityType)
+
+	assert.Len(t, query("?entity_type=entry"), 3)
+	assert.Len(t, query(""), 4)
+
+	w := httptest.NewRecorder()
+	GetAuditLog(w, httptest.NewRequest(http.MethodGet, "/api/audit?entity_type=user", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestCategoryReassignmentIsAudited(t *testing.T) {
+	db := setupHandlerTestDB(t)
+	insertTestEntry(t, db, "Development", "project work", "2025-11-10T09:00:00Z", "2025-11-10T10:30:00Z", 90)
+
+	require.Equal(t, http.StatusNoContent, deleteCategory("1", "?reassign_to=2").Code)
+
+	rows, err := db.Query("SELECT entity_type, entity_id, operation FROM audit_log ORDER BY id")
+	require.NoError(t, err)
+	defer rows.Close()
+	var changes []string
+	for rows.Next() {
+		var entityType, operation string
+		var id int
+		require.NoError(t, rows.Scan(&entityType, &id, &operation))
+		changes = append(changes, entityType+" "+operation)
+	}
+	assert.Equal(t, []string{"entry update", "category delete"}, changes)
+}
+
+func TestCategoryRenameIsAudited(t *testing.T) {
+	db := setupHandlerTestDB(t)
+	insertTestEntry(t, db, "Development", "project work", "2025-11-10T09:00:00Z", "2025-11-10T10:30:00Z", 90)
+	_, err := db.Exec("UPDATE time_entries SET category_id = 1")
+	require.NoError(t, err)
+
+	w := sendJSON(t, UpdateCategory, http.MethodPut, "/api/categories/1", "1",
+		pkgmodel.CategoryRequest{Name: "client work", Color: "#48bb78"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	// The entry is renamed with its category in the same change
+	w = getHistory("1")
+	require.Equal(t, http.StatusOK, w.Code)
+	var records []pkgmodel.AuditRecord
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&records))
+	require.Len(t, records, 1)
+	var before, after map[string]interface{}
+	require.NoError(t, json.Unmarshal(records[0].Before, &before))
+	require.NoError(t, json.Unmarshal(records[0].After, &after))
+	assert.Equal(t, "project work", before["category"])
+	assert.Equal(t, "client work", after["category"])
+}
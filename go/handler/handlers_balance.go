@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	pkgglobal "timesheet/go/global"
+	pkgmodel "timesheet/go/model"
+	pkgutil "timesheet/go/util"
+)
+
+// maxBalanceDays limits the period of the balance report to about ten years
+const maxBalanceDays = 3660
+
+// dateOf returns the midnight of a day
+func dateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// buildBalanceReport compares the booked minutes per day with the targets of the schedules
+// from the first to the last day and accumulates the differences. Public holidays have no
+// target and absences count as booked, at most up to the target of the day.
+func buildBalanceReport(first, last time.Time, schedules []pkgmodel.WorkSchedule, booked map[string]int, daysOff map[string]*dayOff) pkgmodel.BalanceReport {
+	report := pkgmodel.BalanceReport{
+		From: first.Format("2006-01-02"),
+		To:   last.Format("2006-01-02"),
+		Days: []pkgmodel.BalanceDay{},
+	}
+	for day := first; !day.After(last); day = day.AddDate(0, 0, 1) {
+		date := day.Format("2006-01-02")
+		balanceDay := pkgmodel.BalanceDay{
+			Date:          date,
+			TargetMinutes: scheduleTarget(schedules, day),
+			BookedMinutes: booked[date],
+		}
+		if off := daysOff[date]; off != nil {
+			balanceDay.Holiday = off.holiday
+			balanceDay.Absence = strings.Join(off.absences, ", ")
+			if off.holiday != "" {
+				balanceDay.TargetMinutes = 0
+			}
+			balanceDay.AbsenceMinutes = balanceDay.TargetMinutes * min(off.halves, 2) / 2
+		}
+		balanceDay.DeltaMinutes = balanceDay.BookedMinutes + balanceDay.AbsenceMinutes - balanceDay.TargetMinutes
+
+		report.TargetMinutes += balanceDay.TargetMinutes
+		report.BookedMinutes += balanceDay.BookedMinutes
+		report.AbsenceMinutes += balanceDay.AbsenceMinutes
+		report.BalanceMinutes += balanceDay.DeltaMinutes
+		balanceDay.BalanceMinutes = report.BalanceMinutes
+		report.Days = append(report.Days, balanceDay)
+	}
+	return report
+}
+
+// GetBalanceReport compares the booked time with the working-hours schedule per day. The
+// period defaults to the start of the first schedule until today.
+func GetBalanceReport(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	query := r.URL.Query()
+	from, to, err := ParseDateRange(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	region, err := RequestHolidayRegion(query.Get("region"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	schedules, err := querySchedules(pkgglobal.Db)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	today := dateOf(pkgutil.CurrentWallClockTime())
+	first, last := dateOf(from), today
+	if from.IsZero() {
+		first = today
+		if len(schedules) > 0 {
+			first, _ = time.Parse("2006-01-02", schedules[0].EffectiveFrom)
+		}
+	}
+	if !to.IsZero() {
+		// to is exclusive
+		last = dateOf(to.Add(-time.Nanosecond))
+	}
+	if last.Sub(first) > maxBalanceDays*24*time.Hour {
+		http.Error(w, fmt.Sprintf("The period is too long. Expected at most %d days", maxBalanceDays), http.StatusBadRequest)
+		return
+	}
+
+	// Running timers have no duration yet and are left out
+	where, args := TimeEntryFilter{From: first, To: last.AddDate(0, 0, 1)}.WhereClause(false)
+	where += " AND e.end_time IS NOT NULL"
+	rows, err := pkgglobal.Db.Query("SELECT date(e.start_time), SUM(e.duration) FROM time_entries e"+where+" GROUP BY date(e.start_time)", args...)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	booked := map[string]int{}
+	for rows.Next() {
+		var date string
+		var minutes int
+		if err := rows.Scan(&date, &minutes); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		booked[date] = minutes
+	}
+	if err := rows.Err(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	daysOff, err := queryDaysOff(pkgglobal.Db, region, first, last)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	report := buildBalanceReport(first, last, schedules, booked, daysOff)
+	report.Region = region
+
+	json.NewEncoder(w).Encode(report)
+}
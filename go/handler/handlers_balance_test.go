@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pkgmodel "timesheet/go/model"
+)
+
+func getBalanceReport(t *testing.T, query string) (*httptest.ResponseRecorder, pkgmodel.BalanceReport) {
+	w := httptest.NewRecorder()
+	GetBalanceReport(w, httptest.NewRequest(http.MethodGet, "/api/reports/balance?"+query, nil))
+
+	var report pkgmodel.BalanceReport
+	if w.Code == http.StatusOK {
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&report))
+	}
+	return w, report
+}
+
+func TestScheduleValidation(t *testing.T) {
+	setupHandlerTestDB(t)
+
+	schedule := pkgmodel.WorkScheduleRequest{EffectiveFrom: "2025-11-03", Monday: 480, Friday: 240}
+	w := sendJSON(t, CreateSchedule, http.MethodPost, "/api/schedules", "", schedule)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var created pkgmodel.WorkSchedule
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&created))
+	assert.Equal(t, 720, created.WeeklyMinutes)
+
+	assert.Equal(t, http.StatusConflict, sendJSON(t, CreateSchedule, http.MethodPost, "/api/schedules", "", schedule).Code)
+	for _, invalid := range []pkgmodel.WorkScheduleRequest{
+		{EffectiveFrom: "03.11.2025"},
+		{EffectiveFrom: "2025-11-10", Tuesday: -60},
+		{EffectiveFrom: "2025-11-10", Sunday: 1441},
+	} {
+		assert.Equal(t, http.StatusBadRequest, sendJSON(t, CreateSchedule, http.MethodPost, "/api/schedules", "", invalid).Code, invalid)
+	}
+	assert.Equal(t, http.StatusNotFound, sendJSON(t, UpdateSchedule, http.MethodPut, "/api/schedules/9", "9", schedule).Code)
+
+	records := sendJSON(t, GetAuditLog, http.MethodGet, "/api/audit?entity_type=schedule", "", nil)
+	assert.Contains(t, records.Body.String(), `"entity_id":1`)
+
+	assert.Equal(t, http.StatusNoContent, sendJSON(t, DeleteSchedule, http.MethodDelete, "/api/schedules/1", "1", nil).Code)
+	assert.Equal(t, http.StatusNotFound, sendJSON(t, DeleteSchedule, http.MethodDelete, "/api/schedules/1", "1", nil).Code)
+}
+
+func TestBalanceReport(t *testing.T) {
+	db := setupHandlerTestDB(t)
+	for _, schedule := range []pkgmodel.WorkScheduleRequest{
+		{EffectiveFrom: "2025-11-03", Monday: 480, Tuesday: 480, Wednesday: 480, Thursday: 480, Friday: 240},
+		{EffectiveFrom: "2025-11-10", Monday: 360, Tuesday: 360, Wednesday: 360, Thursday: 360, Friday: 360},
+	} {
+		require.Equal(t, http.StatusOK, sendJSON(t, CreateSchedule, http.MethodPost, "/api/schedules", "", schedule).Code)
+	}
+	insertTestEntry(t, db, "Development", "project work", "2025-11-07T08:00:00Z", "2025-11-07T13:00:00Z", 300)
+	insertTestEntry(t, db, "Development", "project work", "2025-11-10T08:00:00Z", "2025-11-10T12:00:00Z", 240)
+	insertTestEntry(t, db, "Support", "project support", "2025-11-10T13:00:00Z", "2025-11-10T15:40:00Z", 160)
+
+	w, report := getBalanceReport(t, "from=2025-11-06&to=2025-11-10")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, []pkgmodel.BalanceDay{
+		{Date: "2025-11-06", TargetMinutes: 480, DeltaMinutes: -480, BalanceMinutes: -480},
+		{Date: "2025-11-07", TargetMinutes: 240, BookedMinutes: 300, DeltaMinutes: 60, BalanceMinutes: -420},
+		{Date: "2025-11-08", BalanceMinutes: -420},
+		{Date: "2025-11-09", BalanceMinutes: -420},
+		{Date: "2025-11-10", TargetMinutes: 360, BookedMinutes: 400, DeltaMinutes: 40, BalanceMinutes: -380},
+	}, report.Days)
+	assert.Equal(t, 1080, report.TargetMinutes)
+	assert.Equal(t, 700, report.BookedMinutes)
+	assert.Equal(t, -380, report.BalanceMinutes)
+
+	// Without from the balance starts with the first schedule
+	w, report = getBalanceReport(t, "to=2025-11-04")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "2025-11-03", report.From)
+	assert.Equal(t, -960, report.BalanceMinutes)
+
+	w, _ = getBalanceReport(t, "from=2000-01-01&to=2025-11-04")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,249 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"sort"
+
+	pkgglobal "timesheet/go/global"
+	pkgmodel "timesheet/go/model"
+)
+
+// billableEntry is a billable entry with the IDs that select its rate
+type billableEntry struct {
+	id, minutes                   int
+	day, task                     string
+	taskID, categoryID, projectID sql.NullInt64
+	clientID                      sql.NullInt64
+	projectName, clientName       sql.NullString
+}
+
+// scopeID returns the ID of the entry's task, project, client or category
+func (e billableEntry) scopeID(scope string) (int, bool) {
+	var id sql.NullInt64
+	switch scope {
+	case RateTask:
+		id = e.taskID
+	case RateProject:
+		id = e.projectID
+	case RateClient:
+		id = e.clientID
+	case RateCategory:
+		id = e.categoryID
+	}
+	return int(id.Int64), id.Valid
+}
+
+// rateBook finds the rate of a scope on a day
+type rateBook map[string]map[int][]pkgmodel.Rate
+
+// newRateBook indexes rates ordered by scope, scope_id and descending effective date
+func newRateBook(rates []pkgmodel.Rate) rateBook {
+	book := rateBook{}
+	for _, rate := range rates {
+		if book[rate.Scope] == nil {
+			book[rate.Scope] = map[int][]pkgmodel.Rate{}
+		}
+		book[rate.Scope][rate.ScopeID] = append(book[rate.Scope][rate.ScopeID], rate)
+	}
+	return book
+}
+
+// rateFor returns the rate applying to an entry: the latest rate effective on its day of the
+// most specific scope that has one
+func (book rateBook) rateFor(e billableEntry) (pkgmodel.Rate, bool) {
+	for _, scope := range rateScopes {
+		id, ok := e.scopeID(scope)
+		if !ok {
+			continue
+		}
+		for _, rate := range book[scope][id] {
+			if rate.EffectiveFrom <= e.day {
+				return rate, true
+			}
+		}
+	}
+	return pkgmodel.Rate{}, false
+}
+
+// queryBillableEntries reads the finished billable entries matching the condition. Entries
+// that were not linked to their task or category by name are matched here.
+func queryBillableEntries(q Querier, where string, args []interface{}) ([]billableEntry, error) {
+	rows, err := q.Query(`
+		SELECT e.id, e.duration, date(e.start_time), e.task,
+			COALESCE(e.task_id, (SELECT t.id FROM tasks t WHERE t.name = e.task AND t.deleted_at IS NULL ORDER BY t.id LIMIT 1)),
+			COALESCE(e.category_id, (SELECT c.id FROM categories c WHERE c.name = e.category AND c.deleted_at IS NULL)),
+			e.project_id, p.client_id, p.name, cl.name
+		FROM time_entries e
+		LEFT JOIN projects p ON p.id = e.project_id
+		LEFT JOIN clients cl ON cl.id = p.client_id`+where+`
+		ORDER BY e.start_time, e.id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []billableEntry
+	for rows.Next() {
+		var e billableEntry
+		err := rows.Scan(&e.id, &e.minutes, &e.day, &e.task, &e.taskID, &e.categoryID, &e.projectID,
+			&e.clientID, &e.projectName, &e.clientName)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// billingItemKey identifies a line item
+type billingItemKey struct {
+	client, project, task, rate, currency string
+}
+
+// buildBillingReport prices the entries. Quantities are rounded to MoneyPlaces hours per
+// line item and amounts are computed from the rounded quantities, so that the lines of an
+// invoice add up.
+func buildBillingReport(entries []billableEntry, book rateBook) (pkgmodel.BillingReport, error) {
+	report := pkgmodel.BillingReport{Items: []pkgmodel.BillingLineItem{}, Totals: []pkgmodel.CurrencyTotal{}, UnratedEntryIDs: []int{}}
+
+	items := map[billingItemKey]*pkgmodel.BillingLineItem{}
+	for _, e := range entries {
+		rate, ok := book.rateFor(e)
+		if !ok {
+			report.UnratedEntryIDs = append(report.UnratedEntryIDs, e.id)
+			continue
+		}
+		key := billingItemKey{e.clientName.String, e.projectName.String, e.task, rate.HourlyRate, rate.Currency}
+		item, ok := items[key]
+		if !ok {
+			item = &pkgmodel.BillingLineItem{Client: key.client, Project: key.project, Task: key.task,
+				HourlyRate: rate.HourlyRate, Currency: rate.Currency}
+			items[key] = item
+		}
+		item.Minutes += e.minutes
+		item.EntryCount++
+	}
+
+	amounts := map[string]*big.Rat{}
+	minutes := map[string]int{}
+	for _, item := range items {
+		hourlyRate, err := ParseDecimal(item.HourlyRate)
+		if err != nil {
+			return report, err
+		}
+		quantity := RoundDecimal(hoursOf(item.Minutes), MoneyPlaces)
+		amount := RoundDecimal(new(big.Rat).Mul(quantity, hourlyRate), MoneyPlaces)
+		item.Quantity = quantity.FloatString(MoneyPlaces)
+		item.Amount = amount.FloatString(MoneyPlaces)
+		report.Items = append(report.Items, *item)
+
+		if amounts[item.Currency] == nil {
+			amounts[item.Currency] = new(big.Rat)
+		}
+		amounts[item.Currency].Add(amounts[item.Currency], amount)
+		minutes[item.Currency] += item.Minutes
+	}
+
+	sort.Slice(report.Items, func(i, j int) bool {
+		a, b := report.Items[i], report.Items[j]
+		if a.Client != b.Client {
+			return a.Client < b.Client
+		}
+		if a.Project != b.Project {
+			return a.Project < b.Project
+		}
+		if a.Task != b.Task {
+			return a.Task < b.Task
+		}
+		if a.Currency != b.Currency {
+			return a.Currency < b.Currency
+		}
+		return a.HourlyRate < b.HourlyRate
+	})
+	for currency, amount := range amounts {
+		report.Totals = append(report.Totals, pkgmodel.CurrencyTotal{
+			Currency: currency,
+			Minutes:  minutes[currency],
+			Amount:   amount.FloatString(MoneyPlaces),
+		})
+	}
+	sort.Slice(report.Totals, func(i, j int) bool { return report.Totals[i].Currency < report.Totals[j].Currency })
+	return report, nil
+}
+
+// queryBillingReport prices the finished billable entries matching the condition with their
+// durations rounded by the rounding book
+func queryBillingReport(q Querier, where string, args []interface{}, rounding roundingBook) (pkgmodel.BillingReport, error) {
+	entries, err := queryBillableEntries(q, where, args)
+	if err != nil {
+		return pkgmodel.BillingReport{}, err
+	}
+	rates, err := queryRates(q, "")
+	if err != nil {
+		return pkgmodel.BillingReport{}, err
+	}
+
+	rounder := rounding.newRounder()
+	for i, e := range entries {
+		entries[i].minutes = rounder.round(e.day, int(e.projectID.Int64), e.minutes)
+	}
+	report, err := buildBillingReport(entries, newRateBook(rates))
+	report.Rounding = rounder.appliedRules()
+	return report, err
+}
+
+// GetBillingReport prices the billable entries within the optional from/to range, optionally
+// only those of the projects of one client
+func GetBillingReport(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	query := r.URL.Query()
+	from, to, err := ParseDateRange(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	exact, err := ParseRoundingParam(query.Get("rounding"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Running timers have no duration yet and are left out
+	where, args := TimeEntryFilter{From: from, To: to}.WhereClause(false)
+	where += " AND e.end_time IS NOT NULL AND e.billable = 1"
+	client := query.Get("client")
+	if client != "" {
+		exists, err := clientNamed(pkgglobal.Db, client)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !exists {
+			http.Error(w, "Invalid client. Client does not exist", http.StatusBadRequest)
+			return
+		}
+		where += " AND cl.name = ?"
+		args = append(args, client)
+	}
+
+	book, err := newRoundingBook(pkgglobal.Db, exact)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	report, err := queryBillingReport(pkgglobal.Db, where, args, book)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	report.From = query.Get("from")
+	report.To = query.Get("to")
+	report.Client = client
+
+	json.NewEncoder(w).Encode(report)
+}
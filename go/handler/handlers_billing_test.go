@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pkgmodel "timesheet/go/model"
+)
+
+// getBillingReport calls GetBillingReport with the given query string
+func getBillingReport(t *testing.T, query string) (*httptest.ResponseRecorder, pkgmodel.BillingReport) {
+	w := httptest.NewRecorder()
+	GetBillingReport(w, httptest.NewRequest(http.MethodGet, "/api/reports/billing"+query, nil))
+	var report pkgmodel.BillingReport
+	if w.Code == http.StatusOK {
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&report))
+	}
+	return w, report
+}
+
+func TestRateValidation(t *testing.T) {
+	setupHandlerTestDB(t)
+	setupProjects(t)
+
+	w := sendJSON(t, CreateRate, http.MethodPost, "/api/rates", "", `{"scope": "project", "scope_id": 1, "hourly_rate": 95.5, "currency": "eur", "effective_from": "2025-01-01"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var rate pkgmodel.Rate
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&rate))
+	assert.Equal(t, pkgmodel.Rate{ID: 1, Scope: "project", ScopeID: 1, HourlyRate: "95.5", Currency: "EUR", EffectiveFrom: "2025-01-01"}, rate)
+
+	for _, tc := range []struct {
+		body   string
+		status int
+	}{
+		{`{"scope": "project", "scope_id": 1, "hourly_rate": "100", "currency": "EUR", "effective_from": "2025-01-01"}`, http.StatusConflict},
+		{`{"scope": "project", "scope_id": 1, "hourly_rate": "1e3", "currency": "EUR", "effective_from": "2025-02-01"}`, http.StatusBadRequest},
+		{`{"scope": "project", "scope_id": 1, "hourly_rate": "100", "currency": "euro", "effective_from": "2025-02-01"}`, http.StatusBadRequest},
+		{`{"scope": "project", "scope_id": 9, "hourly_rate": "100", "currency": "EUR", "effective_from": "2025-02-01"}`, http.StatusBadRequest},
+		{`{"scope": "team", "scope_id": 1, "hourly_rate": "100", "currency": "EUR", "effective_from": "2025-02-01"}`, http.StatusBadRequest},
+		{`{"scope": "task", "scope_id": 1, "hourly_rate": "100", "currency": "EUR", "effective_from": "next month"}`, http.StatusBadRequest},
+	} {
+		assert.Equal(t, tc.status, sendJSON(t, CreateRate, http.MethodPost, "/api/rates", "", tc.body).Code, tc.body)
+	}
+}
+
+func TestBillingReport(t *testing.T) {
+	setupHandlerTestDB(t)
+	setupProjects(t)
+	for _, body := range []string{
+		`{"scope": "client", "scope_id": 1, "hourly_rate": "80", "currency": "EUR", "effective_from": "2025-01-01"}`,
+		`{"scope": "project", "scope_id": 1, "hourly_rate": "100", "currency": "EUR", "effective_from": "2025-01-01"}`,
+		`{"scope": "project", "scope_id": 1, "hourly_rate": "120", "currency": "EUR", "effective_from": "2025-11-15"}`,
+		`{"scope": "category", "scope_id": 2, "hourly_rate": "50", "currency": "USD", "effective_from": "2025-01-01"}`,
+	} {
+		w := sendJSON(t, CreateRate, http.MethodPost, "/api/rates", "", body)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	}
+
+	notBillable := false
+	for _, entry := range []pkgmodel.TimeEntryRequest{
+		{Task: "Development", Category: "project work", StartTime: "2025-11-10T09:00:00Z", EndTime: "2025-11-10T10:20:00Z"},
+		{Task: "Development", Category: "project work", StartTime: "2025-11-17T09:00:00Z", EndTime: "2025-11-17T10:00:00Z"},
+		{Task: "Development", Category: "project work", StartTime: "2025-11-18T09:00:00Z", EndTime: "2025-11-18T10:00:00Z", Billable: &notBillable},
+		{Task: "Meeting", Category: "project support", StartTime: "2025-11-11T09:00:00Z", EndTime: "2025-11-11T09:30:00Z"},
+		{Task: "Ad-hoc", Category: "project work", StartTime: "2025-11-12T09:00:00Z", EndTime: "2025-11-12T09:15:00Z"},
+	} {
+		w := sendJSON(t, CreateTimeEntry, http.MethodPost, "/api/entries", "", entry)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	}
+
+	w, report := getBillingReport(t, "?from=2025-11-01&to=2025-11-30")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, []pkgmodel.BillingLineItem{
+		{Task: "Meeting", HourlyRate: "50", Currency: "USD", Minutes: 30, Quantity: "0.50", Amount: "25.00", EntryCount: 1},
+		{Client: "Acme", Project: "Website", Task: "Development", HourlyRate: "100", Currency: "EUR",
+			Minutes: 80, Quantity: "1.33", Amount: "133.00", EntryCount: 1},
+		{Client: "Acme", Project: "Website", Task: "Development", HourlyRate: "120", Currency: "EUR",
+			Minutes: 60, Quantity: "1.00", Amount: "120.00", EntryCount: 1},
+	}, report.Items)
+	assert.Equal(t, []pkgmodel.CurrencyTotal{{Currency: "EUR", Minutes: 140, Amount: "253.00"}, {Currency: "USD", Minutes: 30, Amount: "25.00"}}, report.Totals)
+	assert.Equal(t, []int{5}, report.UnratedEntryIDs)
+
+	// Marking the entry billable again puts it on the bill
+	w = sendJSON(t, PatchTimeEntry, http.MethodPatch, "/api/entries/3", "3", `{"billable": null}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	_, report = getBillingReport(t, "?client=Acme")
+	require.Len(t, report.Items, 2)
+	assert.Equal(t, 2, report.Items[1].EntryCount)
+	assert.Equal(t, "Acme", report.Client)
+
+	w, _ = getBillingReport(t, "?client=Nobody")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
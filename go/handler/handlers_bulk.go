@@ -0,0 +1,342 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	pkgmodel "timesheet/go/model"
+	pkgutil "timesheet/go/util"
+)
+
+// MaxBulkOperations is the largest number of entries a bulk request may write
+const MaxBulkOperations = 1000
+
+// Operations of a bulk request
+const (
+	BulkCreate = "create"
+	BulkUpdate = "update"
+	BulkDelete = "delete"
+)
+
+// errBulkItem marks an item that failed; its result holds the reason
+var errBulkItem = errors.New("bulk item failed")
+
+// bulkWriter applies the items of one bulk request inside its transaction
+type bulkWriter struct {
+	tx     *ChangeTx
+	policy OverlapPolicy
+}
+
+// write validates an entry and inserts it, or updates the entry with the given ID.
+// Validation errors and overlaps are stored in the result and reported as errBulkItem.
+func (b *bulkWriter) write(result *pkgmodel.BulkItemResult, id int, req pkgmodel.TimeEntryRequest) error {
+	startTime, endTime, duration, err := ParseAndValidateTimeEntry(req)
+	if err != nil {
+		result.Error = err.Error()
+		return errBulkItem
+	}
+	tags, err := NormalizeTags(req.Tags)
+	if err != nil {
+		result.Error = err.Error()
+		return errBulkItem
+	}
+	exists, err := categoryExists(b.tx, req.Category)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		result.Error = fmt.Sprintf("invalid category '%s': category does not exist in the system", req.Category)
+		return errBulkItem
+	}
+	if valid, err := validEntryProject(b.tx, req.ProjectID); err != nil {
+		return err
+	} else if !valid {
+		result.Error = fmt.Sprintf("invalid project_id %d: project does not exist", *req.ProjectID)
+		return errBulkItem
+	}
+
+	conflicts, err := ResolveOverlaps(b.tx, b.policy, startTime, endTime, id)
+	var overlapErr *OverlapError
+	if errors.As(err, &overlapErr) {
+		result.Error = overlapErr.Error()
+		result.Conflicts = overlapErr.ConflictIDs
+		return errBulkItem
+	}
+	if err != nil {
+		return err
+	}
+	result.Warning = overlapWarning(b.policy, conflicts)
+	result.Conflicts = conflicts
+
+	// Get current date for compatibility with existing database schema
+	currentDate := time.Now().Format("2006-01-02")
+
+	if id == 0 {
+		res, err := b.tx.Exec(`
+			INSERT INTO time_entries (task, description, category, start_time, end_time, duration, date, billable)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		`, req.Task, req.Description, req.Category, startTime.Format(time.RFC3339),
+			endTime.Format(time.RFC3339), duration, currentDate, req.Billable == nil || *req.Billable)
+		if err != nil {
+			return err
+		}
+		newID, _ := res.LastInsertId()
+		id = int(newID)
+		if err := setEntryProject(b.tx, id, req.ProjectID); err != nil {
+			return err
+		}
+		if err := setEntryTags(b.tx, id, tags); err != nil {
+			return err
+		}
+		if err := AuditInsert(b.tx, AuditEntry, id); err != nil {
+			return err
+		}
+	} else {
+		err := AuditedWrite(b.tx, AuditEntry, id, AuditUpdate, func() error {
+			_, err := b.tx.Exec(`
+				UPDATE time_entries
+				SET task = ?, description = ?, category = ?, start_time = ?, end_time = ?, duration = ?, date = ?,
+					billable = COALESCE(?, billable)
+				WHERE id = ? AND deleted_at IS NULL
+			`, req.Task, req.Description, req.Category, startTime.Format(time.RFC3339),
+				endTime.Format(time.RFC3339), duration, currentDate, req.Billable, id)
+			if err == nil && req.ProjectID != nil {
+				err = setEntryProject(b.tx, id, req.ProjectID)
+			}
+			if err != nil || tags == nil {
+				return err
+			}
+			return setEntryTags(b.tx, id, tags)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	entry, err := getTimeEntry(b.tx, id)
+	if err == sql.ErrNoRows {
+		result.Error = "Time entry not found"
+		return errBulkItem
+	}
+	if err != nil {
+		return err
+	}
+	result.ID = id
+	result.Entry = &entry
+	return nil
+}
+
+// delete moves an entry to the trash
+func (b *bulkWriter) delete(result *pkgmodel.BulkItemResult, id int) error {
+	if _, err := getTimeEntry(b.tx, id); err == sql.ErrNoRows {
+		result.Error = "Time entry not found"
+		return errBulkItem
+	} else if err != nil {
+		return err
+	}
+	return AuditedWrite(b.tx, AuditEntry, id, AuditDelete, func() error {
+		_, err := b.tx.Exec("UPDATE time_entries SET deleted_at = ? WHERE id = ?",
+			pkgutil.FormatTimeForDB(pkgutil.CurrentWallClockTime()), id)
+		return err
+	})
+}
+
+// apply runs one operation of a bulk request
+func (b *bulkWriter) apply(index int, op pkgmodel.BulkOperation) (pkgmodel.BulkItemResult, error) {
+	result := pkgmodel.BulkItemResult{Index: index, Op: op.Op, ID: op.ID}
+
+	switch op.Op {
+	case BulkCreate, BulkUpdate:
+		if op.Entry == nil {
+			result.Error = "entry is required"
+			return result, errBulkItem
+		}
+		if op.Op == BulkCreate && op.ID != 0 {
+			result.Error = "id must not be given when creating an entry"
+			return result, errBulkItem
+		}
+		if op.Op == BulkUpdate && op.ID <= 0 {
+			result.Error = "id is required"
+			return result, errBulkItem
+		}
+		return result, b.write(&result, op.ID, *op.Entry)
+	case BulkDelete:
+		if op.ID <= 0 {
+			result.Error = "id is required"
+			return result, errBulkItem
+		}
+		return result, b.delete(&result, op.ID)
+	}
+	result.Error = fmt.Sprintf("invalid op '%s'. Expected create, update or delete", op.Op)
+	return result, errBulkItem
+}
+
+// filterValues converts a bulk filter into the query parameters of the entries listing
+func filterValues(f pkgmodel.BulkFilter) url.Values {
+	values := url.Values{}
+	for key, value := range map[string]string{"from": f.From, "to": f.To, "category": f.Category, "task": f.Task, "q": f.Query} {
+		if value != "" {
+			values.Set(key, value)
+		}
+	}
+	for _, tag := range f.Tags {
+		values.Add("tag", tag)
+	}
+	return values
+}
+
+// patchTargets validates a patch and returns the IDs of the entries matching the filter.
+// Running timers are not matched.
+func patchTargets(q Querier, f pkgmodel.BulkFilter, patch pkgmodel.BulkPatch) ([]int, error) {
+	values := filterValues(f)
+	if len(values) == 0 {
+		return nil, errors.New("filter must set at least one of from, to, category, task, q or tags")
+	}
+	if patch.Task == nil && patch.Description == nil && patch.Category == nil {
+		return nil, errors.New("patch must set at least one of task, description or category")
+	}
+	if (patch.Task != nil && *patch.Task == "") || (patch.Category != nil && *patch.Category == "") {
+		return nil, errors.New("task and category must not be empty")
+	}
+	if patch.Category != nil {
+		exists, err := categoryExists(q, *patch.Category)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, fmt.Errorf("invalid category '%s': category does not exist in the system", *patch.Category)
+		}
+	}
+	filter, err := ParseTimeEntryFilter(values)
+	if err != nil {
+		return nil, err
+	}
+
+	where, args := filter.WhereClause(false)
+	return matchingIDs(q, AuditEntry, strings.TrimPrefix(where, " WHERE ")+" AND end_time IS NOT NULL", args...)
+}
+
+// patch writes the fields of a bulk patch to an entry. Only the patched columns are written;
+// the times stay as stored, so the overlap policy does not apply.
+func (b *bulkWriter) patch(result *pkgmodel.BulkItemResult, id int, patch pkgmodel.BulkPatch) error {
+	var assignments []string
+	var args []interface{}
+	for column, value := range map[string]*string{"task": patch.Task, "description": patch.Description, "category": patch.Category} {
+		if value != nil {
+			assignments = append(assignments, column+" = ?")
+			args = append(args, *value)
+		}
+	}
+	err := AuditedWrite(b.tx, AuditEntry, id, AuditUpdate, func() error {
+		_, err := b.tx.Exec("UPDATE time_entries SET "+strings.Join(assignments, ", ")+" WHERE id = ?", append(args, id)...)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	entry, err := getTimeEntry(b.tx, id)
+	if err != nil {
+		return err
+	}
+	result.Entry = &entry
+	return nil
+}
+
+// BulkTimeEntries applies a list of operations, or a patch to all entries matching a filter,
+// in one transaction. Every item is validated with the rules of the single-entry endpoints;
+// if any item fails nothing is written and the response lists the errors per item.
+func BulkTimeEntries(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req pkgmodel.BulkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	byFilter := req.Filter != nil || req.Patch != nil
+	if byFilter == (len(req.Operations) > 0) {
+		http.Error(w, "Expected either operations or a filter with a patch", http.StatusBadRequest)
+		return
+	}
+	if byFilter && (req.Filter == nil || req.Patch == nil) {
+		http.Error(w, "A filter and a patch are required", http.StatusBadRequest)
+		return
+	}
+
+	policy, err := RequestOverlapPolicy(r.URL.Query().Get("overlap"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tx, err := BeginChange()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	ops := req.Operations
+	var patchIDs []int
+	if byFilter {
+		if patchIDs, err = patchTargets(tx, *req.Filter, *req.Patch); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if len(ops)+len(patchIDs) > MaxBulkOperations {
+		http.Error(w, fmt.Sprintf("Too many operations. At most %d entries can be written at once", MaxBulkOperations), http.StatusBadRequest)
+		return
+	}
+
+	// Keep going after a failed item so that all errors are reported at once
+	writer := &bulkWriter{tx: tx, policy: policy}
+	response := pkgmodel.BulkResponse{Results: []pkgmodel.BulkItemResult{}}
+	failed := 0
+	for i, op := range ops {
+		result, err := writer.apply(i, op)
+		if errors.Is(err, errBulkItem) {
+			failed++
+		} else if err != nil {
+			log.Printf("ERROR: Bulk %s of time entry %d failed - Error: %v", op.Op, op.ID, err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		response.Results = append(response.Results, result)
+	}
+	for i, id := range patchIDs {
+		result := pkgmodel.BulkItemResult{Index: i, Op: BulkUpdate, ID: id}
+		if err := writer.patch(&result, id, *req.Patch); err != nil {
+			log.Printf("ERROR: Bulk patch of time entry %d failed - Error: %v", id, err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		response.Results = append(response.Results, result)
+	}
+
+	if failed > 0 {
+		log.Printf("WARNING: Rejected bulk request - %d of %d items failed", failed, len(ops))
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	if err := tx.Commit(w, r); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	response.Applied = true
+
+	log.Printf("UPDATE: Applied bulk request with %d items", len(ops)+len(patchIDs))
+
+	json.NewEncoder(w).Encode(response)
+}
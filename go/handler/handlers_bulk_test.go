@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pkgmodel "timesheet/go/model"
+)
+
+// postBulk calls BulkTimeEntries with the given request body
+func postBulk(t *testing.T, query, body string) (*httptest.ResponseRecorder, pkgmodel.BulkResponse) {
+	req := httptest.NewRequest(http.MethodPost, "/api/entries/bulk"+query, bytes.NewBufferString(body))
+	req.Header.Set("X-Session-ID", testSession)
+	w := httptest.NewRecorder()
+	BulkTimeEntries(w, req)
+
+	var response pkgmodel.BulkResponse
+	if w.Code == http.StatusOK || w.Code == http.StatusBadRequest {
+		json.Unmarshal(w.Body.Bytes(), &response)
+	}
+	return w, response
+}
+
+func TestBulkOperationsAreApplied(t *testing.T) {
+	db := setupHandlerTestDB(t)
+	insertTestEntry(t, db, "Development", "project work", "2025-11-10T09:00:00Z", "2025-11-10T10:00:00Z", 60)
+	insertTestEntry(t, db, "Review", "project work", "2025-11-10T10:00:00Z", "2025-11-10T10:30:00Z", 30)
+
+	w, response := postBulk(t, "", `{"operations": [
+		{"op": "create", "entry": {"task": "Standup", "category": "project work", "start_time": "2025-11-10T11:00:00Z", "end_time": "2025-11-10T11:15:00Z"}},
+		{"op": "update", "id": 1, "entry": {"task": "Development", "category": "project support", "start_time": "2025-11-10T09:00:00Z", "end_time": "2025-11-10T09:45:00Z"}},
+		{"op": "delete", "id": 2}
+	]}`)
+
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.True(t, response.Applied)
+	require.Len(t, response.Results, 3)
+	assert.Equal(t, 3, response.Results[0].ID)
+	assert.Equal(t, 15, response.Results[0].Entry.Duration)
+	assert.Equal(t, 45, response.Results[1].Entry.Duration)
+	assert.Empty(t, response.Results[2].Error)
+
+	entries := currentEntries(t)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "project support", entries[1].Category)
+
+	// The request is recorded as one undo step
+	require.Equal(t, http.StatusOK, callUndo(Undo, testSession).Code)
+	assert.Len(t, currentEntries(t), 2)
+	assert.Equal(t, "Review", currentEntries(t)[0].Task)
+}
+
+func TestBulkOperationsAreAtomic(t *testing.T) {
+	db := setupHandlerTestDB(t)
+	insertTestEntry(t, db, "Development", "project work", "2025-11-10T09:00:00Z", "2025-11-10T10:00:00Z", 60)
+
+	w, response := postBulk(t, "?overlap=reject", `{"operations": [
+		{"op": "create", "entry": {"task": "Standup", "category": "project work", "start_time": "2025-11-10T11:00:00Z", "end_time": "2025-11-10T11:15:00Z"}},
+		{"op": "create", "entry": {"task": "Review", "category": "meetings", "start_time": "2025-11-10T12:00:00Z", "end_time": "2025-11-10T12:30:00Z"}},
+		{"op": "create", "entry": {"task": "Call", "category": "project work", "start_time": "2025-11-10T09:30:00Z", "end_time": "2025-11-10T09:45:00Z"}},
+		{"op": "update", "id": 7, "entry": {"task": "Call", "category": "project work", "start_time": "2025-11-10T14:00:00Z", "end_time": "2025-11-10T13:00:00Z"}},
+		{"op": "delete", "id": 7},
+		{"op": "move"}
+	]}`)
+
+	require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	assert.False(t, response.Applied)
+	require.Len(t, response.Results, 6)
+	assert.Empty(t, response.Results[0].Error)
+	assert.Contains(t, response.Results[1].Error, "invalid category 'meetings'")
+	assert.Equal(t, []int{1}, response.Results[2].Conflicts)
+	assert.Equal(t, "end time must be after start time", response.Results[3].Error)
+	assert.Equal(t, "Time entry not found", response.Results[4].Error)
+	assert.Contains(t, response.Results[5].Error, "invalid op 'move'")
+
+	assert.Len(t, currentEntries(t), 1)
+	var audited int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM audit_log").Scan(&audited))
+	assert.Equal(t, 0, audited)
+}
+
+func TestBulkPatchByFilter(t *testing.T) {
+	db := setupHandlerTestDB(t)
+	insertTestEntry(t, db, "Development", "project work", "2025-11-10T09:00:00Z", "2025-11-10T10:00:00Z", 60)
+	insertTestEntry(t, db, "Development", "project work", "2025-11-20T09:00:00Z", "2025-11-20T10:00:00Z", 60)
+	insertTestEntry(t, db, "Development", "project work", "2025-12-01T09:00:00Z", "2025-12-01T10:00:00Z", 60)
+	insertTestEntry(t, db, "Review", "project work", "2025-11-12T09:00:00Z", "2025-11-12T10:00:00Z", 60)
+
+	w, response := postBulk(t, "", `{"filter": {"task": "Development", "from": "2025-11-01", "to": "2025-11-30"},
+		"patch": {"category": "project support"}}`)
+
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Len(t, response.Results, 2)
+	assert.Equal(t, []int{1, 2}, []int{response.Results[0].ID, response.Results[1].ID})
+
+	page := listEntries(t, "?category=project+support")
+	assert.Equal(t, 2, page.Total)
+	assert.Equal(t, "Development", page.Entries[0].Task)
+}
+
+func TestBulkPatchKeepsTimesOfOverlappingEntries(t *testing.T) {
+	db := setupHandlerTestDB(t)
+	insertTestEntry(t, db, "Development", "project work", "2025-11-10T09:00:00Z", "2025-11-10T11:00:00Z", 120)
+	insertTestEntry(t, db, "Review", "project work", "2025-11-10T10:00:00Z", "2025-11-10T12:00:00Z", 120)
+	// The fixture date and a stored duration that differ from the times show that neither is recomputed
+	_, err := db.Exec("UPDATE time_entries SET duration = 100 WHERE id = 1")
+	require.NoError(t, err)
+
+	w, response := postBulk(t, "?overlap=reject", `{"filter": {"task": "Development"}, "patch": {"description": "pairing"}}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Len(t, response.Results, 1)
+	require.NotNil(t, response.Results[0].Entry)
+	assert.Equal(t, "pairing", response.Results[0].Entry.Description)
+	assert.Empty(t, response.Results[0].Conflicts)
+
+	var start, end, date string
+	var duration int
+	require.NoError(t, db.QueryRow("SELECT start_time, end_time, duration, date FROM time_entries WHERE id = 1").
+		Scan(&start, &end, &duration, &date))
+	assert.Equal(t, []string{"2025-11-10T09:00:00Z", "2025-11-10T11:00:00Z", "2025-11-09"}, []string{start, end, date})
+	assert.Equal(t, 100, duration)
+}
+
+func TestBulkRejectsMalformedRequests(t *testing.T) {
+	setupHandlerTestDB(t)
+
+	for _, body := range []string{
+		`{}`,
+		`{"operations": [{"op": "delete", "id": 1}], "filter": {"task": "Development"}, "patch": {"category": "other"}}`,
+		`{"filter": {"task": "Development"}}`,
+		`{"filter": {}, "patch": {"category": "other"}}`,
+		`{"filter": {"task": "Development"}, "patch": {}}`,
+		`{"filter": {"from": "soon"}, "patch": {"category": "other"}}`,
+		`{"filter": {"task": "Development"}, "patch": {"task": ""}}`,
+		`{"filter": {"task": "Development"}, "patch": {"category": "meetings"}}`,
+	} {
+		w, response := postBulk(t, "", body)
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+		assert.Empty(t, response.Results, body)
+	}
+}
@@ -1,12 +1,14 @@
 package handler
 
 import (
+	"database/sql"
 	"encoding/json"
 	"log"
 	"net/http"
 	"strconv"
 	pkgglobal "timesheet/go/global"
 	pkgmodel "timesheet/go/model"
+	pkgutil "timesheet/go/util"
 
 	"github.com/gorilla/mux"
 )
@@ -15,7 +17,7 @@ import (
 func GetCategories(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	rows, err := pkgglobal.Db.Query("SELECT id, name, color FROM categories ORDER BY name")
+	rows, err := pkgglobal.Db.Query("SELECT id, name, color FROM categories WHERE deleted_at IS NULL ORDER BY name")
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -54,7 +56,26 @@ func CreateCategory(w http.ResponseWriter, r *http.Request) {
 		req.Color = "#718096" // Default color
 	}
 
-	result, err := pkgglobal.Db.Exec("INSERT INTO categories (name, color) VALUES (?, ?)", req.Name, req.Color)
+	tx, err := BeginChange()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	var trashed bool
+	err = tx.QueryRow("SELECT EXISTS(SELECT 1 FROM categories WHERE name = ? AND deleted_at IS NOT NULL)", req.Name).
+		Scan(&trashed)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if trashed {
+		http.Error(w, "A category with this name is in the trash. Restore it instead", http.StatusConflict)
+		return
+	}
+
+	result, err := tx.Exec("INSERT INTO categories (name, color) VALUES (?, ?)", req.Name, req.Color)
 	if err != nil {
 		log.Printf("ERROR: Failed to insert category - Name: %s, Color: %s - Error: %v", req.Name, req.Color, err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -62,6 +83,20 @@ func CreateCategory(w http.ResponseWriter, r *http.Request) {
 	}
 
 	id, _ := result.LastInsertId()
+	err = AuditInsert(tx, AuditCategory, int(id))
+	if err == nil {
+		err = SyncEntryLinks(tx, "category", int(id), req.Name)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(w, r); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
 	log.Printf("INSERT: Created category ID %d - Name: %s, Color: %s", id, req.Name, req.Color)
 	category := pkgmodel.Category{
 		ID:    int(id),
@@ -97,13 +132,44 @@ func UpdateCategory(w http.ResponseWriter, r *http.Request) {
 		req.Color = "#718096" // Default color
 	}
 
-	_, err = pkgglobal.Db.Exec("UPDATE categories SET name = ?, color = ? WHERE id = ?", req.Name, req.Color, id)
+	tx, err := BeginChange()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	// Categories in the trash have to be restored before they can be edited
+	var exists bool
+	err = tx.QueryRow("SELECT EXISTS(SELECT 1 FROM categories WHERE id = ? AND deleted_at IS NULL)", id).Scan(&exists)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !exists {
+		log.Printf("WARNING: Attempted to update non-existent category ID %d", id)
+		http.Error(w, "Category not found", http.StatusNotFound)
+		return
+	}
+
+	err = AuditedWrite(tx, AuditCategory, id, AuditUpdate, func() error {
+		_, err := tx.Exec("UPDATE categories SET name = ?, color = ? WHERE id = ?", req.Name, req.Color, id)
+		return err
+	})
+	if err == nil {
+		err = SyncEntryLinks(tx, "category", id, req.Name)
+	}
 	if err != nil {
 		log.Printf("ERROR: Failed to update category ID %d - Name: %s, Color: %s - Error: %v", id, req.Name, req.Color, err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	if err := tx.Commit(w, r); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
 	log.Printf("UPDATE: Modified category ID %d - Name: %s, Color: %s", id, req.Name, req.Color)
 
 	category := pkgmodel.Category{
@@ -115,6 +181,166 @@ func UpdateCategory(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(category)
 }
 
+// categoryExists reports whether a category that is not in the trash has the given name
+func categoryExists(q Querier, name string) (bool, error) {
+	var exists bool
+	err := q.QueryRow("SELECT EXISTS(SELECT 1 FROM categories WHERE name = ? AND deleted_at IS NULL)", name).Scan(&exists)
+	return exists, err
+}
+
+// PatchCategory applies a JSON merge patch to a category; removing the color resets it to
+// the default
+func PatchCategory(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	patch, err := decodeMergePatch(r.Body, "name", "color")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tx, err := BeginChange()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	current := pkgmodel.Category{ID: id}
+	err = tx.QueryRow("SELECT name, color FROM categories WHERE id = ? AND deleted_at IS NULL", id).Scan(&current.Name, &current.Color)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Category not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var req pkgmodel.CategoryRequest
+	if err := applyMergePatch(patch, pkgmodel.CategoryRequest{Name: current.Name, Color: current.Color}, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Name == "" {
+		http.Error(w, "Category name is required", http.StatusBadRequest)
+		return
+	}
+	if req.Color == "" {
+		req.Color = "#718096" // Default color
+	}
+
+	err = AuditedWrite(tx, AuditCategory, id, AuditUpdate, func() error {
+		_, err := tx.Exec("UPDATE categories SET name = ?, color = ? WHERE id = ?", req.Name, req.Color, id)
+		return err
+	})
+	if err == nil {
+		err = SyncEntryLinks(tx, "category", id, req.Name)
+	}
+	if err != nil {
+		log.Printf("ERROR: Failed to patch category ID %d - Name: %s, Color: %s - Error: %v", id, req.Name, req.Color, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(w, r); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("UPDATE: Patched category ID %d - Name: %s, Color: %s", id, req.Name, req.Color)
+
+	json.NewEncoder(w).Encode(pkgmodel.Category{ID: id, Name: req.Name, Color: req.Color})
+}
+
+// queryCategoryUsage counts the entries (by ID or name) and tasks using the category.
+// Rows in the trash are counted too, since they could not be restored without the category;
+// Trashed tells how many of them are in the trash.
+func queryCategoryUsage(q Querier, id int) (pkgmodel.CategoryUsage, error) {
+	usage := pkgmodel.CategoryUsage{CategoryID: id}
+	err := q.QueryRow("SELECT name FROM categories WHERE id = ? AND deleted_at IS NULL", id).Scan(&usage.Name)
+	if err != nil {
+		return usage, err
+	}
+
+	var trashedEntries, trashedTasks int
+	err = q.QueryRow(`
+		SELECT COUNT(*), COALESCE(SUM(duration), 0), COUNT(deleted_at) FROM time_entries
+		WHERE category_id = ? OR category = ?`, id, usage.Name).Scan(&usage.Entries, &usage.TotalMinutes, &trashedEntries)
+	if err != nil {
+		return usage, err
+	}
+
+	err = q.QueryRow("SELECT COUNT(*), COUNT(deleted_at) FROM tasks WHERE category_id = ?", id).Scan(&usage.Tasks, &trashedTasks)
+	usage.Trashed = trashedEntries + trashedTasks
+	return usage, err
+}
+
+func GetCategoryUsage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	usage, err := queryCategoryUsage(pkgglobal.Db, id)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Category not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(usage)
+}
+
+// reassignCategory moves all entries and tasks of a category to the target category
+func reassignCategory(q Querier, id int, name string, targetID int, target string) error {
+	entryIDs, err := matchingIDs(q, AuditEntry, "category_id = ? OR category = ?", id, name)
+	if err != nil {
+		return err
+	}
+	for _, entryID := range entryIDs {
+		err := AuditedWrite(q, AuditEntry, entryID, AuditUpdate, func() error {
+			_, err := q.Exec("UPDATE time_entries SET category = ?, category_id = ? WHERE id = ?", target, targetID, entryID)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	taskIDs, err := matchingIDs(q, AuditTask, "category_id = ?", id)
+	if err != nil {
+		return err
+	}
+	for _, taskID := range taskIDs {
+		err := AuditedWrite(q, AuditTask, taskID, AuditUpdate, func() error {
+			_, err := q.Exec("UPDATE tasks SET category_id = ? WHERE id = ?", targetID, taskID)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteCategory moves a category to the trash. It refuses to delete a category that is still
+// in use unless reassign_to names the category its entries and tasks are moved to; entries
+// and tasks already in the trash are moved as well, so they can be restored.
 func DeleteCategory(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
@@ -123,11 +349,24 @@ func DeleteCategory(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get category details before deletion for logging
-	var name, color string
-	err = pkgglobal.Db.QueryRow("SELECT name, color FROM categories WHERE id = ?", id).Scan(&name, &color)
+	reassignTo := 0
+	if v := r.URL.Query().Get("reassign_to"); v != "" {
+		if reassignTo, err = strconv.Atoi(v); err != nil || reassignTo == id {
+			http.Error(w, "Invalid reassign_to. Expected the ID of another category", http.StatusBadRequest)
+			return
+		}
+	}
+
+	tx, err := BeginChange()
 	if err != nil {
-		if err.Error() == "sql: no rows in result set" {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	usage, err := queryCategoryUsage(tx, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
 			log.Printf("WARNING: Attempted to delete non-existent category ID %d", id)
 			http.Error(w, "Category not found", http.StatusNotFound)
 			return
@@ -137,14 +376,53 @@ func DeleteCategory(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_, err = pkgglobal.Db.Exec("DELETE FROM categories WHERE id = ?", id)
+	if reassignTo > 0 {
+		var target string
+		err := tx.QueryRow("SELECT name FROM categories WHERE id = ? AND deleted_at IS NULL", reassignTo).Scan(&target)
+		if err == sql.ErrNoRows {
+			http.Error(w, "Invalid reassign_to. Category does not exist", http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		err = reassignCategory(tx, id, usage.Name, reassignTo, target)
+		if err != nil {
+			log.Printf("ERROR: Failed to reassign category ID %d to %d - Error: %v", id, reassignTo, err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		log.Printf("UPDATE: Moved %d time entries and %d tasks from category %s to %s",
+			usage.Entries, usage.Tasks, usage.Name, target)
+	} else if usage.Entries > 0 || usage.Tasks > 0 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error": "Category is still in use. Pass reassign_to to move its entries and tasks to another category",
+			"usage": usage,
+		})
+		return
+	}
+
+	err = AuditedWrite(tx, AuditCategory, id, AuditDelete, func() error {
+		_, err := tx.Exec("UPDATE categories SET deleted_at = ? WHERE id = ?",
+			pkgutil.FormatTimeForDB(pkgutil.CurrentWallClockTime()), id)
+		return err
+	})
 	if err != nil {
 		log.Printf("ERROR: Failed to delete category ID %d - Error: %v", id, err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	log.Printf("DELETE: Removed category ID %d - Name: %s, Color: %s", id, name, color)
+	if err := tx.Commit(w, r); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("DELETE: Moved category ID %d to the trash - Name: %s", id, usage.Name)
 
 	w.WriteHeader(http.StatusNoContent)
 }
@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pkgmodel "timesheet/go/model"
+)
+
+// deleteCategory calls DeleteCategory for the given ID and query
+func deleteCategory(id, query string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodDelete, "/api/categories/"+id+query, nil)
+	req = mux.SetURLVars(req, map[string]string{"id": id})
+	req.Header.Set("X-Session-ID", testSession)
+	w := httptest.NewRecorder()
+	DeleteCategory(w, req)
+	return w
+}
+
+func TestGetCategoryUsage(t *testing.T) {
+	db := setupHandlerTestDB(t)
+	insertTestEntry(t, db, "Development", "project work", "2025-11-10T09:00:00Z", "2025-11-10T10:30:00Z", 90)
+	insertTestEntry(t, db, "Review", "project work", "2025-11-10T11:00:00Z", "2025-11-10T11:30:00Z", 30)
+	insertTestEntry(t, db, "Support", "project support", "2025-11-10T12:00:00Z", "2025-11-10T12:15:00Z", 15)
+	_, err := db.Exec("INSERT INTO tasks (name, category_id) VALUES ('Development', 1)")
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/categories/1/usage", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": "1"})
+	w := httptest.NewRecorder()
+	GetCategoryUsage(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var usage pkgmodel.CategoryUsage
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&usage))
+	assert.Equal(t, pkgmodel.CategoryUsage{CategoryID: 1, Name: "project work", Entries: 2, TotalMinutes: 120, Tasks: 1}, usage)
+}
+
+func TestDeleteCategoryInUseIsRejected(t *testing.T) {
+	db := setupHandlerTestDB(t)
+	insertTestEntry(t, db, "Development", "project work", "2025-11-10T09:00:00Z", "2025-11-10T10:30:00Z", 90)
+
+	w := deleteCategory("1", "")
+
+	require.Equal(t, http.StatusConflict, w.Code)
+	var body struct {
+		Error string                 `json:"error"`
+		Usage pkgmodel.CategoryUsage `json:"usage"`
+	}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+	assert.Equal(t, 1, body.Usage.Entries)
+	assert.Equal(t, 90, body.Usage.TotalMinutes)
+
+	var count int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM categories").Scan(&count))
+	assert.Equal(t, 3, count)
+}
+
+func TestDeleteCategoryWithReassignment(t *testing.T) {
+	db := setupHandlerTestDB(t)
+	insertTestEntry(t, db, "Development", "project work", "2025-11-10T09:00:00Z", "2025-11-10T10:30:00Z", 90)
+	_, err := db.Exec("INSERT INTO tasks (name, category_id) VALUES ('Development', 1)")
+	require.NoError(t, err)
+
+	w := deleteCategory("1", "?reassign_to=2")
+
+	require.Equal(t, http.StatusNoContent, w.Code, w.Body.String())
+	var category string
+	var categoryID, taskCategoryID int
+	require.NoError(t, db.QueryRow("SELECT category, category_id FROM time_entries").Scan(&category, &categoryID))
+	assert.Equal(t, "project support", category)
+	assert.Equal(t, 2, categoryID)
+	require.NoError(t, db.QueryRow("SELECT category_id FROM tasks").Scan(&taskCategoryID))
+	assert.Equal(t, 2, taskCategoryID)
+}
+
+func TestDeleteCategoryValidatesReassignment(t *testing.T) {
+	db := setupHandlerTestDB(t)
+
+	assert.Equal(t, http.StatusBadRequest, deleteCategory("1", "?reassign_to=1").Code)
+	assert.Equal(t, http.StatusBadRequest, deleteCategory("1", "?reassign_to=99").Code)
+	assert.Equal(t, http.StatusNotFound, deleteCategory("99", "").Code)
+
+	// An unused category is moved to the trash without reassignment
+	assert.Equal(t, http.StatusNoContent, deleteCategory("2", "").Code)
+	var count int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM categories WHERE deleted_at IS NULL").Scan(&count))
+	assert.Equal(t, 2, count)
+}
+
+func TestCategoryUsageCountsTrashedEntries(t *testing.T) {
+	db := setupHandlerTestDB(t)
+	insertTestEntry(t, db, "Development", "project work", "2025-11-10T09:00:00Z", "2025-11-10T10:30:00Z", 90)
+	_, err := db.Exec(`
+		INSERT INTO tasks (name, category_id, deleted_at) VALUES ('Development', 1, '2025-11-20T12:00:00Z');
+		UPDATE time_entries SET deleted_at = '2025-11-20T12:00:00Z';`)
+	require.NoError(t, err)
+
+	// The trashed entry and task could not be restored without their category
+	w := deleteCategory("1", "")
+	require.Equal(t, http.StatusConflict, w.Code, w.Body.String())
+	var body struct {
+		Usage pkgmodel.CategoryUsage `json:"usage"`
+	}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+	assert.Equal(t, pkgmodel.CategoryUsage{CategoryID: 1, Name: "project work", Entries: 1, TotalMinutes: 90, Tasks: 1, Trashed: 2}, body.Usage)
+
+	// Reassignment moves them along, so they can still be restored
+	require.Equal(t, http.StatusNoContent, deleteCategory("1", "?reassign_to=2").Code)
+	var category string
+	var taskCategoryID int
+	require.NoError(t, db.QueryRow("SELECT category FROM time_entries").Scan(&category))
+	assert.Equal(t, "project support", category)
+	require.NoError(t, db.QueryRow("SELECT category_id FROM tasks").Scan(&taskCategoryID))
+	assert.Equal(t, 2, taskCategoryID)
+	assert.Equal(t, http.StatusNoContent, trashRequest(RestoreTrashItem, http.MethodPost, "entries", "1").Code)
+}
+
+func TestUpdateCategoryRequiresExistingCategory(t *testing.T) {
+	db := setupHandlerTestDB(t)
+	category := pkgmodel.CategoryRequest{Name: "client work", Color: "#48bb78"}
+
+	assert.Equal(t, http.StatusNotFound, sendJSON(t, UpdateCategory, http.MethodPut, "/api/categories/99", "99", category).Code)
+
+	// A category in the trash has to be restored first
+	_, err := db.Exec("UPDATE categories SET deleted_at = '2025-11-20T12:00:00Z' WHERE id = 1")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, sendJSON(t, UpdateCategory, http.MethodPut, "/api/categories/1", "1", category).Code)
+	var name string
+	require.NoError(t, db.QueryRow("SELECT name FROM categories WHERE id = 1").Scan(&name))
+	assert.Equal(t, "project work", name)
+}
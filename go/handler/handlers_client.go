@@ -0,0 +1,241 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	pkgglobal "timesheet/go/global"
+	pkgmodel "timesheet/go/model"
+
+	"github.com/gorilla/mux"
+)
+
+// Client handlers
+func GetClients(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	rows, err := pkgglobal.Db.Query("SELECT id, name FROM clients ORDER BY name")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	clients := []pkgmodel.Client{}
+	for rows.Next() {
+		var client pkgmodel.Client
+		if err := rows.Scan(&client.ID, &client.Name); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		clients = append(clients, client)
+	}
+
+	json.NewEncoder(w).Encode(clients)
+}
+
+// clientNameTaken reports whether another client than id already has the name
+func clientNameTaken(q Querier, name string, id int) (bool, error) {
+	var taken bool
+	err := q.QueryRow("SELECT EXISTS(SELECT 1 FROM clients WHERE name = ? AND id != ?)", name, id).Scan(&taken)
+	return taken, err
+}
+
+func CreateClient(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req pkgmodel.ClientRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.Name == "" {
+		http.Error(w, "Client name is required", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := BeginChange()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	taken, err := clientNameTaken(tx, req.Name, 0)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if taken {
+		http.Error(w, "A client with this name already exists", http.StatusConflict)
+		return
+	}
+
+	result, err := tx.Exec("INSERT INTO clients (name) VALUES (?)", req.Name)
+	if err != nil {
+		log.Printf("ERROR: Failed to insert client - Name: %s - Error: %v", req.Name, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	id, _ := result.LastInsertId()
+	if err := AuditInsert(tx, AuditClient, int(id)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(w, r); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("INSERT: Created client ID %d - Name: %s", id, req.Name)
+
+	json.NewEncoder(w).Encode(pkgmodel.Client{ID: int(id), Name: req.Name})
+}
+
+func UpdateClient(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	var req pkgmodel.ClientRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.Name == "" {
+		http.Error(w, "Client name is required", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := BeginChange()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	exists, err := clientExists(tx, id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !exists {
+		http.Error(w, "Client not found", http.StatusNotFound)
+		return
+	}
+	taken, err := clientNameTaken(tx, req.Name, id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if taken {
+		http.Error(w, "A client with this name already exists", http.StatusConflict)
+		return
+	}
+
+	err = AuditedWrite(tx, AuditClient, id, AuditUpdate, func() error {
+		_, err := tx.Exec("UPDATE clients SET name = ? WHERE id = ?", req.Name, id)
+		return err
+	})
+	if err != nil {
+		log.Printf("ERROR: Failed to update client ID %d - Name: %s - Error: %v", id, req.Name, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(w, r); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("UPDATE: Modified client ID %d - Name: %s", id, req.Name)
+
+	json.NewEncoder(w).Encode(pkgmodel.Client{ID: id, Name: req.Name})
+}
+
+// DeleteClient removes a client. Clients that still have projects are kept and reported
+// with 409 Conflict.
+func DeleteClient(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := BeginChange()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	var name string
+	var projects int
+	err = tx.QueryRow("SELECT name, (SELECT COUNT(*) FROM projects WHERE client_id = clients.id) FROM clients WHERE id = ?", id).
+		Scan(&name, &projects)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			log.Printf("WARNING: Attempted to delete non-existent client ID %d", id)
+			http.Error(w, "Client not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("ERROR: Failed to fetch client ID %d for deletion - Error: %v", id, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if projects > 0 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error":    "Client still has projects. Delete or move them first",
+			"projects": projects,
+		})
+		return
+	}
+
+	err = AuditedWrite(tx, AuditClient, id, AuditDelete, func() error {
+		_, err := tx.Exec("DELETE FROM clients WHERE id = ?", id)
+		return err
+	})
+	if err != nil {
+		log.Printf("ERROR: Failed to delete client ID %d - Error: %v", id, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(w, r); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("DELETE: Deleted client ID %d - Name: %s", id, name)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// clientNamed reports whether a client with the given name exists
+func clientNamed(q Querier, name string) (bool, error) {
+	var exists bool
+	err := q.QueryRow("SELECT EXISTS(SELECT 1 FROM clients WHERE name = ?)", name).Scan(&exists)
+	return exists, err
+}
+
+// clientExists reports whether a client with the given ID exists
+func clientExists(q Querier, id int) (bool, error) {
+	var exists bool
+	err := q.QueryRow("SELECT EXISTS(SELECT 1 FROM clients WHERE id = ?)", id).Scan(&exists)
+	return exists, err
+}
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	pkgglobal "timesheet/go/global"
+	pkgmodel "timesheet/go/model"
+)
+
+// queryCompany reads the company details; they are empty until they are first saved
+func queryCompany(q Querier) (pkgmodel.Company, error) {
+	var company pkgmodel.Company
+	err := q.QueryRow("SELECT name, address, email, phone, tax_id, bank_details FROM company WHERE id = 1").
+		Scan(&company.Name, &company.Address, &company.Email, &company.Phone, &company.TaxID, &company.BankDetails)
+	if err == sql.ErrNoRows {
+		return company, nil
+	}
+	return company, err
+}
+
+// Company handlers
+func GetCompany(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	company, err := queryCompany(pkgglobal.Db)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(company)
+}
+
+// UpdateCompany replaces the company details printed on documents
+func UpdateCompany(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var company pkgmodel.Company
+	if err := json.NewDecoder(r.Body).Decode(&company); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tx, err := BeginChange()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	// The details are kept in the single row with ID 1, which is created on the first save
+	var exists bool
+	if err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM company WHERE id = 1)").Scan(&exists); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	args := []interface{}{company.Name, company.Address, company.Email, company.Phone, company.TaxID, company.BankDetails}
+	if exists {
+		err = AuditedWrite(tx, AuditCompany, 1, AuditUpdate, func() error {
+			_, err := tx.Exec("UPDATE company SET name = ?, address = ?, email = ?, phone = ?, tax_id = ?, bank_details = ? WHERE id = 1", args...)
+			return err
+		})
+	} else {
+		_, err = tx.Exec("INSERT INTO company (id, name, address, email, phone, tax_id, bank_details) VALUES (1, ?, ?, ?, ?, ?, ?)", args...)
+		if err == nil {
+			err = AuditInsert(tx, AuditCompany, 1)
+		}
+	}
+	if err != nil {
+		log.Printf("ERROR: Failed to update company details - Error: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(w, r); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("UPDATE: Modified company details - Name: %s", company.Name)
+
+	json.NewEncoder(w).Encode(company)
+}
@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	pkgglobal "timesheet/go/global"
+	pkgmodel "timesheet/go/model"
+	pkgutil "timesheet/go/util"
+)
+
+// writeOverlapError answers with 409 and the conflicting IDs for an *OverlapError,
+// and with 500 for anything else
+func writeOverlapError(w http.ResponseWriter, err error) {
+	var overlapErr *OverlapError
+	if !errors.As(err, &overlapErr) {
+		log.Printf("ERROR: Failed to check overlapping time entries - Error: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusConflict)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error":     "Time entry overlaps existing entries",
+		"conflicts": overlapErr.ConflictIDs,
+	})
+}
+
+// Conflict report handler
+func GetTimeEntryConflicts(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	from, to, err := ParseDateRange(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Running timers count as ending now
+	now := pkgutil.CurrentWallClockTime()
+	query := `
+		SELECT a.id, a.task, a.description, a.category, a.start_time, a.end_time, a.duration, a.category_id, a.task_id, a.project_id, a.billable,
+			b.id, b.task, b.description, b.category, b.start_time, b.end_time, b.duration, b.category_id, b.task_id, b.project_id, b.billable
+		FROM time_entries a
+		JOIN time_entries b ON a.id < b.id
+			AND datetime(a.start_time) < datetime(COALESCE(b.end_time, ?1))
+			AND datetime(b.start_time) < datetime(COALESCE(a.end_time, ?1))
+		WHERE a.deleted_at IS NULL AND b.deleted_at IS NULL`
+	args := []interface{}{pkgutil.FormatTimeForDB(now)}
+	if !from.IsZero() {
+		query += " AND datetime(COALESCE(a.end_time, ?1)) > datetime(?) AND datetime(COALESCE(b.end_time, ?1)) > datetime(?)"
+		args = append(args, from.Format(time.RFC3339), from.Format(time.RFC3339))
+	}
+	if !to.IsZero() {
+		query += " AND datetime(a.start_time) < datetime(?) AND datetime(b.start_time) < datetime(?)"
+		args = append(args, to.Format(time.RFC3339), to.Format(time.RFC3339))
+	}
+	query += " ORDER BY a.start_time, a.id, b.id"
+
+	rows, err := pkgglobal.Db.Query(query, args...)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	conflicts := []pkgmodel.TimeEntryConflict{}
+	for rows.Next() {
+		var first, second timeEntryRow
+		err := rows.Scan(append(first.fields(), second.fields()...)...)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		conflict := pkgmodel.TimeEntryConflict{First: first.toEntry(), Second: second.toEntry()}
+
+		// The overlap runs from the later start to the earlier end
+		overlapStart, overlapEnd := conflict.First.StartTime, now
+		if conflict.First.EndTime != nil {
+			overlapEnd = *conflict.First.EndTime
+		}
+		if conflict.Second.StartTime.After(overlapStart) {
+			overlapStart = conflict.Second.StartTime
+		}
+		if conflict.Second.EndTime != nil && conflict.Second.EndTime.Before(overlapEnd) {
+			overlapEnd = *conflict.Second.EndTime
+		}
+		conflict.OverlapMinutes = int(overlapEnd.Sub(overlapStart).Minutes())
+
+		conflicts = append(conflicts, conflict)
+	}
+
+	json.NewEncoder(w).Encode(conflicts)
+}
@@ -0,0 +1,281 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	pkgglobal "timesheet/go/global"
+	pkgmodel "timesheet/go/model"
+	pkgutil "timesheet/go/util"
+)
+
+// documentFuncs are the functions available in the document templates
+var documentFuncs = template.FuncMap{
+	"hours": formatHours,
+}
+
+// formatHours formats minutes as hours and minutes, e.g. 1:05
+func formatHours(minutes int) string {
+	return fmt.Sprintf("%d:%02d", minutes/60, minutes%60)
+}
+
+// documentPage holds what every document shows around its content: the company header
+// and the signature lines
+type documentPage struct {
+	Title   string
+	Company pkgmodel.Company
+	Client  string
+	Period  string
+	Created string
+	// Rounding describes the rounding rules applied to the durations, empty for exact minutes
+	Rounding   string
+	Signatures []string
+}
+
+// timesheetTask holds the entries of one task on the timesheet
+type timesheetTask struct {
+	Task    string
+	Entries []timesheetEntry
+	Minutes int
+}
+
+// timesheetEntry is one line of the timesheet
+type timesheetEntry struct {
+	Date, Start, End, Category, Description string
+	Minutes                                 int
+}
+
+type timesheetDocument struct {
+	documentPage
+	Tasks        []timesheetTask
+	TotalMinutes int
+}
+
+// invoiceGroup holds the line items of one task on the invoice
+type invoiceGroup struct {
+	Task  string
+	Items []pkgmodel.BillingLineItem
+}
+
+type invoiceDocument struct {
+	documentPage
+	Number         string
+	Groups         []invoiceGroup
+	Totals         []pkgmodel.CurrencyTotal
+	UnratedEntries int
+}
+
+// renderDocument executes the named template within document.html and writes the page
+func renderDocument(w http.ResponseWriter, name string, data interface{}) {
+	tmpl, err := template.New("document.html").Funcs(documentFuncs).ParseFS(pkgglobal.Templates, "document.html", name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	// Render into a buffer so that a failing template does not leave half a page
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(buf.Bytes())
+}
+
+// documentPeriod reads the period of a document: either from and to, or a month (YYYY-MM)
+// which defaults to the current month. It returns the range and its label.
+func documentPeriod(query url.Values) (from, to time.Time, label string, err error) {
+	if query.Get("from") != "" || query.Get("to") != "" {
+		if query.Get("from") == "" || query.Get("to") == "" {
+			return from, to, "", fmt.Errorf("both from and to are required")
+		}
+		from, to, err = ParseDateRange(query)
+		return from, to, query.Get("from") + " – " + query.Get("to"), err
+	}
+
+	month := pkgutil.CurrentWallClockTime()
+	if v := query.Get("month"); v != "" {
+		if month, err = time.Parse("2006-01", v); err != nil {
+			return from, to, "", fmt.Errorf("invalid month '%s'. Expected YYYY-MM", v)
+		}
+	}
+	from = time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return from, from.AddDate(0, 1, 0), from.Format("January 2006"), nil
+}
+
+// roundingNote describes the applied rounding rules unless all of them keep exact minutes
+func roundingNote(rules []pkgmodel.RoundingRule) string {
+	for _, rule := range rules {
+		if rule.Mode != RoundNone {
+			return describeRoundingRules(rules)
+		}
+	}
+	return ""
+}
+
+// newDocumentPage reads the company details and validates the client, if one is given
+func newDocumentPage(w http.ResponseWriter, title, client, period string) (documentPage, bool) {
+	page := documentPage{
+		Title:   title,
+		Client:  client,
+		Period:  period,
+		Created: pkgutil.CurrentWallClockTime().Format("2006-01-02"),
+	}
+	var err error
+	if page.Company, err = queryCompany(pkgglobal.Db); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return page, false
+	}
+	if client != "" {
+		exists, err := clientNamed(pkgglobal.Db, client)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return page, false
+		}
+		if !exists {
+			http.Error(w, "Invalid client. Client does not exist", http.StatusBadRequest)
+			return page, false
+		}
+	}
+	return page, true
+}
+
+// Document handlers
+
+// GetTimesheetDocument renders the printable timesheet of a month or date range with the
+// entries grouped by task, optionally only those of one client
+func GetTimesheetDocument(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	from, to, period, err := documentPeriod(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	exact, err := ParseRoundingParam(query.Get("rounding"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	page, ok := newDocumentPage(w, "Timesheet", query.Get("client"), period)
+	if !ok {
+		return
+	}
+	page.Signatures = []string{"Date, signature contractor", "Date, signature client"}
+	book, err := newRoundingBook(pkgglobal.Db, exact)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Running timers have no duration yet and are left out
+	where, args := TimeEntryFilter{From: from, To: to}.WhereClause(false)
+	where += " AND end_time IS NOT NULL"
+	if page.Client != "" {
+		where += " AND project_id IN (SELECT p.id FROM projects p JOIN clients c ON c.id = p.client_id WHERE c.name = ?)"
+		args = append(args, page.Client)
+	}
+	rows, err := pkgglobal.Db.Query("SELECT "+timeEntryColumns+" FROM time_entries"+where+" ORDER BY start_time, id", args...)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+	entries, err := scanTimeEntries(rows)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	doc := timesheetDocument{documentPage: page}
+	rounder := book.newRounder()
+	tasks := map[string]int{}
+	for _, entry := range entries {
+		entry.Duration = rounder.round(entry.StartTime.Format("2006-01-02"), projectOf(entry), entry.Duration)
+		i, ok := tasks[entry.Task]
+		if !ok {
+			i = len(doc.Tasks)
+			tasks[entry.Task] = i
+			doc.Tasks = append(doc.Tasks, timesheetTask{Task: entry.Task})
+		}
+		task := &doc.Tasks[i]
+		task.Entries = append(task.Entries, timesheetEntry{
+			Date:        entry.StartTime.Format("2006-01-02"),
+			Start:       entry.StartTime.Format("15:04"),
+			End:         entry.EndTime.Format("15:04"),
+			Category:    entry.Category,
+			Description: entry.Description,
+			Minutes:     entry.Duration,
+		})
+		task.Minutes += entry.Duration
+		doc.TotalMinutes += entry.Duration
+	}
+	sort.SliceStable(doc.Tasks, func(i, j int) bool { return doc.Tasks[i].Task < doc.Tasks[j].Task })
+	doc.Rounding = roundingNote(rounder.appliedRules())
+
+	renderDocument(w, "timesheet.html", doc)
+}
+
+// GetInvoiceDocument renders the printable invoice of a client for a month or date range.
+// The line items are those of the billing report, grouped by task.
+func GetInvoiceDocument(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("client") == "" {
+		http.Error(w, "client is required", http.StatusBadRequest)
+		return
+	}
+	from, to, period, err := documentPeriod(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	exact, err := ParseRoundingParam(query.Get("rounding"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	page, ok := newDocumentPage(w, "Invoice", query.Get("client"), period)
+	if !ok {
+		return
+	}
+	page.Signatures = []string{"Date, signature"}
+	book, err := newRoundingBook(pkgglobal.Db, exact)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	where, args := TimeEntryFilter{From: from, To: to}.WhereClause(false)
+	where += " AND e.end_time IS NOT NULL AND e.billable = 1 AND cl.name = ?"
+	args = append(args, page.Client)
+	report, err := queryBillingReport(pkgglobal.Db, where, args, book)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	page.Rounding = roundingNote(report.Rounding)
+	doc := invoiceDocument{
+		documentPage:   page,
+		Number:         query.Get("number"),
+		Totals:         report.Totals,
+		UnratedEntries: len(report.UnratedEntryIDs),
+	}
+	groups := map[string]int{}
+	for _, item := range report.Items {
+		i, ok := groups[item.Task]
+		if !ok {
+			i = len(doc.Groups)
+			groups[item.Task] = i
+			doc.Groups = append(doc.Groups, invoiceGroup{Task: item.Task})
+		}
+		doc.Groups[i].Items = append(doc.Groups[i].Items, item)
+	}
+	sort.SliceStable(doc.Groups, func(i, j int) bool { return doc.Groups[i].Task < doc.Groups[j].Task })
+
+	renderDocument(w, "invoice.html", doc)
+}
@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pkgglobal "timesheet/go/global"
+	pkgmodel "timesheet/go/model"
+)
+
+// setupDocumentTest prepares the projects of setupProjects, the company details and the
+// templates of the repository
+func setupDocumentTest(t *testing.T) {
+	setupHandlerTestDB(t)
+	setupProjects(t)
+
+	previous := pkgglobal.Templates
+	pkgglobal.SetTemplates(os.DirFS("../../templates"))
+	t.Cleanup(func() { pkgglobal.SetTemplates(previous) })
+
+	company := pkgmodel.Company{Name: "Jane Doe Consulting", Address: "Main Street 1\n12345 Springfield", BankDetails: "IBAN DE00 1234"}
+	require.Equal(t, http.StatusOK, sendJSON(t, UpdateCompany, http.MethodPut, "/api/company", "", company).Code)
+}
+
+// getDocument calls a document handler with the given query string
+func getDocument(handler http.HandlerFunc, query string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodGet, "/documents"+query, nil))
+	return w
+}
+
+func TestTimesheetDocument(t *testing.T) {
+	setupDocumentTest(t)
+	for _, entry := range []pkgmodel.TimeEntryRequest{
+		{Task: "Development", Category: "project work", Description: "<b>feature</b>", StartTime: "2025-11-10T09:00:00Z", EndTime: "2025-11-10T10:30:00Z"},
+		{Task: "Meeting", Category: "project support", StartTime: "2025-11-11T09:00:00Z", EndTime: "2025-11-11T09:15:00Z"},
+		{Task: "Development", Category: "project work", StartTime: "2025-11-12T09:00:00Z", EndTime: "2025-11-12T10:00:00Z"},
+		{Task: "Development", Category: "project work", StartTime: "2025-12-01T09:00:00Z", EndTime: "2025-12-01T10:00:00Z"},
+	} {
+		require.Equal(t, http.StatusOK, sendJSON(t, CreateTimeEntry, http.MethodPost, "/api/entries", "", entry).Code)
+	}
+
+	w := getDocument(GetTimesheetDocument, "/timesheet?month=2025-11")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+	body := w.Body.String()
+	assert.Contains(t, body, "Jane Doe Consulting")
+	assert.Contains(t, body, "November 2025")
+	assert.Contains(t, body, "&lt;b&gt;feature&lt;/b&gt;")
+	assert.Contains(t, body, "Date, signature client")
+	// Development is listed before Meeting with a subtotal of both November entries
+	assert.Less(t, strings.Index(body, "<h2>Development</h2>"), strings.Index(body, "<h2>Meeting</h2>"))
+	assert.Contains(t, body, `<td colspan="4">Subtotal</td><td class="number">2:30</td>`)
+	assert.Contains(t, body, `<td>Total</td><td class="number">2:45</td>`)
+
+	// Only the entries of the client's projects
+	w = getDocument(GetTimesheetDocument, "/timesheet?from=2025-11-01&to=2025-11-30&client=Acme")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.NotContains(t, w.Body.String(), "<h2>Meeting</h2>")
+
+	assert.Equal(t, http.StatusBadRequest, getDocument(GetTimesheetDocument, "/timesheet?month=11-2025").Code)
+	assert.Equal(t, http.StatusBadRequest, getDocument(GetTimesheetDocument, "/timesheet?from=2025-11-01").Code)
+	assert.Equal(t, http.StatusBadRequest, getDocument(GetTimesheetDocument, "/timesheet?client=Nobody").Code)
+}
+
+func TestInvoiceDocument(t *testing.T) {
+	setupDocumentTest(t)
+	w := sendJSON(t, CreateRate, http.MethodPost, "/api/rates", "", `{"scope": "project", "scope_id": 1, "hourly_rate": "95.50", "currency": "EUR", "effective_from": "2025-01-01"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	entry := pkgmodel.TimeEntryRequest{Task: "Development", Category: "project work", StartTime: "2025-11-10T09:00:00Z", EndTime: "2025-11-10T10:20:00Z"}
+	require.Equal(t, http.StatusOK, sendJSON(t, CreateTimeEntry, http.MethodPost, "/api/entries", "", entry).Code)
+
+	w = getDocument(GetInvoiceDocument, "/invoice?client=Acme&month=2025-11&number=2025-042")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	body := w.Body.String()
+	assert.Contains(t, body, "Invoice no. 2025-042")
+	assert.Contains(t, body, "Bill to: Acme")
+	assert.Contains(t, body, "<h2>Development</h2>")
+	assert.Contains(t, body, "127.02 EUR")
+	assert.Contains(t, body, "IBAN DE00 1234")
+
+	assert.Equal(t, http.StatusBadRequest, getDocument(GetInvoiceDocument, "/invoice?month=2025-11").Code)
+	assert.Equal(t, http.StatusBadRequest, getDocument(GetInvoiceDocument, "/invoice?client=Nobody").Code)
+}
+
+func TestDocumentTemplatesParse(t *testing.T) {
+	previous := pkgglobal.Templates
+	pkgglobal.SetTemplates(os.DirFS("../../templates"))
+	t.Cleanup(func() { pkgglobal.SetTemplates(previous) })
+
+	for _, doc := range []struct {
+		name string
+		data interface{}
+	}{
+		{"timesheet.html", timesheetDocument{}},
+		{"invoice.html", invoiceDocument{}},
+	} {
+		w := httptest.NewRecorder()
+		renderDocument(w, doc.name, doc.data)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.True(t, bytes.HasPrefix(w.Body.Bytes(), []byte("<!DOCTYPE html>")), doc.name)
+	}
+}
+
+func TestCompanyChangesAreAuditedAndUndoable(t *testing.T) {
+	setupUndoTest(t)
+	first := pkgmodel.Company{Name: "Jane Doe Consulting", Address: "Main Street 1"}
+	require.Equal(t, http.StatusOK, sendJSON(t, UpdateCompany, http.MethodPut, "/api/company", "", first).Code)
+	second := first
+	second.Name = "Doe & Partners"
+	require.Equal(t, http.StatusOK, sendJSON(t, UpdateCompany, http.MethodPut, "/api/company", "", second).Code)
+
+	records, err := queryAuditLog(pkgglobal.Db, " WHERE entity_type = ?", AuditCompany)
+	require.NoError(t, err)
+	// The migrations create the single company row, so it is only ever updated
+	require.Len(t, records, 2)
+	assert.Equal(t, "update", records[0].Operation)
+	assert.Equal(t, "update", records[1].Operation)
+
+	require.Equal(t, http.StatusOK, callUndo(Undo, testSession).Code)
+	company, err := queryCompany(pkgglobal.Db)
+	require.NoError(t, err)
+	assert.Equal(t, first, company)
+
+	require.Equal(t, http.StatusOK, callUndo(Undo, testSession).Code)
+	company, err = queryCompany(pkgglobal.Db)
+	require.NoError(t, err)
+	assert.Equal(t, pkgmodel.Company{}, company)
+}
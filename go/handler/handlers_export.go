@@ -0,0 +1,205 @@
+package handler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	pkgglobal "timesheet/go/global"
+	"timesheet/go/ical"
+)
+
+// Export handlers
+func ExportTimeEntries(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	formatName := query.Get("format")
+	if formatName == "" {
+		formatName = "csv"
+	}
+	format, ok := exportFormats[formatName]
+	if !ok {
+		http.Error(w, fmt.Sprintf("invalid format '%s'. Expected csv, xlsx or json", formatName), http.StatusBadRequest)
+		return
+	}
+
+	columns, err := ParseExportColumns(query.Get("columns"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	subtotals := false
+	if v := query.Get("subtotals"); v != "" {
+		if subtotals, err = strconv.ParseBool(v); err != nil {
+			http.Error(w, "invalid subtotals value. Expected true or false", http.StatusBadRequest)
+			return
+		}
+	}
+
+	from, to, err := ParseDateRange(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	exact, err := ParseRoundingParam(query.Get("rounding"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	book, err := newRoundingBook(pkgglobal.Db, exact)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Export finished entries in chronological order so days stay together
+	filter := TimeEntryFilter{From: from, To: to, Category: query.Get("category"), SortColumn: "start_time"}
+	where, args := filter.WhereClause(false)
+	where += " AND end_time IS NOT NULL"
+
+	rows, err := pkgglobal.Db.Query(`
+		SELECT `+timeEntryColumns+`
+		FROM time_entries`+where+filter.OrderClause(), args...)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	filename := "timesheet"
+	if v := query.Get("from"); v != "" {
+		filename += "_" + v
+	}
+	if v := query.Get("to"); v != "" {
+		filename += "_" + v
+	}
+	w.Header().Set("Content-Type", format.contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename+"."+format.extension))
+	// The file formats have no room for metadata, so the rounding rules go into a header
+	w.Header().Set("X-Rounding", book.describe())
+
+	writer, err := format.newWriter(w)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// From here on the response is streamed, so errors can only be logged
+	if err := writer.WriteHeader(columns); err != nil {
+		log.Printf("ERROR: Failed to write export header - Error: %v", err)
+		return
+	}
+
+	rounder := book.newRounder()
+	currentDate, dayMinutes, count := "", 0, 0
+	for rows.Next() {
+		var row timeEntryRow
+		if err := rows.Scan(row.fields()...); err != nil {
+			log.Printf("ERROR: Failed to read time entry for export - Error: %v", err)
+			return
+		}
+		entry := row.toEntry()
+
+		// Durations are rounded while start and end keep the recorded times
+		date := entry.StartTime.Format("2006-01-02")
+		entry.Duration = rounder.round(date, projectOf(entry), entry.Duration)
+		if subtotals && count > 0 && date != currentDate {
+			if err := writer.WriteRow(exportSubtotalRow(columns, currentDate, dayMinutes), true); err != nil {
+				log.Printf("ERROR: Failed to write export subtotal - Error: %v", err)
+				return
+			}
+			dayMinutes = 0
+		}
+		currentDate = date
+		dayMinutes += entry.Duration
+		count++
+
+		if err := writer.WriteRow(exportEntryRow(columns, entry), false); err != nil {
+			log.Printf("ERROR: Failed to write export row - Error: %v", err)
+			return
+		}
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("ERROR: Failed to read time entries for export - Error: %v", err)
+		return
+	}
+
+	if subtotals && count > 0 {
+		if err := writer.WriteRow(exportSubtotalRow(columns, currentDate, dayMinutes), true); err != nil {
+			log.Printf("ERROR: Failed to write export subtotal - Error: %v", err)
+			return
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		log.Printf("ERROR: Failed to finish export - Error: %v", err)
+		return
+	}
+
+	log.Printf("EXPORT: Exported %d time entries as %s", count, formatName)
+}
+
+// ExportTimeEntriesICS serves finished time entries as an iCalendar feed
+func ExportTimeEntriesICS(w http.ResponseWriter, r *http.Request) {
+	from, to, err := ParseDateRange(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filter := TimeEntryFilter{From: from, To: to, SortColumn: "start_time"}
+	where, args := filter.WhereClause(false)
+	where += " AND end_time IS NOT NULL"
+
+	rows, err := pkgglobal.Db.Query(`
+		SELECT `+timeEntryColumns+`
+		FROM time_entries`+where+filter.OrderClause(), args...)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="entries.ics"`)
+
+	// Stored times are local wall-clock times, so events are written as floating times
+	calendar := ical.NewWriter(w, "-//timesheet//Time Entries//EN", "Timesheet", time.Now())
+	for rows.Next() {
+		var row timeEntryRow
+		if err := rows.Scan(row.fields()...); err != nil {
+			log.Printf("ERROR: Failed to read time entry for calendar export - Error: %v", err)
+			return
+		}
+		entry := row.toEntry()
+		if entry.EndTime == nil {
+			continue
+		}
+
+		err := calendar.WriteEvent(ical.Event{
+			UID:         fmt.Sprintf("time-entry-%d@timesheet", entry.ID),
+			Summary:     entry.Task,
+			Description: entry.Description,
+			Categories:  []string{entry.Category},
+			Start:       entry.StartTime,
+			End:         *entry.EndTime,
+			Floating:    true,
+		})
+		if err != nil {
+			log.Printf("ERROR: Failed to write calendar event - Error: %v", err)
+			return
+		}
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("ERROR: Failed to read time entries for calendar export - Error: %v", err)
+		return
+	}
+
+	if err := calendar.Close(); err != nil {
+		log.Printf("ERROR: Failed to finish calendar export - Error: %v", err)
+	}
+}
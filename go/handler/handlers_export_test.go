@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func exportTimeEntries(query string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/api/export?"+query, nil)
+	w := httptest.NewRecorder()
+	ExportTimeEntries(w, req)
+	return w
+}
+
+func TestExportTimeEntriesCSVWithSubtotals(t *testing.T) {
+	db := setupHandlerTestDB(t)
+	insertTestEntry(t, db, "Development", "project work", "2025-11-10T09:00:00Z", "2025-11-10T10:30:00Z", 90)
+	insertTestEntry(t, db, "Review, PR", "project work", "2025-11-10T11:00:00Z", "2025-11-10T11:30:00Z", 30)
+	insertTestEntry(t, db, "Support", "project support", "2025-11-11T09:00:00Z", "2025-11-11T09:15:00Z", 15)
+
+	w := exportTimeEntries("format=csv&columns=date,task,duration_minutes,start_time&subtotals=true")
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="timesheet.csv"`, w.Header().Get("Content-Disposition"))
+	assert.Equal(t, "Date,Task,Duration (Minutes),Start Time\n"+
+		"2025-11-10,Development,90,2025-11-10 09:00\n"+
+		"2025-11-10,\"Review, PR\",30,2025-11-10 11:00\n"+
+		"2025-11-10,Subtotal,120,\n"+
+		"2025-11-11,Support,15,2025-11-11 09:00\n"+
+		"2025-11-11,Subtotal,15,\n", w.Body.String())
+}
+
+func TestExportTimeEntriesJSONFiltered(t *testing.T) {
+	db := setupHandlerTestDB(t)
+	insertTestEntry(t, db, "Development", "project work", "2025-11-10T09:00:00Z", "2025-11-10T10:30:00Z", 90)
+	insertTestEntry(t, db, "Support", "project support", "2025-11-11T09:00:00Z", "2025-11-11T09:15:00Z", 15)
+	insertTestEntry(t, db, "Support", "project support", "2025-12-01T09:00:00Z", "2025-12-01T09:15:00Z", 15)
+
+	w := exportTimeEntries("format=json&from=2025-11-01&to=2025-11-30&category=project+support&columns=task,duration_hours")
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var rows []map[string]interface{}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&rows))
+	assert.Equal(t, []map[string]interface{}{{"task": "Support", "duration_hours": 0.25}}, rows)
+}
+
+func TestExportTimeEntriesXLSX(t *testing.T) {
+	db := setupHandlerTestDB(t)
+	insertTestEntry(t, db, "Development", "project work", "2025-11-10T09:00:00Z", "2025-11-10T10:30:00Z", 90)
+
+	w := exportTimeEntries("format=xlsx&from=2025-11-01&to=2025-11-30")
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `attachment; filename="timesheet_2025-11-01_2025-11-30.xlsx"`, w.Header().Get("Content-Disposition"))
+	// xlsx files are zip archives
+	assert.Equal(t, "PK", w.Body.String()[:2])
+}
+
+func TestExportTimeEntriesQuotesFormulas(t *testing.T) {
+	db := setupHandlerTestDB(t)
+	insertTestEntry(t, db, "=HYPERLINK(\"http://example.com\")", "project work", "2025-11-10T09:00:00Z", "2025-11-10T10:30:00Z", 90)
+	insertTestEntry(t, db, "@SUM(A1)", "project work", "2025-11-10T11:00:00Z", "2025-11-10T11:30:00Z", 30)
+
+	w := exportTimeEntries("format=csv&columns=task,duration_minutes")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "Task,Duration (Minutes)\n"+
+		"\"'=HYPERLINK(\"\"http://example.com\"\")\",90\n"+
+		"'@SUM(A1),30\n", w.Body.String())
+
+	w = exportTimeEntries("format=xlsx&columns=task")
+	require.Equal(t, http.StatusOK, w.Code)
+	archive, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	require.NoError(t, err)
+	sheet, err := archive.Open("xl/worksheets/sheet1.xml")
+	require.NoError(t, err)
+	content, err := io.ReadAll(sheet)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), ">&#39;@SUM(A1)<")
+
+	// JSON is not opened by spreadsheets and keeps the values as they are
+	w = exportTimeEntries("format=json&columns=task")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"task":"@SUM(A1)"`)
+}
+
+func TestExportTimeEntriesInvalidParameters(t *testing.T) {
+	setupHandlerTestDB(t)
+
+	w := exportTimeEntries("format=pdf")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid format 'pdf'")
+
+	w = exportTimeEntries("columns=task,hours")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid column 'hours'")
+}
+
+func TestExportTimeEntriesICS(t *testing.T) {
+	db := setupHandlerTestDB(t)
+	insertTestEntry(t, db, "Development", "project work", "2025-11-10T09:00:00Z", "2025-11-10T10:30:00Z", 90)
+	insertTestEntry(t, db, "Support", "project support", "2025-12-01T09:00:00Z", "2025-12-01T09:15:00Z", 15)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/export/entries.ics?from=2025-11-01&to=2025-11-30", nil)
+	w := httptest.NewRecorder()
+	ExportTimeEntriesICS(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/calendar; charset=utf-8", w.Header().Get("Content-Type"))
+	body := w.Body.String()
+	assert.Equal(t, 1, strings.Count(body, "BEGIN:VEVENT"))
+	assert.Contains(t, body, "UID:time-entry-1@timesheet\r\n")
+	assert.Contains(t, body, "DTSTART:20251110T090000\r\n")
+	assert.Contains(t, body, "DTEND:20251110T103000\r\n")
+	assert.Contains(t, body, "SUMMARY:Development\r\n")
+	assert.Contains(t, body, "CATEGORIES:project work\r\n")
+	assert.True(t, strings.HasSuffix(body, "END:VCALENDAR\r\n"))
+}
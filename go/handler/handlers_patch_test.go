@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pkgmodel "timesheet/go/model"
+)
+
+func TestPatchTimeEntryKeepsOmittedFields(t *testing.T) {
+	db := setupHandlerTestDB(t)
+	insertTestEntry(t, db, "Development", "project work", "2025-11-10T09:00:00Z", "2025-11-10T10:30:00Z", 90)
+	// An overlap that would be rejected if the times were checked again
+	insertTestEntry(t, db, "Review", "project work", "2025-11-10T10:00:00Z", "2025-11-10T11:00:00Z", 60)
+
+	w := sendJSON(t, PatchTimeEntry, http.MethodPatch, "/api/entries/1", "1", `{"description": "feature x"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var result pkgmodel.TimeEntryResult
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&result))
+	assert.Equal(t, "feature x", result.Description)
+	assert.Equal(t, "Development", result.Task)
+	assert.Equal(t, "project work", result.Category)
+	assert.Equal(t, 90, result.Duration)
+
+	w = sendJSON(t, PatchTimeEntry, http.MethodPatch, "/api/entries/1", "1", `{"end_time": "2025-11-10T10:00:00Z", "description": null}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&result))
+	assert.Equal(t, 60, result.Duration)
+	assert.Empty(t, result.Description)
+
+	var audited int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM audit_log WHERE operation = 'update'").Scan(&audited))
+	assert.Equal(t, 2, audited)
+}
+
+func TestPatchTimeEntryValidatesSuppliedFields(t *testing.T) {
+	db := setupHandlerTestDB(t)
+	insertTestEntry(t, db, "Development", "project work", "2025-11-10T09:00:00Z", "2025-11-10T10:00:00Z", 60)
+	insertTestEntry(t, db, "Review", "project work", "2025-11-10T11:00:00Z", "2025-11-10T12:00:00Z", 60)
+
+	for _, tc := range []struct {
+		patch  string
+		status int
+	}{
+		{`{"category": "meetings"}`, http.StatusBadRequest},
+		{`{"task": null}`, http.StatusBadRequest},
+		{`{"task": 5}`, http.StatusBadRequest},
+		{`{"end_time": "2025-11-10T08:00:00Z"}`, http.StatusBadRequest},
+		{`{"notes": "typo"}`, http.StatusBadRequest},
+		{`[]`, http.StatusBadRequest},
+		{`{"end_time": "2025-11-10T11:30:00Z"}`, http.StatusConflict},
+		{`{"start_time": "2025-11-10T08:30:00Z"}`, http.StatusOK},
+	} {
+		w := sendJSON(t, PatchTimeEntry, http.MethodPatch, "/api/entries/1?overlap=reject", "1", tc.patch)
+		assert.Equal(t, tc.status, w.Code, tc.patch)
+	}
+
+	assert.Equal(t, http.StatusNotFound, sendJSON(t, PatchTimeEntry, http.MethodPatch, "/api/entries/9", "9", `{}`).Code)
+}
+
+func TestPatchCategory(t *testing.T) {
+	setupHandlerTestDB(t)
+
+	w := sendJSON(t, PatchCategory, http.MethodPatch, "/api/categories/1", "1", `{"name": "client work"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var category pkgmodel.Category
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&category))
+	assert.Equal(t, pkgmodel.Category{ID: 1, Name: "client work", Color: "#48bb78"}, category)
+
+	w = sendJSON(t, PatchCategory, http.MethodPatch, "/api/categories/1", "1", `{"color": null}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&category))
+	assert.Equal(t, "#718096", category.Color)
+
+	assert.Equal(t, http.StatusBadRequest, sendJSON(t, PatchCategory, http.MethodPatch, "/api/categories/1", "1", `{"name": ""}`).Code)
+	assert.Equal(t, http.StatusNotFound, sendJSON(t, PatchCategory, http.MethodPatch, "/api/categories/9", "9", `{"color": "#000000"}`).Code)
+}
+
+func TestPatchTask(t *testing.T) {
+	db := setupHandlerTestDB(t)
+	_, err := db.Exec("INSERT INTO tasks (name, category_id, description) VALUES ('Development', 1, 'coding')")
+	require.NoError(t, err)
+
+	w := sendJSON(t, PatchTask, http.MethodPatch, "/api/tasks/1", "1", `{"description": "coding and tests"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var task pkgmodel.Task
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&task))
+	assert.Equal(t, pkgmodel.Task{ID: 1, Name: "Development", CategoryID: 1, Description: "coding and tests"}, task)
+
+	assert.Equal(t, http.StatusBadRequest, sendJSON(t, PatchTask, http.MethodPatch, "/api/tasks/1", "1", `{"category_id": 9}`).Code)
+
+	w = sendJSON(t, PatchTask, http.MethodPatch, "/api/tasks/1", "1", `{"category_id": null}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var categoryID *int
+	require.NoError(t, db.QueryRow("SELECT category_id FROM tasks WHERE id = 1").Scan(&categoryID))
+	assert.Nil(t, categoryID)
+}
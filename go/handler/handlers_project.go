@@ -0,0 +1,349 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	pkgglobal "timesheet/go/global"
+	pkgmodel "timesheet/go/model"
+
+	"github.com/gorilla/mux"
+)
+
+// Project handlers
+
+// GetProjects lists all projects, or those of one client if client_id is given
+func GetProjects(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	query := "SELECT id, client_id, name FROM projects"
+	var args []interface{}
+	if v := r.URL.Query().Get("client_id"); v != "" {
+		clientID, err := strconv.Atoi(v)
+		if err != nil {
+			http.Error(w, "Invalid client_id", http.StatusBadRequest)
+			return
+		}
+		query += " WHERE client_id = ?"
+		args = append(args, clientID)
+	}
+
+	projects, err := queryProjects(pkgglobal.Db, query+" ORDER BY name, id", args...)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(projects)
+}
+
+// queryProjects reads the projects selected by a query of id, client_id and name
+func queryProjects(q Querier, query string, args ...interface{}) ([]pkgmodel.Project, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	projects := []pkgmodel.Project{}
+	for rows.Next() {
+		var project pkgmodel.Project
+		if err := rows.Scan(&project.ID, &project.ClientID, &project.Name); err != nil {
+			return nil, err
+		}
+		projects = append(projects, project)
+	}
+	return projects, rows.Err()
+}
+
+// validateProject checks a project request, writing the error response if it is invalid
+func validateProject(w http.ResponseWriter, q Querier, id int, req pkgmodel.ProjectRequest) bool {
+	if req.Name == "" {
+		http.Error(w, "Project name is required", http.StatusBadRequest)
+		return false
+	}
+
+	exists, err := clientExists(q, req.ClientID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return false
+	}
+	if !exists {
+		http.Error(w, "Invalid client_id. Client does not exist", http.StatusBadRequest)
+		return false
+	}
+
+	var taken bool
+	err = q.QueryRow("SELECT EXISTS(SELECT 1 FROM projects WHERE client_id = ? AND name = ? AND id != ?)",
+		req.ClientID, req.Name, id).Scan(&taken)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return false
+	}
+	if taken {
+		http.Error(w, "The client already has a project with this name", http.StatusConflict)
+		return false
+	}
+	return true
+}
+
+func CreateProject(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req pkgmodel.ProjectRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tx, err := BeginChange()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	if !validateProject(w, tx, 0, req) {
+		return
+	}
+
+	result, err := tx.Exec("INSERT INTO projects (client_id, name) VALUES (?, ?)", req.ClientID, req.Name)
+	if err != nil {
+		log.Printf("ERROR: Failed to insert project - ClientID: %d, Name: %s - Error: %v", req.ClientID, req.Name, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	id, _ := result.LastInsertId()
+	if err := AuditInsert(tx, AuditProject, int(id)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(w, r); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("INSERT: Created project ID %d - ClientID: %d, Name: %s", id, req.ClientID, req.Name)
+
+	json.NewEncoder(w).Encode(pkgmodel.Project{ID: int(id), ClientID: req.ClientID, Name: req.Name})
+}
+
+func UpdateProject(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	var req pkgmodel.ProjectRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tx, err := BeginChange()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	exists, err := projectExists(tx, id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !exists {
+		http.Error(w, "Project not found", http.StatusNotFound)
+		return
+	}
+	if !validateProject(w, tx, id, req) {
+		return
+	}
+
+	err = AuditedWrite(tx, AuditProject, id, AuditUpdate, func() error {
+		_, err := tx.Exec("UPDATE projects SET client_id = ?, name = ? WHERE id = ?", req.ClientID, req.Name, id)
+		return err
+	})
+	if err != nil {
+		log.Printf("ERROR: Failed to update project ID %d - ClientID: %d, Name: %s - Error: %v", id, req.ClientID, req.Name, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(w, r); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("UPDATE: Modified project ID %d - ClientID: %d, Name: %s", id, req.ClientID, req.Name)
+
+	json.NewEncoder(w).Encode(pkgmodel.Project{ID: id, ClientID: req.ClientID, Name: req.Name})
+}
+
+// DeleteProject removes a project. Projects still referenced by tasks or entries, including
+// those in the trash, are kept and reported with their usage and 409 Conflict.
+func DeleteProject(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := BeginChange()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	var name string
+	usage := pkgmodel.ProjectUsage{ProjectID: id}
+	err = tx.QueryRow(`
+		SELECT name,
+			(SELECT COUNT(*) FROM tasks WHERE project_id = projects.id),
+			(SELECT COUNT(*) FROM time_entries WHERE project_id = projects.id)
+		FROM projects WHERE id = ?`, id).Scan(&name, &usage.Tasks, &usage.Entries)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			log.Printf("WARNING: Attempted to delete non-existent project ID %d", id)
+			http.Error(w, "Project not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("ERROR: Failed to fetch project ID %d for deletion - Error: %v", id, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if usage.Tasks > 0 || usage.Entries > 0 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error": "Project is still in use by tasks or time entries",
+			"usage": usage,
+		})
+		return
+	}
+
+	err = AuditedWrite(tx, AuditProject, id, AuditDelete, func() error {
+		_, err := tx.Exec("DELETE FROM projects WHERE id = ?", id)
+		return err
+	})
+	if err != nil {
+		log.Printf("ERROR: Failed to delete project ID %d - Error: %v", id, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(w, r); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("DELETE: Deleted project ID %d - Name: %s", id, name)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetProjectTree lists all clients with their projects and the tasks of each project
+func GetProjectTree(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	rows, err := pkgglobal.Db.Query("SELECT id, name FROM clients ORDER BY name")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	tree := []pkgmodel.ClientNode{}
+	clientIndex := make(map[int]int)
+	for rows.Next() {
+		node := pkgmodel.ClientNode{Projects: []pkgmodel.ProjectNode{}}
+		if err := rows.Scan(&node.ID, &node.Name); err != nil {
+			rows.Close()
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		clientIndex[node.ID] = len(tree)
+		tree = append(tree, node)
+	}
+	rows.Close()
+
+	projects, err := queryProjects(pkgglobal.Db, "SELECT id, client_id, name FROM projects ORDER BY name, id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	type position struct{ client, project int }
+	projectIndex := make(map[int]position)
+	for _, project := range projects {
+		i, ok := clientIndex[project.ClientID]
+		if !ok {
+			continue
+		}
+		projectIndex[project.ID] = position{i, len(tree[i].Projects)}
+		tree[i].Projects = append(tree[i].Projects, pkgmodel.ProjectNode{Project: project, Tasks: []pkgmodel.Task{}})
+	}
+
+	rows, err = pkgglobal.Db.Query(`
+		SELECT id, name, category_id, project_id, description FROM tasks
+		WHERE project_id IS NOT NULL AND deleted_at IS NULL ORDER BY name`)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if p, ok := projectIndex[task.ProjectID]; ok {
+			node := &tree[p.client].Projects[p.project]
+			node.Tasks = append(node.Tasks, task)
+		}
+	}
+
+	json.NewEncoder(w).Encode(tree)
+}
+
+// projectExists reports whether a project with the given ID exists
+func projectExists(q Querier, id int) (bool, error) {
+	var exists bool
+	err := q.QueryRow("SELECT EXISTS(SELECT 1 FROM projects WHERE id = ?)", id).Scan(&exists)
+	return exists, err
+}
+
+// setEntryProject sets the project of an entry. Nil links it to the project of its task
+// and 0 removes the project.
+func setEntryProject(q Querier, entryID int, projectID *int) error {
+	if projectID == nil {
+		_, err := q.Exec(`
+			UPDATE time_entries SET project_id = (
+				SELECT project_id FROM tasks WHERE name = time_entries.task AND deleted_at IS NULL ORDER BY id LIMIT 1
+			) WHERE id = ?`, entryID)
+		return err
+	}
+	var value interface{}
+	if *projectID > 0 {
+		value = *projectID
+	}
+	_, err := q.Exec("UPDATE time_entries SET project_id = ? WHERE id = ?", value, entryID)
+	return err
+}
+
+// validEntryProject reports whether the project of an entry request is unset, 0 or exists
+func validEntryProject(q Querier, projectID *int) (bool, error) {
+	if projectID == nil || *projectID == 0 {
+		return true, nil
+	}
+	return projectExists(q, *projectID)
+}
@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pkgmodel "timesheet/go/model"
+)
+
+// setupProjects creates the client Acme with the project Website and the task Development
+// in it, and returns the project ID
+func setupProjects(t *testing.T) int {
+	w := sendJSON(t, CreateClient, http.MethodPost, "/api/clients", "", pkgmodel.ClientRequest{Name: "Acme"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var client pkgmodel.Client
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&client))
+
+	w = sendJSON(t, CreateProject, http.MethodPost, "/api/projects", "", pkgmodel.ProjectRequest{ClientID: client.ID, Name: "Website"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var project pkgmodel.Project
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&project))
+
+	task := pkgmodel.TaskRequest{Name: "Development", CategoryID: 1, ProjectID: project.ID}
+	w = sendJSON(t, CreateTask, http.MethodPost, "/api/tasks", "", task)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	return project.ID
+}
+
+func TestProjectTree(t *testing.T) {
+	setupHandlerTestDB(t)
+	projectID := setupProjects(t)
+	w := sendJSON(t, CreateClient, http.MethodPost, "/api/clients", "", pkgmodel.ClientRequest{Name: "Beta"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = httptest.NewRecorder()
+	GetProjectTree(w, httptest.NewRequest(http.MethodGet, "/api/projects/tree", nil))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var tree []pkgmodel.ClientNode
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&tree))
+
+	require.Len(t, tree, 2)
+	assert.Equal(t, "Acme", tree[0].Name)
+	require.Len(t, tree[0].Projects, 1)
+	assert.Equal(t, projectID, tree[0].Projects[0].ID)
+	require.Len(t, tree[0].Projects[0].Tasks, 1)
+	assert.Equal(t, "Development", tree[0].Projects[0].Tasks[0].Name)
+	assert.Equal(t, "Beta", tree[1].Name)
+	assert.Empty(t, tree[1].Projects)
+}
+
+func TestProjectValidationAndDeletion(t *testing.T) {
+	db := setupHandlerTestDB(t)
+	projectID := setupProjects(t)
+
+	assert.Equal(t, http.StatusConflict,
+		sendJSON(t, CreateClient, http.MethodPost, "/api/clients", "", pkgmodel.ClientRequest{Name: "Acme"}).Code)
+	assert.Equal(t, http.StatusConflict,
+		sendJSON(t, CreateProject, http.MethodPost, "/api/projects", "", pkgmodel.ProjectRequest{ClientID: 1, Name: "Website"}).Code)
+	assert.Equal(t, http.StatusBadRequest,
+		sendJSON(t, CreateProject, http.MethodPost, "/api/projects", "", pkgmodel.ProjectRequest{ClientID: 9, Name: "Shop"}).Code)
+	assert.Equal(t, http.StatusBadRequest,
+		sendJSON(t, CreateTask, http.MethodPost, "/api/tasks", "", pkgmodel.TaskRequest{Name: "Review", ProjectID: 9}).Code)
+
+	// Projects in use and clients with projects are kept
+	w := sendJSON(t, DeleteProject, http.MethodDelete, "/api/projects/1", "1", nil)
+	require.Equal(t, http.StatusConflict, w.Code)
+	var conflict struct {
+		Usage pkgmodel.ProjectUsage `json:"usage"`
+	}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&conflict))
+	assert.Equal(t, pkgmodel.ProjectUsage{ProjectID: projectID, Tasks: 1}, conflict.Usage)
+	assert.Equal(t, http.StatusConflict, sendJSON(t, DeleteClient, http.MethodDelete, "/api/clients/1", "1", nil).Code)
+
+	w = sendJSON(t, PatchTask, http.MethodPatch, "/api/tasks/1", "1", `{"project_id": null}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, http.StatusNoContent, sendJSON(t, DeleteProject, http.MethodDelete, "/api/projects/1", "1", nil).Code)
+	assert.Equal(t, http.StatusNoContent, sendJSON(t, DeleteClient, http.MethodDelete, "/api/clients/1", "1", nil).Code)
+
+	var audited int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM audit_log WHERE entity_type IN ('client', 'project')").Scan(&audited))
+	assert.Equal(t, 4, audited)
+}
+
+func TestTimeEntryProjects(t *testing.T) {
+	setupHandlerTestDB(t)
+	projectID := setupProjects(t)
+
+	// New entries get the project of their task unless one is given
+	entry := pkgmodel.TimeEntryRequest{Task: "Development", Category: "project work",
+		StartTime: "2025-11-10T09:00:00Z", EndTime: "2025-11-10T10:00:00Z"}
+	require.Equal(t, http.StatusOK, sendJSON(t, CreateTimeEntry, http.MethodPost, "/api/entries", "", entry).Code)
+	other := pkgmodel.TimeEntryRequest{Task: "Meeting", Category: "project support",
+		StartTime: "2025-11-10T10:00:00Z", EndTime: "2025-11-10T10:30:00Z", ProjectID: &projectID}
+	require.Equal(t, http.StatusOK, sendJSON(t, CreateTimeEntry, http.MethodPost, "/api/entries", "", other).Code)
+
+	entries := listEntries(t, "?sort=start_time").Entries
+	require.Len(t, entries, 2)
+	for _, e := range entries {
+		require.NotNil(t, e.ProjectID, e.Task)
+		assert.Equal(t, projectID, *e.ProjectID)
+	}
+
+	// Updates without project_id keep the project, a null patch removes it
+	entry.EndTime = "2025-11-10T09:45:00Z"
+	w := sendJSON(t, UpdateTimeEntry, http.MethodPut, "/api/entries/1", "1", entry)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var result pkgmodel.TimeEntryResult
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&result))
+	assert.Equal(t, &projectID, result.ProjectID)
+
+	w = sendJSON(t, PatchTimeEntry, http.MethodPatch, "/api/entries/2", "2", `{"project_id": null}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&result))
+	assert.Nil(t, result.ProjectID)
+
+	assert.Equal(t, http.StatusBadRequest, sendJSON(t, PatchTimeEntry, http.MethodPatch, "/api/entries/2", "2", `{"project_id": 9}`).Code)
+}
+
+func TestUndoProjectDeletion(t *testing.T) {
+	db := setupUndoTest(t)
+	w := sendJSON(t, CreateClient, http.MethodPost, "/api/clients", "", pkgmodel.ClientRequest{Name: "Acme"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = sendJSON(t, CreateProject, http.MethodPost, "/api/projects", "", pkgmodel.ProjectRequest{ClientID: 1, Name: "Website"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Equal(t, http.StatusNoContent, sendJSON(t, DeleteProject, http.MethodDelete, "/api/projects/1", "1", nil).Code)
+
+	require.Equal(t, http.StatusOK, callUndo(Undo, testSession).Code)
+	var name string
+	require.NoError(t, db.QueryRow("SELECT name FROM projects WHERE id = 1 AND client_id = 1").Scan(&name))
+	assert.Equal(t, "Website", name)
+}
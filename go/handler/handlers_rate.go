@@ -0,0 +1,290 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	pkgglobal "timesheet/go/global"
+	pkgmodel "timesheet/go/model"
+
+	"github.com/gorilla/mux"
+)
+
+// Rate scopes, from the most to the least specific. The billing report applies the rate of
+// the first scope that has one on the day of an entry.
+const (
+	RateTask     = "task"
+	RateProject  = "project"
+	RateClient   = "client"
+	RateCategory = "category"
+)
+
+// rateScopes lists the scopes in order of precedence
+var rateScopes = []string{RateTask, RateProject, RateClient, RateCategory}
+
+// rateScopeTargets holds the query checking that the target of a rate exists
+var rateScopeTargets = map[string]string{
+	RateTask:     "SELECT EXISTS(SELECT 1 FROM tasks WHERE id = ? AND deleted_at IS NULL)",
+	RateProject:  "SELECT EXISTS(SELECT 1 FROM projects WHERE id = ?)",
+	RateClient:   "SELECT EXISTS(SELECT 1 FROM clients WHERE id = ?)",
+	RateCategory: "SELECT EXISTS(SELECT 1 FROM categories WHERE id = ? AND deleted_at IS NULL)",
+}
+
+// currencyPattern matches ISO 4217 currency codes
+var currencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+const rateColumns = "id, scope, scope_id, hourly_rate, currency, effective_from"
+
+// queryRates reads the rates matching the condition, latest effective date first
+func queryRates(q Querier, where string, args ...interface{}) ([]pkgmodel.Rate, error) {
+	rows, err := q.Query("SELECT "+rateColumns+" FROM rates"+where+" ORDER BY scope, scope_id, effective_from DESC", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rates := []pkgmodel.Rate{}
+	for rows.Next() {
+		var rate pkgmodel.Rate
+		err := rows.Scan(&rate.ID, &rate.Scope, &rate.ScopeID, &rate.HourlyRate, &rate.Currency, &rate.EffectiveFrom)
+		if err != nil {
+			return nil, err
+		}
+		rates = append(rates, rate)
+	}
+	return rates, rows.Err()
+}
+
+// Rate handlers
+
+// GetRates lists all rates, optionally only those of one scope and scope_id
+func GetRates(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	query := r.URL.Query()
+	var conditions []string
+	var args []interface{}
+	if scope := query.Get("scope"); scope != "" {
+		if _, ok := rateScopeTargets[scope]; !ok {
+			http.Error(w, "Invalid scope. Expected task, project, client or category", http.StatusBadRequest)
+			return
+		}
+		conditions = append(conditions, "scope = ?")
+		args = append(args, scope)
+	}
+	if v := query.Get("scope_id"); v != "" {
+		scopeID, err := strconv.Atoi(v)
+		if err != nil {
+			http.Error(w, "Invalid scope_id", http.StatusBadRequest)
+			return
+		}
+		conditions = append(conditions, "scope_id = ?")
+		args = append(args, scopeID)
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+	rates, err := queryRates(pkgglobal.Db, where, args...)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(rates)
+}
+
+// validateRate checks a rate request and returns the rate to store. The currency is
+// upper-cased and the hourly rate kept as sent.
+func validateRate(q Querier, req pkgmodel.RateRequest) (pkgmodel.Rate, error) {
+	rate := pkgmodel.Rate{
+		Scope:         req.Scope,
+		ScopeID:       req.ScopeID,
+		HourlyRate:    req.HourlyRate.String(),
+		Currency:      strings.ToUpper(strings.TrimSpace(req.Currency)),
+		EffectiveFrom: req.EffectiveFrom,
+	}
+
+	target, ok := rateScopeTargets[rate.Scope]
+	if !ok {
+		return rate, fmt.Errorf("invalid scope '%s'. Expected task, project, client or category", rate.Scope)
+	}
+	if _, err := ParseDecimal(rate.HourlyRate); err != nil {
+		return rate, fmt.Errorf("invalid hourly_rate: %w", err)
+	}
+	if !currencyPattern.MatchString(rate.Currency) {
+		return rate, fmt.Errorf("invalid currency '%s'. Expected a three-letter ISO 4217 code", req.Currency)
+	}
+	if _, err := time.Parse("2006-01-02", rate.EffectiveFrom); err != nil {
+		return rate, fmt.Errorf("invalid effective_from '%s'. Expected YYYY-MM-DD", rate.EffectiveFrom)
+	}
+
+	var exists bool
+	if err := q.QueryRow(target, rate.ScopeID).Scan(&exists); err != nil {
+		return rate, err
+	}
+	if !exists {
+		return rate, fmt.Errorf("invalid scope_id. The %s does not exist", rate.Scope)
+	}
+	return rate, nil
+}
+
+// rateDateTaken reports whether another rate than id of the same scope starts on the same day
+func rateDateTaken(q Querier, rate pkgmodel.Rate, id int) (bool, error) {
+	var taken bool
+	err := q.QueryRow("SELECT EXISTS(SELECT 1 FROM rates WHERE scope = ? AND scope_id = ? AND effective_from = ? AND id != ?)",
+		rate.Scope, rate.ScopeID, rate.EffectiveFrom, id).Scan(&taken)
+	return taken, err
+}
+
+// writeRate validates a rate and inserts it, or updates the rate with the given ID
+func writeRate(w http.ResponseWriter, r *http.Request, id int) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req pkgmodel.RateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tx, err := BeginChange()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	if id > 0 {
+		var exists bool
+		if err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM rates WHERE id = ?)", id).Scan(&exists); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !exists {
+			http.Error(w, "Rate not found", http.StatusNotFound)
+			return
+		}
+	}
+
+	rate, err := validateRate(tx, req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	taken, err := rateDateTaken(tx, rate, id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if taken {
+		http.Error(w, "A rate of this scope already starts on effective_from", http.StatusConflict)
+		return
+	}
+
+	action := "UPDATE: Modified"
+	if id == 0 {
+		action = "INSERT: Created"
+		result, err := tx.Exec("INSERT INTO rates (scope, scope_id, hourly_rate, currency, effective_from) VALUES (?, ?, ?, ?, ?)",
+			rate.Scope, rate.ScopeID, rate.HourlyRate, rate.Currency, rate.EffectiveFrom)
+		if err == nil {
+			newID, _ := result.LastInsertId()
+			id = int(newID)
+			err = AuditInsert(tx, AuditRate, id)
+		}
+		if err != nil {
+			log.Printf("ERROR: Failed to insert rate - Scope: %s %d, Rate: %s %s - Error: %v",
+				rate.Scope, rate.ScopeID, rate.HourlyRate, rate.Currency, err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	} else {
+		err := AuditedWrite(tx, AuditRate, id, AuditUpdate, func() error {
+			_, err := tx.Exec("UPDATE rates SET scope = ?, scope_id = ?, hourly_rate = ?, currency = ?, effective_from = ? WHERE id = ?",
+				rate.Scope, rate.ScopeID, rate.HourlyRate, rate.Currency, rate.EffectiveFrom, id)
+			return err
+		})
+		if err != nil {
+			log.Printf("ERROR: Failed to update rate ID %d - Error: %v", id, err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := tx.Commit(w, r); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("%s rate ID %d - Scope: %s %d, Rate: %s %s from %s",
+		action, id, rate.Scope, rate.ScopeID, rate.HourlyRate, rate.Currency, rate.EffectiveFrom)
+	rate.ID = id
+
+	json.NewEncoder(w).Encode(rate)
+}
+
+func CreateRate(w http.ResponseWriter, r *http.Request) {
+	writeRate(w, r, 0)
+}
+
+func UpdateRate(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || id <= 0 {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+	writeRate(w, r, id)
+}
+
+func DeleteRate(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := BeginChange()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	var scope string
+	err = tx.QueryRow("SELECT scope FROM rates WHERE id = ?", id).Scan(&scope)
+	if err == sql.ErrNoRows {
+		log.Printf("WARNING: Attempted to delete non-existent rate ID %d", id)
+		http.Error(w, "Rate not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = AuditedWrite(tx, AuditRate, id, AuditDelete, func() error {
+		_, err := tx.Exec("DELETE FROM rates WHERE id = ?", id)
+		return err
+	})
+	if err != nil {
+		log.Printf("ERROR: Failed to delete rate ID %d - Error: %v", id, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(w, r); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("DELETE: Deleted %s rate ID %d", scope, id)
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,271 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+
+	pkgglobal "timesheet/go/global"
+	pkgmodel "timesheet/go/model"
+)
+
+// summaryGrouping holds the SQL expressions for the key and first day of a summary bucket
+type summaryGrouping struct {
+	key   string
+	start string
+}
+
+// summaryGroupings maps the accepted group_by values onto their SQL expressions.
+// Weeks follow ISO 8601 and start on Monday.
+var summaryGroupings = map[string]summaryGrouping{
+	"day":      {"date(e.start_time)", "date(e.start_time)"},
+	"week":     {"strftime('%G-W%V', e.start_time)", "date(e.start_time, '-6 days', 'weekday 1')"},
+	"month":    {"strftime('%Y-%m', e.start_time)", "date(e.start_time, 'start of month')"},
+	"category": {"e.category", "''"},
+	"task":     {"e.task", "''"},
+}
+
+// summaryRow is one (bucket, category) group as returned by the database
+type summaryRow struct {
+	key, start string
+	category   pkgmodel.CategoryTotal
+}
+
+// buildSummaryBuckets folds the per-category rows, ordered by key, into buckets
+func buildSummaryBuckets(groupBy string, rows []summaryRow) []pkgmodel.SummaryBucket {
+	buckets := []pkgmodel.SummaryBucket{}
+	for _, row := range rows {
+		if len(buckets) == 0 || buckets[len(buckets)-1].Key != row.key {
+			buckets = append(buckets, pkgmodel.SummaryBucket{Key: row.key, Start: row.start})
+		}
+		bucket := &buckets[len(buckets)-1]
+		bucket.TotalMinutes += row.category.TotalMinutes
+		bucket.EntryCount += row.category.EntryCount
+
+		if groupBy == "category" {
+			// The bucket is the category itself
+			bucket.Color = row.category.Color
+		} else {
+			bucket.Categories = append(bucket.Categories, row.category)
+		}
+	}
+	return buckets
+}
+
+// Report handlers
+func GetSummaryReport(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	query := r.URL.Query()
+	groupBy := query.Get("group_by")
+	if groupBy == "" {
+		groupBy = "day"
+	}
+	grouping, ok := summaryGroupings[groupBy]
+	if !ok {
+		http.Error(w, fmt.Sprintf("invalid group_by '%s'. Expected day, week, month, category or task", groupBy), http.StatusBadRequest)
+		return
+	}
+
+	from, to, err := ParseDateRange(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tags, err := NormalizeTags(query["tag"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Running timers have no duration yet and are left out
+	where, args := TimeEntryFilter{From: from, To: to, Tags: tags}.WhereClause(false)
+	where += " AND e.end_time IS NOT NULL"
+
+	exact, err := ParseRoundingParam(query.Get("rounding"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	book, err := newRoundingBook(pkgglobal.Db, exact)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	report := pkgmodel.SummaryReport{
+		From:    query.Get("from"),
+		To:      query.Get("to"),
+		GroupBy: groupBy,
+	}
+	var summaryRows []summaryRow
+	var minutes map[int]int
+	if book.keepsExactMinutes() {
+		summaryRows, err = querySummaryRows(pkgglobal.Db, grouping, where, args)
+		report.Rounding = book.rules
+	} else {
+		rounder := book.newRounder()
+		summaryRows, minutes, err = queryRoundedSummaryRows(pkgglobal.Db, grouping, where, args, rounder)
+		report.Rounding = rounder.appliedRules()
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	report.Buckets = buildSummaryBuckets(groupBy, summaryRows)
+	if report.Tags, err = queryTagTotals(pkgglobal.Db, where, args, minutes); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	for _, bucket := range report.Buckets {
+		report.TotalMinutes += bucket.TotalMinutes
+		report.EntryCount += bucket.EntryCount
+	}
+
+	json.NewEncoder(w).Encode(report)
+}
+
+// querySummaryRows sums up the entries matching the condition per bucket and category in
+// the database, ordered by key and category
+func querySummaryRows(q Querier, grouping summaryGrouping, where string, args []interface{}) ([]summaryRow, error) {
+	rows, err := q.Query(fmt.Sprintf(`
+		SELECT %[1]s, MIN(%[2]s), e.category,
+			COALESCE((SELECT c.color FROM categories c WHERE c.name = e.category), '#718096'), SUM(e.duration), COUNT(*)
+		FROM time_entries e`+where+`
+		GROUP BY %[1]s, e.category
+		ORDER BY %[1]s, e.category
+	`, grouping.key, grouping.start), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var summaryRows []summaryRow
+	for rows.Next() {
+		var row summaryRow
+		err := rows.Scan(&row.key, &row.start, &row.category.Name, &row.category.Color,
+			&row.category.TotalMinutes, &row.category.EntryCount)
+		if err != nil {
+			return nil, err
+		}
+		summaryRows = append(summaryRows, row)
+	}
+	return summaryRows, rows.Err()
+}
+
+// queryRoundedSummaryRows is querySummaryRows with rounded durations. Entries are read one by
+// one in chronological order, as rounding per day depends on the entries before; the rounded
+// minutes are also returned per entry ID.
+func queryRoundedSummaryRows(q Querier, grouping summaryGrouping, where string, args []interface{}, rounder *durationRounder) ([]summaryRow, map[int]int, error) {
+	rows, err := q.Query(fmt.Sprintf(`
+		SELECT e.id, %[1]s, %[2]s, e.category,
+			COALESCE((SELECT c.color FROM categories c WHERE c.name = e.category), '#718096'),
+			date(e.start_time), e.duration, COALESCE(e.project_id, 0)
+		FROM time_entries e`+where+`
+		ORDER BY e.start_time, e.id
+	`, grouping.key, grouping.start), args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	minutes := map[int]int{}
+	groups := map[[2]string]int{}
+	var summaryRows []summaryRow
+	for rows.Next() {
+		var id, duration, projectID int
+		var key, start, category, color, day string
+		if err := rows.Scan(&id, &key, &start, &category, &color, &day, &duration, &projectID); err != nil {
+			return nil, nil, err
+		}
+		minutes[id] = rounder.round(day, projectID, duration)
+
+		i, ok := groups[[2]string{key, category}]
+		if !ok {
+			i = len(summaryRows)
+			groups[[2]string{key, category}] = i
+			summaryRows = append(summaryRows, summaryRow{key: key, start: start, category: pkgmodel.CategoryTotal{Name: category, Color: color}})
+		}
+		row := &summaryRows[i]
+		if start < row.start {
+			row.start = start
+		}
+		row.category.TotalMinutes += minutes[id]
+		row.category.EntryCount++
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	sort.Slice(summaryRows, func(i, j int) bool {
+		a, b := summaryRows[i], summaryRows[j]
+		if a.key != b.key {
+			return a.key < b.key
+		}
+		return a.category.Name < b.category.Name
+	})
+	return summaryRows, minutes, nil
+}
+
+// queryTagTotals sums up the entries matching the condition per tag. minutes holds the rounded
+// minutes per entry; without it the stored durations are summed in the database.
+func queryTagTotals(q Querier, where string, args []interface{}, minutes map[int]int) ([]pkgmodel.TagTotal, error) {
+	if minutes == nil {
+		return querySummedTagTotals(q, where, args)
+	}
+
+	rows, err := q.Query(`
+		SELECT t.name, et.entry_id
+		FROM time_entry_tags et
+		JOIN tags t ON t.id = et.tag_id
+		WHERE et.entry_id IN (SELECT id FROM time_entries e`+where+`)
+		ORDER BY t.name`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	totals := []pkgmodel.TagTotal{}
+	for rows.Next() {
+		var name string
+		var entryID int
+		if err := rows.Scan(&name, &entryID); err != nil {
+			return nil, err
+		}
+		if len(totals) == 0 || totals[len(totals)-1].Name != name {
+			totals = append(totals, pkgmodel.TagTotal{Name: name})
+		}
+		totals[len(totals)-1].TotalMinutes += minutes[entryID]
+		totals[len(totals)-1].EntryCount++
+	}
+	return totals, rows.Err()
+}
+
+// querySummedTagTotals sums up the stored durations of the entries matching the condition per tag
+func querySummedTagTotals(q Querier, where string, args []interface{}) ([]pkgmodel.TagTotal, error) {
+	rows, err := q.Query(`
+		SELECT t.name, SUM(e.duration), COUNT(*)
+		FROM time_entry_tags et
+		JOIN tags t ON t.id = et.tag_id
+		JOIN time_entries e ON e.id = et.entry_id
+		WHERE et.entry_id IN (SELECT id FROM time_entries e`+where+`)
+		GROUP BY t.name
+		ORDER BY t.name`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	totals := []pkgmodel.TagTotal{}
+	for rows.Next() {
+		var total pkgmodel.TagTotal
+		if err := rows.Scan(&total.Name, &total.TotalMinutes, &total.EntryCount); err != nil {
+			return nil, err
+		}
+		totals = append(totals, total)
+	}
+	return totals, rows.Err()
+}
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pkgmodel "timesheet/go/model"
+)
+
+func getSummaryReport(t *testing.T, query string) (*httptest.ResponseRecorder, pkgmodel.SummaryReport) {
+	req := httptest.NewRequest(http.MethodGet, "/api/reports/summary?"+query, nil)
+	w := httptest.NewRecorder()
+	GetSummaryReport(w, req)
+
+	var report pkgmodel.SummaryReport
+	if w.Code == http.StatusOK {
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&report))
+	}
+	return w, report
+}
+
+func TestGetSummaryReportByWeek(t *testing.T) {
+	db := setupHandlerTestDB(t)
+	// 2025-12-29 to 2026-01-04 is ISO week 2026-W01
+	insertTestEntry(t, db, "Development", "project work", "2025-12-28T09:00:00Z", "2025-12-28T10:00:00Z", 60)
+	insertTestEntry(t, db, "Development", "project work", "2025-12-29T09:00:00Z", "2025-12-29T10:30:00Z", 90)
+	insertTestEntry(t, db, "Support", "project support", "2026-01-02T09:00:00Z", "2026-01-02T09:30:00Z", 30)
+
+	w, report := getSummaryReport(t, "group_by=week")
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 180, report.TotalMinutes)
+	assert.Equal(t, 3, report.EntryCount)
+	require.Len(t, report.Buckets, 2)
+	assert.Equal(t, "2025-W52", report.Buckets[0].Key)
+	assert.Equal(t, "2025-12-22", report.Buckets[0].Start)
+	assert.Equal(t, "2026-W01", report.Buckets[1].Key)
+	assert.Equal(t, "2025-12-29", report.Buckets[1].Start)
+	assert.Equal(t, 120, report.Buckets[1].TotalMinutes)
+	require.Len(t, report.Buckets[1].Categories, 2)
+	assert.Equal(t, pkgmodel.CategoryTotal{Name: "project support", Color: "#ed8936", TotalMinutes: 30, EntryCount: 1},
+		report.Buckets[1].Categories[0])
+}
+
+func TestGetSummaryReportByCategoryInRange(t *testing.T) {
+	db := setupHandlerTestDB(t)
+	insertTestEntry(t, db, "Development", "project work", "2025-11-09T09:00:00Z", "2025-11-09T10:00:00Z", 60)
+	insertTestEntry(t, db, "Development", "project work", "2025-11-10T09:00:00Z", "2025-11-10T10:00:00Z", 60)
+	insertTestEntry(t, db, "Support", "project support", "2025-11-12T09:00:00Z", "2025-11-12T09:30:00Z", 30)
+
+	w, report := getSummaryReport(t, "group_by=category&from=2025-11-10&to=2025-11-12")
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Len(t, report.Buckets, 2)
+	assert.Equal(t, pkgmodel.SummaryBucket{Key: "project support", Color: "#ed8936", TotalMinutes: 30, EntryCount: 1}, report.Buckets[0])
+	assert.Equal(t, pkgmodel.SummaryBucket{Key: "project work", Color: "#48bb78", TotalMinutes: 60, EntryCount: 1}, report.Buckets[1])
+}
+
+func TestGetSummaryReportInvalidGroupBy(t *testing.T) {
+	setupHandlerTestDB(t)
+
+	w, _ := getSummaryReport(t, "group_by=year")
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid group_by 'year'")
+}
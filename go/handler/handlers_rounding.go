@@ -0,0 +1,193 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	pkgglobal "timesheet/go/global"
+	pkgmodel "timesheet/go/model"
+
+	"github.com/gorilla/mux"
+)
+
+// writeRoundingRule stores the rule of a client, or the default rule for client ID 0. The
+// rule is audited under the client ID.
+func writeRoundingRule(q Querier, rule pkgmodel.RoundingRule) error {
+	var exists bool
+	err := q.QueryRow("SELECT EXISTS(SELECT 1 FROM rounding_rules WHERE client_id = ?)", rule.ClientID).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		_, err := q.Exec("INSERT INTO rounding_rules (client_id, mode, increment, per) VALUES (?, ?, ?, ?)",
+			rule.ClientID, rule.Mode, rule.Increment, rule.Per)
+		if err != nil {
+			return err
+		}
+		return AuditInsert(q, AuditRounding, rule.ClientID)
+	}
+	return AuditedWrite(q, AuditRounding, rule.ClientID, AuditUpdate, func() error {
+		_, err := q.Exec("UPDATE rounding_rules SET mode = ?, increment = ?, per = ? WHERE client_id = ?",
+			rule.Mode, rule.Increment, rule.Per, rule.ClientID)
+		return err
+	})
+}
+
+// decodeRoundingRule reads and validates the rule of a request
+func decodeRoundingRule(w http.ResponseWriter, r *http.Request) (pkgmodel.RoundingRule, bool) {
+	var rule pkgmodel.RoundingRule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return rule, false
+	}
+	if err := ValidateRoundingRule(&rule); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return rule, false
+	}
+	return rule, true
+}
+
+// Rounding handlers
+func GetRoundingRules(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	rules, err := queryRoundingRules(pkgglobal.Db)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(rules)
+}
+
+// UpdateDefaultRounding replaces the rule of entries whose client has no rule of its own
+func UpdateDefaultRounding(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	rule, ok := decodeRoundingRule(w, r)
+	if !ok {
+		return
+	}
+	rule.ClientID, rule.Client = 0, ""
+
+	tx, err := BeginChange()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	if err := writeRoundingRule(tx, rule); err != nil {
+		log.Printf("ERROR: Failed to update default rounding rule - Error: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(w, r); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("UPDATE: Modified default rounding rule - %s", describeRoundingRule(rule))
+
+	json.NewEncoder(w).Encode(rule)
+}
+
+// UpdateClientRounding sets the rule of the entries of a client's projects
+func UpdateClientRounding(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil || id <= 0 {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	rule, ok := decodeRoundingRule(w, r)
+	if !ok {
+		return
+	}
+	rule.ClientID = id
+
+	tx, err := BeginChange()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow("SELECT name FROM clients WHERE id = ?", id).Scan(&rule.Client)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Client not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := writeRoundingRule(tx, rule); err != nil {
+		log.Printf("ERROR: Failed to update rounding rule of client ID %d - Error: %v", id, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(w, r); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("UPDATE: Modified rounding rule of client ID %d - %s", id, describeRoundingRule(rule))
+
+	json.NewEncoder(w).Encode(rule)
+}
+
+// DeleteClientRounding removes the rule of a client, which then falls back to the default rule
+func DeleteClientRounding(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil || id <= 0 {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := BeginChange()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM rounding_rules WHERE client_id = ?)", id).Scan(&exists); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !exists {
+		http.Error(w, "Client has no rounding rule", http.StatusNotFound)
+		return
+	}
+
+	err = AuditedWrite(tx, AuditRounding, id, AuditDelete, func() error {
+		_, err := tx.Exec("DELETE FROM rounding_rules WHERE client_id = ?", id)
+		return err
+	})
+	if err != nil {
+		log.Printf("ERROR: Failed to delete rounding rule of client ID %d - Error: %v", id, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(w, r); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("DELETE: Deleted rounding rule of client ID %d", id)
+
+	w.WriteHeader(http.StatusNoContent)
+}
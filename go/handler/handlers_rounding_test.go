@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pkgmodel "timesheet/go/model"
+)
+
+func TestRoundingRules(t *testing.T) {
+	setupHandlerTestDB(t)
+	setupProjects(t)
+
+	w := sendJSON(t, UpdateDefaultRounding, http.MethodPut, "/api/rounding", "", pkgmodel.RoundingRule{Mode: RoundNearest, Increment: 15})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = sendJSON(t, UpdateClientRounding, http.MethodPut, "/api/clients/1/rounding", "1", pkgmodel.RoundingRule{Mode: RoundUp, Increment: 30, Per: RoundPerDay})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = sendJSON(t, GetRoundingRules, http.MethodGet, "/api/rounding", "", nil)
+	var rules []pkgmodel.RoundingRule
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&rules))
+	assert.Equal(t, []pkgmodel.RoundingRule{
+		{Mode: RoundNearest, Increment: 15, Per: RoundPerEntry},
+		{ClientID: 1, Client: "Acme", Mode: RoundUp, Increment: 30, Per: RoundPerDay},
+	}, rules)
+
+	assert.Equal(t, http.StatusBadRequest, sendJSON(t, UpdateDefaultRounding, http.MethodPut, "/api/rounding", "", pkgmodel.RoundingRule{Mode: RoundUp}).Code)
+	assert.Equal(t, http.StatusNotFound, sendJSON(t, UpdateClientRounding, http.MethodPut, "/api/clients/9/rounding", "9", pkgmodel.RoundingRule{Mode: RoundNone}).Code)
+	assert.Equal(t, http.StatusNotFound, sendJSON(t, DeleteClientRounding, http.MethodDelete, "/api/clients/9/rounding", "9", nil).Code)
+}
+
+func TestRoundingInReportsAndExports(t *testing.T) {
+	setupHandlerTestDB(t)
+	setupProjects(t)
+	require.Equal(t, http.StatusOK, sendJSON(t, UpdateDefaultRounding, http.MethodPut, "/api/rounding", "", pkgmodel.RoundingRule{Mode: RoundNearest, Increment: 15}).Code)
+	require.Equal(t, http.StatusOK, sendJSON(t, UpdateClientRounding, http.MethodPut, "/api/clients/1/rounding", "1", pkgmodel.RoundingRule{Mode: RoundUp, Increment: 30, Per: RoundPerDay}).Code)
+	require.Equal(t, http.StatusOK, sendJSON(t, CreateRate, http.MethodPost, "/api/rates", "", `{"scope": "client", "scope_id": 1, "hourly_rate": "100", "currency": "EUR", "effective_from": "2025-01-01"}`).Code)
+
+	// Development belongs to Acme: 20 + 10 minutes are rounded up to 30 for the day.
+	// Meeting has no project: 8 minutes are rounded to the nearest 15.
+	for _, entry := range []pkgmodel.TimeEntryRequest{
+		{Task: "Development", Category: "project work", StartTime: "2025-11-10T09:00:00Z", EndTime: "2025-11-10T09:20:00Z"},
+		{Task: "Meeting", Category: "project support", StartTime: "2025-11-10T10:00:00Z", EndTime: "2025-11-10T10:08:00Z", Tags: []string{"customer-x"}},
+		{Task: "Development", Category: "project work", StartTime: "2025-11-10T11:00:00Z", EndTime: "2025-11-10T11:10:00Z"},
+	} {
+		require.Equal(t, http.StatusOK, sendJSON(t, CreateTimeEntry, http.MethodPost, "/api/entries", "", entry).Code)
+	}
+
+	w, report := getSummaryReport(t, "group_by=category")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, 45, report.TotalMinutes)
+	require.Len(t, report.Buckets, 2)
+	assert.Equal(t, 15, report.Buckets[0].TotalMinutes)
+	assert.Equal(t, 30, report.Buckets[1].TotalMinutes)
+	require.Len(t, report.Rounding, 2)
+	assert.Equal(t, "Acme", report.Rounding[1].Client)
+	assert.Equal(t, []pkgmodel.TagTotal{{Name: "customer-x", TotalMinutes: 15, EntryCount: 1}}, report.Tags)
+
+	// Internal reporting in exact minutes
+	w, report = getSummaryReport(t, "group_by=category&rounding=none")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, 38, report.TotalMinutes)
+	assert.Equal(t, []pkgmodel.RoundingRule{{Mode: RoundNone}}, report.Rounding)
+	assert.Equal(t, []pkgmodel.TagTotal{{Name: "customer-x", TotalMinutes: 8, EntryCount: 1}}, report.Tags)
+
+	w, _ = getSummaryReport(t, "rounding=exact")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w, billing := getBillingReport(t, "client=Acme")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Len(t, billing.Items, 1)
+	assert.Equal(t, 30, billing.Items[0].Minutes)
+	assert.Equal(t, "50.00", billing.Items[0].Amount)
+
+	w = exportTimeEntries("format=csv&columns=task,duration_minutes,start_time,end_time")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "nearest to 15 min per entry; Acme: up to 30 min per day", w.Header().Get("X-Rounding"))
+	assert.Equal(t, "Task,Duration (Minutes),Start Time,End Time\n"+
+		"Development,30,2025-11-10 09:00,2025-11-10 09:20\n"+
+		"Meeting,15,2025-11-10 10:00,2025-11-10 10:08\n"+
+		"Development,0,2025-11-10 11:00,2025-11-10 11:10\n", w.Body.String())
+
+	// Without its own rule Acme falls back to the default rule
+	require.Equal(t, http.StatusNoContent, sendJSON(t, DeleteClientRounding, http.MethodDelete, "/api/clients/1/rounding", "1", nil).Code)
+	_, report = getSummaryReport(t, "group_by=category")
+	assert.Equal(t, 15+15+15, report.TotalMinutes)
+}
+
+func TestRoundingRuleChangesAreAuditedAndUndoable(t *testing.T) {
+	setupUndoTest(t)
+	setupProjects(t)
+	require.Equal(t, http.StatusOK, sendJSON(t, UpdateDefaultRounding, http.MethodPut, "/api/rounding", "", pkgmodel.RoundingRule{Mode: RoundNearest, Increment: 15}).Code)
+	require.Equal(t, http.StatusOK, sendJSON(t, UpdateClientRounding, http.MethodPut, "/api/clients/1/rounding", "1", pkgmodel.RoundingRule{Mode: RoundUp, Increment: 30}).Code)
+	require.Equal(t, http.StatusNoContent, sendJSON(t, DeleteClientRounding, http.MethodDelete, "/api/clients/1/rounding", "1", nil).Code)
+
+	w := sendJSON(t, GetAuditLog, http.MethodGet, "/api/audit?entity_type=rounding", "", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var records []pkgmodel.AuditRecord
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&records))
+	var changes []string
+	for _, record := range records {
+		changes = append(changes, fmt.Sprintf("%s %d", record.Operation, record.EntityID))
+	}
+	assert.Equal(t, []string{"update 0", "create 1", "delete 1"}, changes)
+
+	rules := func() []pkgmodel.RoundingRule {
+		w := sendJSON(t, GetRoundingRules, http.MethodGet, "/api/rounding", "", nil)
+		var rules []pkgmodel.RoundingRule
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&rules))
+		return rules
+	}
+
+	require.Equal(t, http.StatusOK, callUndo(Undo, testSession).Code)
+	assert.Equal(t, []pkgmodel.RoundingRule{
+		{Mode: RoundNearest, Increment: 15, Per: RoundPerEntry},
+		{ClientID: 1, Client: "Acme", Mode: RoundUp, Increment: 30, Per: RoundPerEntry},
+	}, rules())
+
+	require.Equal(t, http.StatusOK, callUndo(Undo, testSession).Code)
+	require.Equal(t, http.StatusOK, callUndo(Undo, testSession).Code)
+	assert.Equal(t, []pkgmodel.RoundingRule{{Mode: RoundNone}}, rules())
+}
@@ -0,0 +1,239 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	pkgglobal "timesheet/go/global"
+	pkgmodel "timesheet/go/model"
+
+	"github.com/gorilla/mux"
+)
+
+const scheduleColumns = "id, effective_from, monday, tuesday, wednesday, thursday, friday, saturday, sunday"
+
+// querySchedules reads all work schedules, earliest first
+func querySchedules(q Querier) ([]pkgmodel.WorkSchedule, error) {
+	rows, err := q.Query("SELECT " + scheduleColumns + " FROM work_schedules ORDER BY effective_from")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	schedules := []pkgmodel.WorkSchedule{}
+	for rows.Next() {
+		var s pkgmodel.WorkSchedule
+		err := rows.Scan(&s.ID, &s.EffectiveFrom, &s.Monday, &s.Tuesday, &s.Wednesday, &s.Thursday,
+			&s.Friday, &s.Saturday, &s.Sunday)
+		if err != nil {
+			return nil, err
+		}
+		s.WeeklyMinutes = s.Monday + s.Tuesday + s.Wednesday + s.Thursday + s.Friday + s.Saturday + s.Sunday
+		schedules = append(schedules, s)
+	}
+	return schedules, rows.Err()
+}
+
+// scheduleMinutes returns the target minutes of a weekday
+func scheduleMinutes(s pkgmodel.WorkSchedule, day time.Weekday) int {
+	return [...]int{s.Sunday, s.Monday, s.Tuesday, s.Wednesday, s.Thursday, s.Friday, s.Saturday}[day]
+}
+
+// scheduleTarget returns the target minutes of a day by the schedule in effect, or 0 before
+// the first schedule. Schedules must be ordered by effective date.
+func scheduleTarget(schedules []pkgmodel.WorkSchedule, day time.Time) int {
+	date := day.Format("2006-01-02")
+	for i := len(schedules) - 1; i >= 0; i-- {
+		if schedules[i].EffectiveFrom <= date {
+			return scheduleMinutes(schedules[i], day.Weekday())
+		}
+	}
+	return 0
+}
+
+// validateSchedule checks a schedule request and returns the schedule to store
+func validateSchedule(req pkgmodel.WorkScheduleRequest) (pkgmodel.WorkSchedule, error) {
+	s := pkgmodel.WorkSchedule{
+		EffectiveFrom: req.EffectiveFrom,
+		Monday:        req.Monday,
+		Tuesday:       req.Tuesday,
+		Wednesday:     req.Wednesday,
+		Thursday:      req.Thursday,
+		Friday:        req.Friday,
+		Saturday:      req.Saturday,
+		Sunday:        req.Sunday,
+	}
+	if _, err := time.Parse("2006-01-02", s.EffectiveFrom); err != nil {
+		return s, fmt.Errorf("invalid effective_from '%s'. Expected YYYY-MM-DD", s.EffectiveFrom)
+	}
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if minutes := scheduleMinutes(s, day); minutes < 0 || minutes > 24*60 {
+			return s, fmt.Errorf("invalid minutes %d on %s. Expected 0 to 1440", minutes, day)
+		}
+		s.WeeklyMinutes += scheduleMinutes(s, day)
+	}
+	return s, nil
+}
+
+// Schedule handlers
+func GetSchedules(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	schedules, err := querySchedules(pkgglobal.Db)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(schedules)
+}
+
+// writeSchedule validates a schedule and inserts it, or updates the schedule with the given ID
+func writeSchedule(w http.ResponseWriter, r *http.Request, id int) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req pkgmodel.WorkScheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s, err := validateSchedule(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tx, err := BeginChange()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	if id > 0 {
+		var exists bool
+		if err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM work_schedules WHERE id = ?)", id).Scan(&exists); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !exists {
+			http.Error(w, "Schedule not found", http.StatusNotFound)
+			return
+		}
+	}
+
+	var taken bool
+	err = tx.QueryRow("SELECT EXISTS(SELECT 1 FROM work_schedules WHERE effective_from = ? AND id != ?)", s.EffectiveFrom, id).Scan(&taken)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if taken {
+		http.Error(w, "A schedule already starts on effective_from", http.StatusConflict)
+		return
+	}
+
+	action := "UPDATE: Modified"
+	if id == 0 {
+		action = "INSERT: Created"
+		result, err := tx.Exec(`INSERT INTO work_schedules (effective_from, monday, tuesday, wednesday, thursday, friday, saturday, sunday)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			s.EffectiveFrom, s.Monday, s.Tuesday, s.Wednesday, s.Thursday, s.Friday, s.Saturday, s.Sunday)
+		if err == nil {
+			newID, _ := result.LastInsertId()
+			id = int(newID)
+			err = AuditInsert(tx, AuditSchedule, id)
+		}
+		if err != nil {
+			log.Printf("ERROR: Failed to insert schedule from %s - Error: %v", s.EffectiveFrom, err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	} else {
+		err := AuditedWrite(tx, AuditSchedule, id, AuditUpdate, func() error {
+			_, err := tx.Exec(`UPDATE work_schedules SET effective_from = ?, monday = ?, tuesday = ?, wednesday = ?,
+				thursday = ?, friday = ?, saturday = ?, sunday = ? WHERE id = ?`,
+				s.EffectiveFrom, s.Monday, s.Tuesday, s.Wednesday, s.Thursday, s.Friday, s.Saturday, s.Sunday, id)
+			return err
+		})
+		if err != nil {
+			log.Printf("ERROR: Failed to update schedule ID %d - Error: %v", id, err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := tx.Commit(w, r); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("%s schedule ID %d - %d minutes per week from %s", action, id, s.WeeklyMinutes, s.EffectiveFrom)
+	s.ID = id
+
+	json.NewEncoder(w).Encode(s)
+}
+
+func CreateSchedule(w http.ResponseWriter, r *http.Request) {
+	writeSchedule(w, r, 0)
+}
+
+func UpdateSchedule(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || id <= 0 {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+	writeSchedule(w, r, id)
+}
+
+func DeleteSchedule(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := BeginChange()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	var effectiveFrom string
+	err = tx.QueryRow("SELECT effective_from FROM work_schedules WHERE id = ?", id).Scan(&effectiveFrom)
+	if err == sql.ErrNoRows {
+		log.Printf("WARNING: Attempted to delete non-existent schedule ID %d", id)
+		http.Error(w, "Schedule not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = AuditedWrite(tx, AuditSchedule, id, AuditDelete, func() error {
+		_, err := tx.Exec("DELETE FROM work_schedules WHERE id = ?", id)
+		return err
+	})
+	if err != nil {
+		log.Printf("ERROR: Failed to delete schedule ID %d - Error: %v", id, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(w, r); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("DELETE: Deleted schedule ID %d from %s", id, effectiveFrom)
+
+	w.WriteHeader(http.StatusNoContent)
+}
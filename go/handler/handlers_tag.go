@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	pkgglobal "timesheet/go/global"
+	pkgmodel "timesheet/go/model"
+)
+
+// GetTags lists all tags with the number of entries outside the trash carrying them
+func GetTags(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	rows, err := pkgglobal.Db.Query(`
+		SELECT t.id, t.name, COUNT(e.id)
+		FROM tags t
+		LEFT JOIN time_entry_tags et ON et.tag_id = t.id
+		LEFT JOIN time_entries e ON e.id = et.entry_id AND e.deleted_at IS NULL
+		GROUP BY t.id, t.name
+		ORDER BY t.name`)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	tags := []pkgmodel.Tag{}
+	for rows.Next() {
+		var tag pkgmodel.Tag
+		if err := rows.Scan(&tag.ID, &tag.Name, &tag.Entries); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		tags = append(tags, tag)
+	}
+
+	json.NewEncoder(w).Encode(tags)
+}
@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pkgmodel "timesheet/go/model"
+)
+
+// createTaggedEntry creates an entry with the given tags and returns its ID
+func createTaggedEntry(t *testing.T, task, start, end string, tags ...string) int {
+	entry := pkgmodel.TimeEntryRequest{Task: task, Category: "project work", Tags: tags, StartTime: start, EndTime: end}
+	w := sendJSON(t, CreateTimeEntry, http.MethodPost, "/api/entries", "", entry)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var result pkgmodel.TimeEntryResult
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&result))
+	return result.ID
+}
+
+func TestNormalizeTags(t *testing.T) {
+	tags, err := NormalizeTags([]string{" On-Call", "billable", "on-call"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"billable", "on-call"}, tags)
+
+	tags, err = NormalizeTags(nil)
+	require.NoError(t, err)
+	assert.Nil(t, tags)
+
+	for _, tag := range []string{" ", "a,b", string(make([]byte, MaxTagLength+1))} {
+		_, err := NormalizeTags([]string{tag})
+		assert.Error(t, err)
+	}
+}
+
+func TestTimeEntryTags(t *testing.T) {
+	setupHandlerTestDB(t)
+	createTaggedEntry(t, "Development", "2025-11-10T09:00:00Z", "2025-11-10T10:00:00Z", "Billable", "customer-x")
+	createTaggedEntry(t, "Support", "2025-11-10T10:00:00Z", "2025-11-10T10:30:00Z", "on-call", "billable")
+	createTaggedEntry(t, "Review", "2025-11-10T11:00:00Z", "2025-11-10T11:15:00Z")
+
+	entries := listEntries(t, "?sort=start_time").Entries
+	require.Len(t, entries, 3)
+	assert.Equal(t, []string{"billable", "customer-x"}, entries[0].Tags)
+	assert.Equal(t, []string{}, entries[2].Tags)
+
+	assert.Equal(t, 2, listEntries(t, "?tag=billable").Total)
+	page := listEntries(t, "?tag=billable&tag=on-call")
+	require.Equal(t, 1, page.Total)
+	assert.Equal(t, "Support", page.Entries[0].Task)
+
+	// Updates without tags keep them, an empty list removes them
+	update := pkgmodel.TimeEntryRequest{Task: "Development", Category: "project work",
+		StartTime: "2025-11-10T09:00:00Z", EndTime: "2025-11-10T09:45:00Z"}
+	require.Equal(t, http.StatusOK, sendJSON(t, UpdateTimeEntry, http.MethodPut, "/api/entries/1", "1", update).Code)
+	assert.Equal(t, 2, listEntries(t, "?tag=billable").Total)
+	update.Tags = []string{}
+	require.Equal(t, http.StatusOK, sendJSON(t, UpdateTimeEntry, http.MethodPut, "/api/entries/1", "1", update).Code)
+	assert.Equal(t, 1, listEntries(t, "?tag=billable").Total)
+
+	w := sendJSON(t, PatchTimeEntry, http.MethodPatch, "/api/entries/3", "3", `{"tags": ["customer-x"]}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var result pkgmodel.TimeEntryResult
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&result))
+	assert.Equal(t, []string{"customer-x"}, result.Tags)
+
+	update.Tags = []string{"bad,tag"}
+	assert.Equal(t, http.StatusBadRequest, sendJSON(t, UpdateTimeEntry, http.MethodPut, "/api/entries/1", "1", update).Code)
+
+	w = httptest.NewRecorder()
+	GetTags(w, httptest.NewRequest(http.MethodGet, "/api/tags", nil))
+	var tags []pkgmodel.Tag
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&tags))
+	assert.Equal(t, []pkgmodel.Tag{{ID: 1, Name: "billable", Entries: 1}, {ID: 2, Name: "customer-x", Entries: 1},
+		{ID: 3, Name: "on-call", Entries: 1}}, tags)
+}
+
+func TestSummaryReportTagTotals(t *testing.T) {
+	setupHandlerTestDB(t)
+	createTaggedEntry(t, "Development", "2025-11-10T09:00:00Z", "2025-11-10T10:00:00Z", "billable", "customer-x")
+	createTaggedEntry(t, "Support", "2025-11-10T10:00:00Z", "2025-11-10T10:30:00Z", "billable")
+	createTaggedEntry(t, "Review", "2025-11-11T11:00:00Z", "2025-11-11T11:15:00Z")
+
+	w, report := getSummaryReport(t, "from=2025-11-10&to=2025-11-11")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, 105, report.TotalMinutes)
+	assert.Equal(t, []pkgmodel.TagTotal{
+		{Name: "billable", TotalMinutes: 90, EntryCount: 2},
+		{Name: "customer-x", TotalMinutes: 60, EntryCount: 1},
+	}, report.Tags)
+
+	w, report = getSummaryReport(t, "tag=billable")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, 90, report.TotalMinutes)
+}
+
+func TestUndoRestoresTags(t *testing.T) {
+	setupUndoTest(t)
+	createTaggedEntry(t, "Development", "2025-11-10T09:00:00Z", "2025-11-10T10:00:00Z", "billable")
+	require.Equal(t, http.StatusOK, sendJSON(t, PatchTimeEntry, http.MethodPatch, "/api/entries/1", "1", `{"tags": null}`).Code)
+	assert.Equal(t, []string{}, currentEntries(t)[0].Tags)
+
+	require.Equal(t, http.StatusOK, callUndo(Undo, testSession).Code)
+	assert.Equal(t, []string{"billable"}, currentEntries(t)[0].Tags)
+}
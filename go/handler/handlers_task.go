@@ -9,6 +9,7 @@ import (
 
 	pkgglobal "timesheet/go/global"
 	pkgmodel "timesheet/go/model"
+	pkgutil "timesheet/go/util"
 
 	"github.com/gorilla/mux"
 )
@@ -17,7 +18,7 @@ import (
 func GetTasks(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	rows, err := pkgglobal.Db.Query("SELECT id, name, category_id, description FROM tasks ORDER BY name")
+	rows, err := pkgglobal.Db.Query("SELECT id, name, category_id, project_id, description FROM tasks WHERE deleted_at IS NULL ORDER BY name")
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -26,22 +27,39 @@ func GetTasks(w http.ResponseWriter, r *http.Request) {
 
 	var tasks []pkgmodel.Task
 	for rows.Next() {
-		var task pkgmodel.Task
-		var categoryID sql.NullInt64
-		err := rows.Scan(&task.ID, &task.Name, &categoryID, &task.Description)
+		task, err := scanTask(rows)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
-		if categoryID.Valid {
-			task.CategoryID = int(categoryID.Int64)
-		}
 		tasks = append(tasks, task)
 	}
 
 	json.NewEncoder(w).Encode(tasks)
 }
 
+// scanTask reads a row of id, name, category_id, project_id and description; unlinked
+// categories and projects read as 0
+func scanTask(rows *sql.Rows) (pkgmodel.Task, error) {
+	var task pkgmodel.Task
+	var categoryID, projectID sql.NullInt64
+	var description sql.NullString
+	err := rows.Scan(&task.ID, &task.Name, &categoryID, &projectID, &description)
+	task.CategoryID = int(categoryID.Int64)
+	task.ProjectID = int(projectID.Int64)
+	task.Description = description.String
+	return task, err
+}
+
+// taskProjectID validates the project of a task request and returns the value to store
+func taskProjectID(q Querier, projectID int) (interface{}, bool, error) {
+	if projectID == 0 {
+		return nil, true, nil
+	}
+	exists, err := projectExists(q, projectID)
+	return projectID, exists, err
+}
+
 func CreateTask(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
@@ -61,8 +79,25 @@ func CreateTask(w http.ResponseWriter, r *http.Request) {
 		categoryID = req.CategoryID
 	}
 
-	result, err := pkgglobal.Db.Exec("INSERT INTO tasks (name, category_id, description) VALUES (?, ?, ?)",
-		req.Name, categoryID, req.Description)
+	tx, err := BeginChange()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	projectID, valid, err := taskProjectID(tx, req.ProjectID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !valid {
+		http.Error(w, "Invalid project_id. Project does not exist", http.StatusBadRequest)
+		return
+	}
+
+	result, err := tx.Exec("INSERT INTO tasks (name, category_id, project_id, description) VALUES (?, ?, ?, ?)",
+		req.Name, categoryID, projectID, req.Description)
 	if err != nil {
 		log.Printf("ERROR: Failed to insert task - Name: %s, CategoryID: %v, Description: %s - Error: %v",
 			req.Name, req.CategoryID, req.Description, err)
@@ -71,12 +106,27 @@ func CreateTask(w http.ResponseWriter, r *http.Request) {
 	}
 
 	id, _ := result.LastInsertId()
+	err = AuditInsert(tx, AuditTask, int(id))
+	if err == nil {
+		err = SyncEntryLinks(tx, "task", int(id), req.Name)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(w, r); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
 	log.Printf("INSERT: Created task ID %d - Name: %s, CategoryID: %d, Description: %s",
 		id, req.Name, req.CategoryID, req.Description)
 	task := pkgmodel.Task{
 		ID:          int(id),
 		Name:        req.Name,
 		CategoryID:  req.CategoryID,
+		ProjectID:   req.ProjectID,
 		Description: req.Description,
 	}
 
@@ -109,8 +159,31 @@ func UpdateTask(w http.ResponseWriter, r *http.Request) {
 		categoryID = req.CategoryID
 	}
 
-	_, err = pkgglobal.Db.Exec("UPDATE tasks SET name = ?, category_id = ?, description = ? WHERE id = ?",
-		req.Name, categoryID, req.Description, id)
+	tx, err := BeginChange()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	projectID, valid, err := taskProjectID(tx, req.ProjectID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !valid {
+		http.Error(w, "Invalid project_id. Project does not exist", http.StatusBadRequest)
+		return
+	}
+
+	err = AuditedWrite(tx, AuditTask, id, AuditUpdate, func() error {
+		_, err := tx.Exec("UPDATE tasks SET name = ?, category_id = ?, project_id = ?, description = ? WHERE id = ?",
+			req.Name, categoryID, projectID, req.Description, id)
+		return err
+	})
+	if err == nil {
+		err = SyncEntryLinks(tx, "task", id, req.Name)
+	}
 	if err != nil {
 		log.Printf("ERROR: Failed to update task ID %d - Name: %s, CategoryID: %v, Description: %s - Error: %v",
 			id, req.Name, req.CategoryID, req.Description, err)
@@ -118,6 +191,11 @@ func UpdateTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := tx.Commit(w, r); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
 	log.Printf("UPDATE: Modified task ID %d - Name: %s, CategoryID: %d, Description: %s",
 		id, req.Name, req.CategoryID, req.Description)
 
@@ -125,12 +203,124 @@ func UpdateTask(w http.ResponseWriter, r *http.Request) {
 		ID:          id,
 		Name:        req.Name,
 		CategoryID:  req.CategoryID,
+		ProjectID:   req.ProjectID,
 		Description: req.Description,
 	}
 
 	json.NewEncoder(w).Encode(task)
 }
 
+// PatchTask applies a JSON merge patch to a task; a null or 0 category_id or project_id
+// unlinks the task from its category or project
+func PatchTask(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	patch, err := decodeMergePatch(r.Body, "name", "category_id", "project_id", "description")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tx, err := BeginChange()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	var current pkgmodel.TaskRequest
+	var currentCategoryID, currentProjectID sql.NullInt64
+	var description sql.NullString
+	err = tx.QueryRow("SELECT name, category_id, project_id, description FROM tasks WHERE id = ? AND deleted_at IS NULL", id).
+		Scan(&current.Name, &currentCategoryID, &currentProjectID, &description)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Task not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	current.CategoryID = int(currentCategoryID.Int64)
+	current.ProjectID = int(currentProjectID.Int64)
+	current.Description = description.String
+
+	var req pkgmodel.TaskRequest
+	if err := applyMergePatch(patch, current, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Name == "" {
+		http.Error(w, "Task name is required", http.StatusBadRequest)
+		return
+	}
+
+	var categoryID interface{} = nil
+	if req.CategoryID > 0 {
+		categoryID = req.CategoryID
+	}
+	if patches(patch, "category_id") && req.CategoryID > 0 {
+		var exists bool
+		err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM categories WHERE id = ? AND deleted_at IS NULL)", req.CategoryID).Scan(&exists)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !exists {
+			http.Error(w, "Invalid category_id. Category does not exist", http.StatusBadRequest)
+			return
+		}
+	}
+
+	projectID, valid, err := taskProjectID(tx, req.ProjectID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !valid {
+		http.Error(w, "Invalid project_id. Project does not exist", http.StatusBadRequest)
+		return
+	}
+
+	err = AuditedWrite(tx, AuditTask, id, AuditUpdate, func() error {
+		_, err := tx.Exec("UPDATE tasks SET name = ?, category_id = ?, project_id = ?, description = ? WHERE id = ?",
+			req.Name, categoryID, projectID, req.Description, id)
+		return err
+	})
+	if err == nil {
+		err = SyncEntryLinks(tx, "task", id, req.Name)
+	}
+	if err != nil {
+		log.Printf("ERROR: Failed to patch task ID %d - Name: %s, CategoryID: %v, Description: %s - Error: %v",
+			id, req.Name, req.CategoryID, req.Description, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(w, r); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("UPDATE: Patched task ID %d - Name: %s, CategoryID: %d, Description: %s",
+		id, req.Name, req.CategoryID, req.Description)
+
+	json.NewEncoder(w).Encode(pkgmodel.Task{
+		ID:          id,
+		Name:        req.Name,
+		CategoryID:  req.CategoryID,
+		ProjectID:   req.ProjectID,
+		Description: req.Description,
+	})
+}
+
 func DeleteTask(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
@@ -139,10 +329,17 @@ func DeleteTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	tx, err := BeginChange()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
 	// Get task details before deletion for logging
 	var name, description string
 	var categoryID sql.NullInt64
-	err = pkgglobal.Db.QueryRow("SELECT name, category_id, description FROM tasks WHERE id = ?", id).
+	err = tx.QueryRow("SELECT name, category_id, description FROM tasks WHERE id = ? AND deleted_at IS NULL", id).
 		Scan(&name, &categoryID, &description)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -155,18 +352,27 @@ func DeleteTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_, err = pkgglobal.Db.Exec("DELETE FROM tasks WHERE id = ?", id)
+	err = AuditedWrite(tx, AuditTask, id, AuditDelete, func() error {
+		_, err := tx.Exec("UPDATE tasks SET deleted_at = ? WHERE id = ?",
+			pkgutil.FormatTimeForDB(pkgutil.CurrentWallClockTime()), id)
+		return err
+	})
 	if err != nil {
 		log.Printf("ERROR: Failed to delete task ID %d - Error: %v", id, err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	if err := tx.Commit(w, r); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
 	categoryIDVal := 0
 	if categoryID.Valid {
 		categoryIDVal = int(categoryID.Int64)
 	}
-	log.Printf("DELETE: Removed task ID %d - Name: %s, CategoryID: %d, Description: %s",
+	log.Printf("DELETE: Moved task ID %d to the trash - Name: %s, CategoryID: %d, Description: %s",
 		id, name, categoryIDVal, description)
 
 	w.WriteHeader(http.StatusNoContent)
@@ -0,0 +1,410 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	pkgglobal "timesheet/go/global"
+	pkgmodel "timesheet/go/model"
+
+	"github.com/gorilla/mux"
+)
+
+// templateColumns are the columns read by scanTemplate, in scan order
+const templateColumns = "id, task, description, category, start_time, duration, schedule, starts_on, ends_on, exclude_dates"
+
+// scanTemplate reads a row of templateColumns
+func scanTemplate(scan func(dest ...interface{}) error) (pkgmodel.RecurringTemplate, error) {
+	var t pkgmodel.RecurringTemplate
+	var endsOn sql.NullString
+	var excludeDates string
+	err := scan(&t.ID, &t.Task, &t.Description, &t.Category, &t.StartTime, &t.Duration,
+		&t.Schedule, &t.StartsOn, &endsOn, &excludeDates)
+	t.EndsOn = endsOn.String
+	t.ExcludeDates = []string{}
+	if excludeDates != "" {
+		t.ExcludeDates = strings.Split(excludeDates, ",")
+	}
+	return t, err
+}
+
+// queryTemplates lists all recurring templates ordered by start time
+func queryTemplates(q Querier) ([]pkgmodel.RecurringTemplate, error) {
+	rows, err := q.Query("SELECT " + templateColumns + " FROM recurring_templates ORDER BY start_time, id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	templates := []pkgmodel.RecurringTemplate{}
+	for rows.Next() {
+		t, err := scanTemplate(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, t)
+	}
+	return templates, rows.Err()
+}
+
+// validateTemplate checks a template request and normalizes its schedule and dates
+func validateTemplate(q Querier, req *pkgmodel.RecurringTemplateRequest) error {
+	if req.Task == "" || req.Category == "" || req.StartTime == "" || req.Schedule == "" || req.StartsOn == "" {
+		return errors.New("task, category, start_time, schedule and starts_on are required")
+	}
+	if _, err := time.Parse("15:04", req.StartTime); err != nil {
+		return fmt.Errorf("invalid start_time '%s'. Expected HH:MM", req.StartTime)
+	}
+	if req.Duration < 1 || req.Duration > 24*60 {
+		return errors.New("duration must be between 1 and 1440 minutes")
+	}
+	if _, err := ParseRecurrence(req.Schedule); err != nil {
+		return err
+	}
+	req.Schedule = strings.ToUpper(strings.ReplaceAll(req.Schedule, " ", ""))
+
+	startsOn, err := time.Parse("2006-01-02", req.StartsOn)
+	if err != nil {
+		return fmt.Errorf("invalid starts_on '%s'. Expected YYYY-MM-DD", req.StartsOn)
+	}
+	if req.EndsOn != "" {
+		endsOn, err := time.Parse("2006-01-02", req.EndsOn)
+		if err != nil {
+			return fmt.Errorf("invalid ends_on '%s'. Expected YYYY-MM-DD", req.EndsOn)
+		}
+		if endsOn.Before(startsOn) {
+			return errors.New("ends_on must not be before starts_on")
+		}
+	}
+	for _, date := range req.ExcludeDates {
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return fmt.Errorf("invalid exclude date '%s'. Expected YYYY-MM-DD", date)
+		}
+	}
+	sort.Strings(req.ExcludeDates)
+
+	exists, err := categoryExists(q, req.Category)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return errors.New("Invalid category. Category does not exist in the system")
+	}
+	return nil
+}
+
+// nullableDate stores an empty date as NULL
+func nullableDate(date string) interface{} {
+	if date == "" {
+		return nil
+	}
+	return date
+}
+
+// Recurring template handlers
+func GetTemplates(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	templates, err := queryTemplates(pkgglobal.Db)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(templates)
+}
+
+// writeTemplate inserts a template, or updates the one with the given ID
+func writeTemplate(w http.ResponseWriter, r *http.Request, id int) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req pkgmodel.RecurringTemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tx, err := BeginChange()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	if id > 0 {
+		var exists bool
+		if err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM recurring_templates WHERE id = ?)", id).Scan(&exists); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !exists {
+			http.Error(w, "Template not found", http.StatusNotFound)
+			return
+		}
+	}
+
+	if err := validateTemplate(tx, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	args := []interface{}{req.Task, req.Description, req.Category, req.StartTime, req.Duration,
+		req.Schedule, req.StartsOn, nullableDate(req.EndsOn), strings.Join(req.ExcludeDates, ",")}
+	action := "UPDATE: Modified"
+	if id == 0 {
+		action = "INSERT: Created"
+		result, err := tx.Exec(`
+			INSERT INTO recurring_templates (task, description, category, start_time, duration, schedule, starts_on, ends_on, exclude_dates)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`, args...)
+		if err == nil {
+			newID, _ := result.LastInsertId()
+			id = int(newID)
+			err = AuditInsert(tx, AuditTemplate, id)
+		}
+		if err != nil {
+			log.Printf("ERROR: Failed to insert recurring template - Task: %s - Error: %v", req.Task, err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	} else {
+		err := AuditedWrite(tx, AuditTemplate, id, AuditUpdate, func() error {
+			_, err := tx.Exec(`
+				UPDATE recurring_templates
+				SET task = ?, description = ?, category = ?, start_time = ?, duration = ?, schedule = ?, starts_on = ?, ends_on = ?, exclude_dates = ?
+				WHERE id = ?`, append(args, id)...)
+			return err
+		})
+		if err != nil {
+			log.Printf("ERROR: Failed to update recurring template ID %d - Error: %v", id, err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	template, err := scanTemplate(tx.QueryRow("SELECT "+templateColumns+" FROM recurring_templates WHERE id = ?", id).Scan)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(w, r); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("%s recurring template ID %d - Task: %s, Schedule: %s", action, id, req.Task, req.Schedule)
+
+	json.NewEncoder(w).Encode(template)
+}
+
+func CreateTemplate(w http.ResponseWriter, r *http.Request) {
+	writeTemplate(w, r, 0)
+}
+
+func UpdateTemplate(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+	writeTemplate(w, r, id)
+}
+
+func DeleteTemplate(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := BeginChange()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	var task string
+	err = tx.QueryRow("SELECT task FROM recurring_templates WHERE id = ?", id).Scan(&task)
+	if err == sql.ErrNoRows {
+		log.Printf("WARNING: Attempted to delete non-existent recurring template ID %d", id)
+		http.Error(w, "Template not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = AuditedWrite(tx, AuditTemplate, id, AuditDelete, func() error {
+		_, err := tx.Exec("DELETE FROM recurring_templates WHERE id = ?", id)
+		return err
+	})
+	if err != nil {
+		log.Printf("ERROR: Failed to delete recurring template ID %d - Error: %v", id, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(w, r); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("DELETE: Deleted recurring template ID %d - Task: %s", id, task)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// templateSkipReason returns why a template is not booked on day, or an empty string if it is
+// due and not booked yet
+func templateSkipReason(q Querier, t pkgmodel.RecurringTemplate, day time.Time) (string, error) {
+	date := day.Format("2006-01-02")
+	for _, excluded := range t.ExcludeDates {
+		if excluded == date {
+			return "excluded date", nil
+		}
+	}
+
+	// Nothing is booked on public holidays and full days of absence
+	daysOff, err := queryDaysOff(q, pkgglobal.HolidayRegion, day, day)
+	if err != nil {
+		return "", err
+	}
+	if off := daysOff[date]; off != nil && off.fullDay() {
+		if off.holiday != "" {
+			return "public holiday", nil
+		}
+		return "absent", nil
+	}
+
+	// An entry with the same task and category on that day counts as booked
+	var booked bool
+	err = q.QueryRow(`
+		SELECT EXISTS(SELECT 1 FROM time_entries
+			WHERE task = ? AND category = ? AND deleted_at IS NULL
+				AND datetime(start_time) >= datetime(?) AND datetime(start_time) < datetime(?))`,
+		t.Task, t.Category, day.Format(time.RFC3339), day.AddDate(0, 0, 1).Format(time.RFC3339)).Scan(&booked)
+	if err != nil {
+		return "", err
+	}
+	if booked {
+		return "already booked", nil
+	}
+	return "", nil
+}
+
+// dueTemplate reports whether a template is scheduled on day
+func dueTemplate(t pkgmodel.RecurringTemplate, day time.Time) (bool, error) {
+	recurrence, err := ParseRecurrence(t.Schedule)
+	if err != nil {
+		return false, fmt.Errorf("template %d: %w", t.ID, err)
+	}
+	first, err := time.Parse("2006-01-02", t.StartsOn)
+	if err != nil {
+		return false, fmt.Errorf("template %d: invalid starts_on '%s'", t.ID, t.StartsOn)
+	}
+	if t.EndsOn != "" && day.Format("2006-01-02") > t.EndsOn {
+		return false, nil
+	}
+	return recurrence.Due(first, day), nil
+}
+
+// ApplyTemplates books the templates due on the day given by `date`. Templates that are
+// excluded on that day or already booked are skipped; so are templates whose entry is
+// rejected, e.g. by the overlap policy.
+func ApplyTemplates(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	day, err := time.Parse("2006-01-02", r.URL.Query().Get("date"))
+	if err != nil {
+		http.Error(w, "Invalid date. Expected YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+
+	policy, err := RequestOverlapPolicy(r.URL.Query().Get("overlap"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tx, err := BeginChange()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	templates, err := queryTemplates(tx)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writer := &bulkWriter{tx: tx, policy: policy}
+	result := pkgmodel.TemplateApplyResult{
+		Date:    day.Format("2006-01-02"),
+		Created: []pkgmodel.TimeEntry{},
+		Skipped: []pkgmodel.TemplateSkip{},
+	}
+	for _, t := range templates {
+		due, err := dueTemplate(t, day)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !due {
+			continue
+		}
+
+		reason, err := templateSkipReason(tx, t, day)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if reason == "" {
+			clock, _ := time.Parse("15:04", t.StartTime)
+			start := day.Add(time.Duration(clock.Hour())*time.Hour + time.Duration(clock.Minute())*time.Minute)
+			entry := pkgmodel.TimeEntryRequest{
+				Task:        t.Task,
+				Description: t.Description,
+				Category:    t.Category,
+				StartTime:   start.Format(time.RFC3339),
+				EndTime:     start.Add(time.Duration(t.Duration) * time.Minute).Format(time.RFC3339),
+			}
+
+			var item pkgmodel.BulkItemResult
+			err := writer.write(&item, 0, entry)
+			if errors.Is(err, errBulkItem) {
+				reason = item.Error
+			} else if err != nil {
+				log.Printf("ERROR: Failed to apply recurring template ID %d - Error: %v", t.ID, err)
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			} else {
+				result.Created = append(result.Created, *item.Entry)
+			}
+		}
+		if reason != "" {
+			result.Skipped = append(result.Skipped, pkgmodel.TemplateSkip{TemplateID: t.ID, Task: t.Task, Reason: reason})
+		}
+	}
+
+	if err := tx.Commit(w, r); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("INSERT: Applied recurring templates for %s - Created: %d, Skipped: %d",
+		result.Date, len(result.Created), len(result.Skipped))
+
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,185 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pkgmodel "timesheet/go/model"
+)
+
+// listTemplates calls GetTemplates
+func listTemplates(t *testing.T) []pkgmodel.RecurringTemplate {
+	w := httptest.NewRecorder()
+	GetTemplates(w, httptest.NewRequest(http.MethodGet, "/api/templates", nil))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var templates []pkgmodel.RecurringTemplate
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&templates))
+	return templates
+}
+
+// applyTemplates calls ApplyTemplates for the given day
+func applyTemplates(t *testing.T, date string) pkgmodel.TemplateApplyResult {
+	req := httptest.NewRequest(http.MethodPost, "/api/templates/apply?date="+date, nil)
+	req.Header.Set("X-Session-ID", testSession)
+	w := httptest.NewRecorder()
+	ApplyTemplates(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var result pkgmodel.TemplateApplyResult
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&result))
+	return result
+}
+
+// clearTemplates removes the daily standup seeded by the migrations, so new templates start at ID 1
+func clearTemplates(t *testing.T, db *sql.DB) {
+	_, err := db.Exec(`DELETE FROM recurring_templates;
+		DELETE FROM sqlite_sequence WHERE name = 'recurring_templates';`)
+	require.NoError(t, err)
+}
+
+var dailyTemplate = pkgmodel.RecurringTemplateRequest{
+	Task: "Daily", Category: "project support", StartTime: "09:00", Duration: 30,
+	Schedule: "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR", StartsOn: "2025-11-03", ExcludeDates: []string{"2025-11-11"},
+}
+
+func TestTemplateCRUD(t *testing.T) {
+	clearTemplates(t, setupHandlerTestDB(t))
+
+	w := sendJSON(t, CreateTemplate, http.MethodPost, "/api/templates", "", dailyTemplate)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var created pkgmodel.RecurringTemplate
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&created))
+	assert.Equal(t, 1, created.ID)
+	assert.Equal(t, []string{"2025-11-11"}, created.ExcludeDates)
+
+	review := dailyTemplate
+	review.Task = "Review"
+	review.Schedule = "freq=weekly; interval=2"
+	review.ExcludeDates = nil
+	w = sendJSON(t, UpdateTemplate, http.MethodPut, "/api/templates/1", "1", review)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var updated pkgmodel.RecurringTemplate
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&updated))
+	assert.Equal(t, "FREQ=WEEKLY;INTERVAL=2", updated.Schedule)
+	assert.Equal(t, []string{}, updated.ExcludeDates)
+
+	assert.Equal(t, http.StatusNotFound, sendJSON(t, UpdateTemplate, http.MethodPut, "/api/templates/9", "9", review).Code)
+
+	templates := listTemplates(t)
+	require.Len(t, templates, 1)
+	assert.Equal(t, "Review", templates[0].Task)
+
+	w = httptest.NewRecorder()
+	req := mux.SetURLVars(httptest.NewRequest(http.MethodDelete, "/api/templates/1", nil), map[string]string{"id": "1"})
+	DeleteTemplate(w, req)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+}
+
+func TestTemplateChangesAreAuditedAndUndoable(t *testing.T) {
+	clearTemplates(t, setupUndoTest(t))
+	require.Equal(t, http.StatusOK, sendJSON(t, CreateTemplate, http.MethodPost, "/api/templates", "", dailyTemplate).Code)
+	review := dailyTemplate
+	review.Task = "Review"
+	require.Equal(t, http.StatusOK, sendJSON(t, UpdateTemplate, http.MethodPut, "/api/templates/1", "1", review).Code)
+
+	w := httptest.NewRecorder()
+	GetAuditLog(w, httptest.NewRequest(http.MethodGet, "/api/audit?entity_type=template", nil))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var records []pkgmodel.AuditRecord
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&records))
+	require.Len(t, records, 2)
+	assert.Equal(t, "create", records[0].Operation)
+	assert.Equal(t, "update", records[1].Operation)
+	assert.Equal(t, 1, records[1].EntityID)
+
+	require.Equal(t, http.StatusOK, callUndo(Undo, testSession).Code)
+	templates := listTemplates(t)
+	require.Len(t, templates, 1)
+	assert.Equal(t, "Daily", templates[0].Task)
+	assert.Equal(t, []string{"2025-11-11"}, templates[0].ExcludeDates)
+
+	// Undoing a deletion brings the template back
+	w = httptest.NewRecorder()
+	req := mux.SetURLVars(httptest.NewRequest(http.MethodDelete, "/api/templates/1", nil), map[string]string{"id": "1"})
+	req.Header.Set("X-Session-ID", testSession)
+	DeleteTemplate(w, req)
+	require.Equal(t, http.StatusNoContent, w.Code)
+	assert.Empty(t, listTemplates(t))
+	require.Equal(t, http.StatusOK, callUndo(Undo, testSession).Code)
+	templates = listTemplates(t)
+	require.Len(t, templates, 1)
+	assert.Equal(t, "Daily", templates[0].Task)
+}
+
+func TestTemplateValidation(t *testing.T) {
+	setupHandlerTestDB(t)
+
+	for name, change := range map[string]func(*pkgmodel.RecurringTemplateRequest){
+		"missing task":     func(r *pkgmodel.RecurringTemplateRequest) { r.Task = "" },
+		"unknown category": func(r *pkgmodel.RecurringTemplateRequest) { r.Category = "meetings" },
+		"start time":       func(r *pkgmodel.RecurringTemplateRequest) { r.StartTime = "9am" },
+		"duration":         func(r *pkgmodel.RecurringTemplateRequest) { r.Duration = 0 },
+		"schedule":         func(r *pkgmodel.RecurringTemplateRequest) { r.Schedule = "FREQ=YEARLY" },
+		"ends before":      func(r *pkgmodel.RecurringTemplateRequest) { r.EndsOn = "2025-11-01" },
+		"exclude date":     func(r *pkgmodel.RecurringTemplateRequest) { r.ExcludeDates = []string{"11.11.2025"} },
+	} {
+		template := dailyTemplate
+		change(&template)
+		assert.Equal(t, http.StatusBadRequest, sendJSON(t, CreateTemplate, http.MethodPost, "/api/templates", "", template).Code, name)
+	}
+}
+
+func TestApplyTemplates(t *testing.T) {
+	db := setupHandlerTestDB(t)
+	clearTemplates(t, db)
+	require.Equal(t, http.StatusOK, sendJSON(t, CreateTemplate, http.MethodPost, "/api/templates", "", dailyTemplate).Code)
+	review := dailyTemplate
+	review.Task = "Review"
+	review.Category = "project work"
+	review.StartTime = "16:00"
+	review.Schedule = "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO"
+	review.EndsOn = "2025-11-30"
+	require.Equal(t, http.StatusOK, sendJSON(t, CreateTemplate, http.MethodPost, "/api/templates", "", review).Code)
+
+	// Monday of the first week: both are due
+	result := applyTemplates(t, "2025-11-03")
+	require.Len(t, result.Created, 2)
+	assert.Equal(t, "Daily", result.Created[0].Task)
+	assert.Equal(t, "2025-11-03T09:00:00Z", result.Created[0].StartTime.Format("2006-01-02T15:04:05Z07:00"))
+	assert.Equal(t, 30, result.Created[0].Duration)
+	assert.Equal(t, "Review", result.Created[1].Task)
+
+	// Applying again skips the booked templates
+	result = applyTemplates(t, "2025-11-03")
+	assert.Empty(t, result.Created)
+	assert.Equal(t, []pkgmodel.TemplateSkip{
+		{TemplateID: 1, Task: "Daily", Reason: "already booked"},
+		{TemplateID: 2, Task: "Review", Reason: "already booked"},
+	}, result.Skipped)
+
+	// The review is only due every other week, the daily not on its excluded date
+	assert.Len(t, applyTemplates(t, "2025-11-10").Created, 1)
+	result = applyTemplates(t, "2025-11-11")
+	assert.Empty(t, result.Created)
+	assert.Equal(t, "excluded date", result.Skipped[0].Reason)
+	assert.Len(t, applyTemplates(t, "2025-11-17").Created, 2)
+	assert.Len(t, applyTemplates(t, "2025-12-01").Created, 1)
+	assert.Empty(t, applyTemplates(t, "2025-11-15").Created)
+
+	var count int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM time_entries").Scan(&count))
+	assert.Equal(t, 6, count)
+}
+
+func TestApplyTemplatesRequiresDate(t *testing.T) {
+	setupHandlerTestDB(t)
+	w := httptest.NewRecorder()
+	ApplyTemplates(w, httptest.NewRequest(http.MethodPost, "/api/templates/apply?date=tomorrow", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -10,6 +10,7 @@ import (
 
 	pkgglobal "timesheet/go/global"
 	pkgmodel "timesheet/go/model"
+	pkgutil "timesheet/go/util"
 
 	"github.com/gorilla/mux"
 )
@@ -18,50 +19,140 @@ import (
 func GetTimeEntries(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	rows, err := pkgglobal.Db.Query(`
-		SELECT id, task, description, category, start_time, end_time 
-		FROM time_entries 
-		ORDER BY start_time DESC, id DESC
-	`)
+	filter, err := ParseTimeEntryFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Count all matches regardless of the page
+	where, args := filter.WhereClause(false)
+	var total int
+	err = pkgglobal.Db.QueryRow("SELECT COUNT(*) FROM time_entries"+where, args...).Scan(&total)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	where, args = filter.WhereClause(true)
+	query := `
+		SELECT ` + timeEntryColumns + `
+		FROM time_entries` + where + filter.OrderClause()
+	if filter.Limit > 0 {
+		// Fetch one extra row to find out whether another page follows
+		query += " LIMIT ?"
+		args = append(args, filter.Limit+1)
+	}
+
+	rows, err := pkgglobal.Db.Query(query, args...)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 	defer rows.Close()
 
-	var entries []pkgmodel.TimeEntry
-	for rows.Next() {
-		var entry pkgmodel.TimeEntry
-		var startTime, endTime sql.NullString
+	entries, err := scanTimeEntries(rows)
+	if err == nil {
+		err = attachTags(pkgglobal.Db, entries)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 
-		err := rows.Scan(&entry.ID, &entry.Task, &entry.Description, &entry.Category,
-			&startTime, &endTime)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
+	page := pkgmodel.TimeEntryPage{Entries: entries, Total: total}
+	if filter.Limit > 0 && len(entries) > filter.Limit {
+		page.Entries = entries[:filter.Limit]
+		last := page.Entries[filter.Limit-1]
+		cursor := TimeEntryCursor{Value: last.StartTime.Format(time.RFC3339), ID: last.ID}
+		if filter.SortColumn == "duration" {
+			cursor.Value = strconv.Itoa(last.Duration)
 		}
+		page.NextCursor = EncodeCursor(cursor)
+	}
+	if page.Entries == nil {
+		page.Entries = []pkgmodel.TimeEntry{}
+	}
+
+	json.NewEncoder(w).Encode(page)
+}
+
+// timeEntryColumns are the columns read into a timeEntryRow, in scan order
+const timeEntryColumns = "id, task, description, category, start_time, end_time, duration, category_id, task_id, project_id, billable"
+
+// timeEntryRow holds the raw columns (see timeEntryColumns) of a scanned time entry
+type timeEntryRow struct {
+	entry                           pkgmodel.TimeEntry
+	description, startTime, endTime sql.NullString
+	categoryID, taskID, projectID   sql.NullInt64
+}
+
+// fields returns the scan destinations in column order
+func (row *timeEntryRow) fields() []interface{} {
+	return []interface{}{&row.entry.ID, &row.entry.Task, &row.description, &row.entry.Category,
+		&row.startTime, &row.endTime, &row.entry.Duration, &row.categoryID, &row.taskID, &row.projectID, &row.entry.Billable}
+}
 
-		if startTime.Valid {
-			parsedStartTime, err := time.Parse(time.RFC3339, startTime.String)
-			if err != nil {
-				log.Printf("Error parsing start_time '%s': %v", startTime.String, err)
-			} else {
-				entry.StartTime = parsedStartTime
-			}
+// nullableID converts a nullable ID column into a pointer
+func nullableID(id sql.NullInt64) *int {
+	if !id.Valid {
+		return nil
+	}
+	value := int(id.Int64)
+	return &value
+}
+
+// toEntry converts the raw columns into a time entry
+func (row *timeEntryRow) toEntry() pkgmodel.TimeEntry {
+	entry := row.entry
+	entry.Description = row.description.String
+	entry.CategoryID = nullableID(row.categoryID)
+	entry.TaskID = nullableID(row.taskID)
+	entry.ProjectID = nullableID(row.projectID)
+	entry.Tags = []string{}
+
+	if row.startTime.Valid {
+		parsedStartTime, err := time.Parse(time.RFC3339, row.startTime.String)
+		if err != nil {
+			log.Printf("Error parsing start_time '%s': %v", row.startTime.String, err)
+		} else {
+			entry.StartTime = parsedStartTime
 		}
-		if endTime.Valid {
-			parsedEndTime, err := time.Parse(time.RFC3339, endTime.String)
-			if err != nil {
-				log.Printf("Error parsing end_time '%s': %v", endTime.String, err)
-			} else {
-				entry.EndTime = parsedEndTime
-			}
+	}
+	if row.endTime.Valid {
+		parsedEndTime, err := time.Parse(time.RFC3339, row.endTime.String)
+		if err != nil {
+			log.Printf("Error parsing end_time '%s': %v", row.endTime.String, err)
+		} else {
+			entry.EndTime = &parsedEndTime
 		}
+	}
+	return entry
+}
 
-		entries = append(entries, entry)
+// scanTimeEntries reads rows of timeEntryColumns
+func scanTimeEntries(rows *sql.Rows) ([]pkgmodel.TimeEntry, error) {
+	var entries []pkgmodel.TimeEntry
+	for rows.Next() {
+		var row timeEntryRow
+		if err := rows.Scan(row.fields()...); err != nil {
+			return nil, err
+		}
+		entries = append(entries, row.toEntry())
 	}
+	return entries, rows.Err()
+}
 
-	json.NewEncoder(w).Encode(entries)
+// getTimeEntry reads a single time entry that is not in the trash
+func getTimeEntry(q Querier, id int) (pkgmodel.TimeEntry, error) {
+	var row timeEntryRow
+	err := q.QueryRow("SELECT "+timeEntryColumns+" FROM time_entries WHERE id = ? AND deleted_at IS NULL", id).Scan(row.fields()...)
+	if err != nil {
+		return pkgmodel.TimeEntry{}, err
+	}
+	entries := []pkgmodel.TimeEntry{row.toEntry()}
+	err = attachTags(q, entries)
+	return entries[0], err
 }
 
 func CreateTimeEntry(w http.ResponseWriter, r *http.Request) {
@@ -79,9 +170,15 @@ func CreateTimeEntry(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	tags, err := NormalizeTags(req.Tags)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	// Validate category exists in database
 	var categoryExists bool
-	err := pkgglobal.Db.QueryRow("SELECT EXISTS(SELECT 1 FROM categories WHERE name = ?)", req.Category).Scan(&categoryExists)
+	err = pkgglobal.Db.QueryRow("SELECT EXISTS(SELECT 1 FROM categories WHERE name = ? AND deleted_at IS NULL)", req.Category).Scan(&categoryExists)
 	if err != nil {
 		http.Error(w, "Database error while validating category", http.StatusInternalServerError)
 		return
@@ -116,11 +213,41 @@ func CreateTimeEntry(w http.ResponseWriter, r *http.Request) {
 	// Get current date for compatibility with existing database schema
 	currentDate := time.Now().Format("2006-01-02")
 
-	result, err := pkgglobal.Db.Exec(`
-		INSERT INTO time_entries (task, description, category, start_time, end_time, duration, date)
-		VALUES (?, ?, ?, ?, ?, ?, ?)
+	policy, err := RequestOverlapPolicy(r.URL.Query().Get("overlap"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tx, err := BeginChange()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	validProject, err := validEntryProject(tx, req.ProjectID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !validProject {
+		http.Error(w, "Invalid project_id. Project does not exist", http.StatusBadRequest)
+		return
+	}
+
+	// Check for overlapping entries according to the policy
+	conflicts, err := ResolveOverlaps(tx, policy, startTime, endTime, 0)
+	if err != nil {
+		writeOverlapError(w, err)
+		return
+	}
+
+	result, err := tx.Exec(`
+		INSERT INTO time_entries (task, description, category, start_time, end_time, duration, date, billable)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
 	`, req.Task, req.Description, req.Category, startTime.Format(time.RFC3339),
-		endTime.Format(time.RFC3339), duration, currentDate)
+		endTime.Format(time.RFC3339), duration, currentDate, req.Billable == nil || *req.Billable)
 
 	if err != nil {
 		log.Printf("ERROR: Failed to insert time entry - Task: %s, Category: %s, Start: %s, End: %s - Error: %v",
@@ -129,20 +256,39 @@ func CreateTimeEntry(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Read the entry back to include the category and task IDs linked by the database
 	id, _ := result.LastInsertId()
-	log.Printf("INSERT: Created time entry ID %d - Task: %s, Category: %s, Duration: %d min, Start: %s, End: %s",
-		id, req.Task, req.Category, duration, startTime.Format("2006-01-02 15:04"), endTime.Format("2006-01-02 15:04"))
+	if err := setEntryProject(tx, int(id), req.ProjectID); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := setEntryTags(tx, int(id), tags); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := AuditInsert(tx, AuditEntry, int(id)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	entry, err := getTimeEntry(tx, int(id))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 
-	entry := pkgmodel.TimeEntry{
-		ID:          int(id),
-		Task:        req.Task,
-		Description: req.Description,
-		Category:    req.Category,
-		StartTime:   startTime,
-		EndTime:     endTime,
+	if err := tx.Commit(w, r); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
 	}
 
-	json.NewEncoder(w).Encode(entry)
+	log.Printf("INSERT: Created time entry ID %d - Task: %s, Category: %s, Duration: %d min, Start: %s, End: %s",
+		id, req.Task, req.Category, duration, startTime.Format("2006-01-02 15:04"), endTime.Format("2006-01-02 15:04"))
+
+	json.NewEncoder(w).Encode(pkgmodel.TimeEntryResult{
+		TimeEntry: entry,
+		Warning:   overlapWarning(policy, conflicts),
+		Conflicts: conflicts,
+	})
 }
 
 func UpdateTimeEntry(w http.ResponseWriter, r *http.Request) {
@@ -167,9 +313,15 @@ func UpdateTimeEntry(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	tags, err := NormalizeTags(req.Tags)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	// Validate category exists in database
 	var categoryExists bool
-	err = pkgglobal.Db.QueryRow("SELECT EXISTS(SELECT 1 FROM categories WHERE name = ?)", req.Category).Scan(&categoryExists)
+	err = pkgglobal.Db.QueryRow("SELECT EXISTS(SELECT 1 FROM categories WHERE name = ? AND deleted_at IS NULL)", req.Category).Scan(&categoryExists)
 	if err != nil {
 		http.Error(w, "Database error while validating category", http.StatusInternalServerError)
 		return
@@ -204,13 +356,52 @@ func UpdateTimeEntry(w http.ResponseWriter, r *http.Request) {
 	// Get current date for compatibility with existing database schema
 	currentDate := time.Now().Format("2006-01-02")
 
-	_, err = pkgglobal.Db.Exec(`
-		UPDATE time_entries 
-		SET task = ?, description = ?, category = ?, start_time = ?, end_time = ?, duration = ?, date = ?
-		WHERE id = ?
-	`, req.Task, req.Description, req.Category, startTime.Format(time.RFC3339),
-		endTime.Format(time.RFC3339), duration, currentDate, id)
+	policy, err := RequestOverlapPolicy(r.URL.Query().Get("overlap"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tx, err := BeginChange()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
 
+	validProject, err := validEntryProject(tx, req.ProjectID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !validProject {
+		http.Error(w, "Invalid project_id. Project does not exist", http.StatusBadRequest)
+		return
+	}
+
+	// Check for overlapping entries according to the policy
+	conflicts, err := ResolveOverlaps(tx, policy, startTime, endTime, id)
+	if err != nil {
+		writeOverlapError(w, err)
+		return
+	}
+
+	err = AuditedWrite(tx, AuditEntry, id, AuditUpdate, func() error {
+		_, err := tx.Exec(`
+			UPDATE time_entries 
+			SET task = ?, description = ?, category = ?, start_time = ?, end_time = ?, duration = ?, date = ?,
+				billable = COALESCE(?, billable)
+			WHERE id = ? AND deleted_at IS NULL
+		`, req.Task, req.Description, req.Category, startTime.Format(time.RFC3339),
+			endTime.Format(time.RFC3339), duration, currentDate, req.Billable, id)
+		if err == nil && req.ProjectID != nil {
+			err = setEntryProject(tx, id, req.ProjectID)
+		}
+		if err != nil || tags == nil {
+			return err
+		}
+		return setEntryTags(tx, id, tags)
+	})
 	if err != nil {
 		log.Printf("ERROR: Failed to update time entry ID %d - Task: %s, Category: %s, Start: %s, End: %s - Error: %v",
 			id, req.Task, req.Category, req.StartTime, req.EndTime, err)
@@ -218,19 +409,187 @@ func UpdateTimeEntry(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	entry, err := getTimeEntry(tx, id)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Time entry not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(w, r); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
 	log.Printf("UPDATE: Modified time entry ID %d - Task: %s, Category: %s, Duration: %d min, Start: %s, End: %s",
 		id, req.Task, req.Category, duration, startTime.Format("2006-01-02 15:04"), endTime.Format("2006-01-02 15:04"))
 
-	entry := pkgmodel.TimeEntry{
-		ID:          id,
-		Task:        req.Task,
-		Description: req.Description,
-		Category:    req.Category,
-		StartTime:   startTime,
-		EndTime:     endTime,
+	json.NewEncoder(w).Encode(pkgmodel.TimeEntryResult{
+		TimeEntry: entry,
+		Warning:   overlapWarning(policy, conflicts),
+		Conflicts: conflicts,
+	})
+}
+
+// PatchTimeEntry applies a JSON merge patch to a time entry. Only the supplied fields are
+// validated; the overlap policy is applied when start_time or end_time change.
+func PatchTimeEntry(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	patch, err := decodeMergePatch(r.Body, "task", "description", "category", "project_id", "billable", "tags", "start_time", "end_time")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	policy, err := RequestOverlapPolicy(r.URL.Query().Get("overlap"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tx, err := BeginChange()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	current, err := getTimeEntry(tx, id)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Time entry not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	currentReq := pkgmodel.TimeEntryRequest{
+		Task:        current.Task,
+		Description: current.Description,
+		Category:    current.Category,
+		ProjectID:   current.ProjectID,
+		Billable:    &current.Billable,
+		Tags:        current.Tags,
+		StartTime:   current.StartTime.Format(time.RFC3339),
+	}
+	if current.EndTime != nil {
+		currentReq.EndTime = current.EndTime.Format(time.RFC3339)
+	}
+	var req pkgmodel.TimeEntryRequest
+	if err := applyMergePatch(patch, currentReq, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
-	json.NewEncoder(w).Encode(entry)
+	if req.Task == "" || req.Category == "" {
+		http.Error(w, "Task and category must not be empty", http.StatusBadRequest)
+		return
+	}
+	tags, err := NormalizeTags(req.Tags)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if patches(patch, "project_id") {
+		// A null project_id removes the project
+		if req.ProjectID == nil {
+			req.ProjectID = new(int)
+		}
+		valid, err := validEntryProject(tx, req.ProjectID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !valid {
+			http.Error(w, "Invalid project_id. Project does not exist", http.StatusBadRequest)
+			return
+		}
+	}
+	// A null billable flag resets it to the default
+	billable := req.Billable == nil || *req.Billable
+	if patches(patch, "category") {
+		exists, err := categoryExists(tx, req.Category)
+		if err != nil {
+			http.Error(w, "Database error while validating category", http.StatusInternalServerError)
+			return
+		}
+		if !exists {
+			http.Error(w, "Invalid category. Category does not exist in the system", http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Times are kept as stored unless they are patched, so running timers stay running
+	startTime, endTime, duration := current.StartTime, current.EndTime, current.Duration
+	var conflicts []int
+	if patches(patch, "start_time", "end_time") {
+		start, end, minutes, err := ParseAndValidateTimeEntry(req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if conflicts, err = ResolveOverlaps(tx, policy, start, end, id); err != nil {
+			writeOverlapError(w, err)
+			return
+		}
+		startTime, endTime, duration = start, &end, minutes
+	}
+	var endValue interface{}
+	if endTime != nil {
+		endValue = endTime.Format(time.RFC3339)
+	}
+
+	err = AuditedWrite(tx, AuditEntry, id, AuditUpdate, func() error {
+		_, err := tx.Exec(`
+			UPDATE time_entries
+			SET task = ?, description = ?, category = ?, start_time = ?, end_time = ?, duration = ?, billable = ?
+			WHERE id = ? AND deleted_at IS NULL
+		`, req.Task, req.Description, req.Category, startTime.Format(time.RFC3339), endValue, duration, billable, id)
+		if err == nil && patches(patch, "project_id") {
+			err = setEntryProject(tx, id, req.ProjectID)
+		}
+		if err != nil || !patches(patch, "tags") {
+			return err
+		}
+		return setEntryTags(tx, id, tags)
+	})
+	if err != nil {
+		log.Printf("ERROR: Failed to patch time entry ID %d - Error: %v", id, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	entry, err := getTimeEntry(tx, id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(w, r); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("UPDATE: Patched time entry ID %d - Task: %s, Category: %s, Duration: %d min",
+		id, entry.Task, entry.Category, entry.Duration)
+
+	json.NewEncoder(w).Encode(pkgmodel.TimeEntryResult{
+		TimeEntry: entry,
+		Warning:   overlapWarning(policy, conflicts),
+		Conflicts: conflicts,
+	})
 }
 
 func DeleteTimeEntry(w http.ResponseWriter, r *http.Request) {
@@ -241,10 +600,17 @@ func DeleteTimeEntry(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	tx, err := BeginChange()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
 	// Get entry details before deletion for logging
 	var task, category string
 	var startTime, endTime sql.NullString
-	err = pkgglobal.Db.QueryRow("SELECT task, category, start_time, end_time FROM time_entries WHERE id = ?", id).
+	err = tx.QueryRow("SELECT task, category, start_time, end_time FROM time_entries WHERE id = ? AND deleted_at IS NULL", id).
 		Scan(&task, &category, &startTime, &endTime)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -257,13 +623,22 @@ func DeleteTimeEntry(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_, err = pkgglobal.Db.Exec("DELETE FROM time_entries WHERE id = ?", id)
+	err = AuditedWrite(tx, AuditEntry, id, AuditDelete, func() error {
+		_, err := tx.Exec("UPDATE time_entries SET deleted_at = ? WHERE id = ?",
+			pkgutil.FormatTimeForDB(pkgutil.CurrentWallClockTime()), id)
+		return err
+	})
 	if err != nil {
 		log.Printf("ERROR: Failed to delete time entry ID %d - Error: %v", id, err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	if err := tx.Commit(w, r); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
 	// Format times for logging
 	startTimeStr := "N/A"
 	endTimeStr := "N/A"
@@ -278,7 +653,7 @@ func DeleteTimeEntry(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	log.Printf("DELETE: Removed time entry ID %d - Task: %s, Category: %s, Time: %s to %s",
+	log.Printf("DELETE: Moved time entry ID %d to the trash - Task: %s, Category: %s, Time: %s to %s",
 		id, task, category, startTimeStr, endTimeStr)
 
 	w.WriteHeader(http.StatusNoContent)
@@ -0,0 +1,227 @@
+package handler
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	pkgglobal "timesheet/go/global"
+	pkgmodel "timesheet/go/model"
+	pkgutil "timesheet/go/util"
+)
+
+const selectRunningTimer = `
+	SELECT ` + timeEntryColumns + `
+	FROM time_entries
+	WHERE end_time IS NULL AND start_time IS NOT NULL AND deleted_at IS NULL
+	ORDER BY start_time DESC, id DESC
+	LIMIT 1`
+
+// queryRunningTimer returns the currently running time entry or nil if there is none
+func queryRunningTimer(tx Querier) (*pkgmodel.TimeEntry, error) {
+	rows, err := tx.Query(selectRunningTimer)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries, err := scanTimeEntries(rows)
+	if err != nil || len(entries) == 0 {
+		return nil, err
+	}
+	return &entries[0], nil
+}
+
+// stopRunningTimer closes the running time entry at stopTime and returns it, or nil if none was
+// running. The overlap policy applies to the finished entry; the IDs of the conflicting or
+// trimmed entries are returned with it.
+func stopRunningTimer(tx Querier, policy OverlapPolicy, stopTime time.Time) (*pkgmodel.TimeEntry, []int, error) {
+	entry, err := queryRunningTimer(tx)
+	if err != nil || entry == nil {
+		return nil, nil, err
+	}
+
+	// A timer stopped within the second it was started still has to end after it began
+	if err := ValidateTimeSequence(entry.StartTime, stopTime); err != nil {
+		stopTime = entry.StartTime.Add(time.Second)
+	}
+	duration := pkgutil.CalculateDurationMinutes(entry.StartTime, stopTime)
+
+	conflicts, err := ResolveOverlaps(tx, policy, entry.StartTime, stopTime, entry.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = AuditedWrite(tx, AuditEntry, entry.ID, AuditUpdate, func() error {
+		_, err := tx.Exec("UPDATE time_entries SET end_time = ?, duration = ? WHERE id = ?",
+			pkgutil.FormatTimeForDB(stopTime), duration, entry.ID)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	log.Printf("UPDATE: Stopped timer for time entry ID %d - Task: %s, Category: %s, Duration: %d min, Start: %s, End: %s",
+		entry.ID, entry.Task, entry.Category, duration, entry.StartTime.Format("2006-01-02 15:04"), stopTime.Format("2006-01-02 15:04"))
+
+	entry.EndTime = &stopTime
+	entry.Duration = duration
+	return entry, conflicts, nil
+}
+
+// Timer handlers
+func GetRunningTimer(w http.ResponseWriter, r *http.Request) {
+	tx, err := pkgglobal.Db.Begin()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	entry, err := queryRunningTimer(tx)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if entry == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entry)
+}
+
+func StartTimer(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req pkgmodel.TimerStartRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := ValidateTimerStartRequest(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	policy, err := RequestOverlapPolicy(r.URL.Query().Get("overlap"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tx, err := BeginChange()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	// Validate category exists in database
+	var categoryExists bool
+	err = tx.QueryRow("SELECT EXISTS(SELECT 1 FROM categories WHERE name = ? AND deleted_at IS NULL)", req.Category).Scan(&categoryExists)
+	if err != nil {
+		http.Error(w, "Database error while validating category", http.StatusInternalServerError)
+		return
+	}
+	if !categoryExists {
+		http.Error(w, "Invalid category. Category does not exist in the system", http.StatusBadRequest)
+		return
+	}
+
+	// Starting a new timer stops the previous one
+	now := pkgutil.CurrentWallClockTime()
+	stopped, conflicts, err := stopRunningTimer(tx, policy, now)
+	if err != nil {
+		writeOverlapError(w, err)
+		return
+	}
+
+	startTime := now
+	if stopped != nil && stopped.EndTime.After(startTime) {
+		startTime = *stopped.EndTime
+	}
+
+	result, err := tx.Exec(`
+		INSERT INTO time_entries (task, description, category, start_time, end_time, duration, date)
+		VALUES (?, ?, ?, ?, NULL, 0, ?)
+	`, req.Task, req.Description, req.Category, pkgutil.FormatTimeForDB(startTime), pkgutil.GetCurrentDateForDB())
+	if err != nil {
+		log.Printf("ERROR: Failed to start timer - Task: %s, Category: %s - Error: %v", req.Task, req.Category, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	id, _ := result.LastInsertId()
+	if err := setEntryProject(tx, int(id), nil); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := AuditInsert(tx, AuditEntry, int(id)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	timer, err := getTimeEntry(tx, int(id))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(w, r); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("INSERT: Started timer as time entry ID %d - Task: %s, Category: %s, Start: %s",
+		id, req.Task, req.Category, startTime.Format("2006-01-02 15:04"))
+
+	response := pkgmodel.TimerStartResponse{
+		Timer:     timer,
+		Stopped:   stopped,
+		Warning:   overlapWarning(policy, conflicts),
+		Conflicts: conflicts,
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+func StopTimer(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	policy, err := RequestOverlapPolicy(r.URL.Query().Get("overlap"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tx, err := BeginChange()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	stopped, conflicts, err := stopRunningTimer(tx, policy, pkgutil.CurrentWallClockTime())
+	if err != nil {
+		writeOverlapError(w, err)
+		return
+	}
+	if stopped == nil {
+		http.Error(w, "No timer is running", http.StatusNotFound)
+		return
+	}
+
+	if err := tx.Commit(w, r); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(pkgmodel.TimeEntryResult{
+		TimeEntry: *stopped,
+		Warning:   overlapWarning(policy, conflicts),
+		Conflicts: conflicts,
+	})
+}
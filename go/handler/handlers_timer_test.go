@@ -0,0 +1,174 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pkgmodel "timesheet/go/model"
+	pkgutil "timesheet/go/util"
+)
+
+// runningTimer calls GetRunningTimer
+func runningTimer() *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	GetRunningTimer(w, httptest.NewRequest(http.MethodGet, "/api/timer", nil))
+	return w
+}
+
+func TestStartTimerStopsRunningTimer(t *testing.T) {
+	setupHandlerTestDB(t)
+
+	w := sendJSON(t, StartTimer, http.MethodPost, "/api/timer/start", "", pkgmodel.TimerStartRequest{Task: "Development", Category: "project work"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var first pkgmodel.TimerStartResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&first))
+	assert.Nil(t, first.Stopped)
+	assert.Nil(t, first.Timer.EndTime)
+
+	w = sendJSON(t, StartTimer, http.MethodPost, "/api/timer/start", "", pkgmodel.TimerStartRequest{Task: "Review", Category: "project support"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var second pkgmodel.TimerStartResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&second))
+	require.NotNil(t, second.Stopped)
+	assert.Equal(t, first.Timer.ID, second.Stopped.ID)
+	require.NotNil(t, second.Stopped.EndTime)
+	assert.True(t, second.Stopped.EndTime.After(first.Timer.StartTime))
+	assert.False(t, second.Timer.StartTime.Before(*second.Stopped.EndTime))
+
+	// Only the new timer is running
+	w = runningTimer()
+	require.Equal(t, http.StatusOK, w.Code)
+	var running pkgmodel.TimeEntry
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&running))
+	assert.Equal(t, second.Timer.ID, running.ID)
+	assert.Equal(t, "Review", running.Task)
+
+	assert.Equal(t, http.StatusBadRequest, sendJSON(t, StartTimer, http.MethodPost, "/api/timer/start", "", pkgmodel.TimerStartRequest{Task: "Development", Category: "meetings"}).Code)
+}
+
+func TestStopTimer(t *testing.T) {
+	setupHandlerTestDB(t)
+
+	assert.Equal(t, http.StatusNotFound, sendJSON(t, StopTimer, http.MethodPost, "/api/timer/stop", "", nil).Code)
+	assert.Equal(t, http.StatusNoContent, runningTimer().Code)
+
+	require.Equal(t, http.StatusOK, sendJSON(t, StartTimer, http.MethodPost, "/api/timer/start", "", pkgmodel.TimerStartRequest{Task: "Development", Category: "project work"}).Code)
+	w := sendJSON(t, StopTimer, http.MethodPost, "/api/timer/stop", "", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var stopped pkgmodel.TimeEntry
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&stopped))
+	require.NotNil(t, stopped.EndTime)
+	assert.True(t, stopped.EndTime.After(stopped.StartTime))
+
+	assert.Equal(t, http.StatusNoContent, runningTimer().Code)
+	assert.Equal(t, http.StatusNotFound, sendJSON(t, StopTimer, http.MethodPost, "/api/timer/stop", "", nil).Code)
+}
+
+func TestRunningTimerIsExcludedFromReportsAndExports(t *testing.T) {
+	db := setupHandlerTestDB(t)
+	insertTestEntry(t, db, "Development", "project work", "2025-11-10T09:00:00Z", "2025-11-10T10:30:00Z", 90)
+	require.Equal(t, http.StatusOK, sendJSON(t, StartTimer, http.MethodPost, "/api/timer/start", "", pkgmodel.TimerStartRequest{Task: "Review", Category: "project work"}).Code)
+
+	w, report := getSummaryReport(t, "group_by=category")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, 90, report.TotalMinutes)
+	assert.Equal(t, 1, report.EntryCount)
+
+	w = exportTimeEntries("format=json&columns=task,duration_minutes")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var rows []map[string]interface{}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&rows))
+	assert.Equal(t, []map[string]interface{}{{"task": "Development", "duration_minutes": float64(90)}}, rows)
+}
+
+func TestStopTimerAppliesOverlapPolicy(t *testing.T) {
+	db := setupHandlerTestDB(t)
+
+	w := sendJSON(t, StartTimer, http.MethodPost, "/api/timer/start", "", pkgmodel.TimerStartRequest{Task: "Development", Category: "project work"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var started pkgmodel.TimerStartResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&started))
+
+	// An entry booked meanwhile that covers the whole timer
+	insertTestEntry(t, db, "Meeting", "project support",
+		pkgutil.FormatTimeForDB(started.Timer.StartTime.Add(-time.Minute)),
+		pkgutil.FormatTimeForDB(started.Timer.StartTime.Add(time.Hour)), 61)
+
+	w = sendJSON(t, StopTimer, http.MethodPost, "/api/timer/stop?overlap=reject", "", nil)
+	assert.Equal(t, http.StatusConflict, w.Code, w.Body.String())
+	assert.Equal(t, http.StatusOK, runningTimer().Code)
+
+	w = sendJSON(t, StopTimer, http.MethodPost, "/api/timer/stop?overlap=allow", "", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var stopped pkgmodel.TimeEntryResult
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&stopped))
+	assert.NotEmpty(t, stopped.Warning)
+	assert.Len(t, stopped.Conflicts, 1)
+	assert.Equal(t, http.StatusNoContent, runningTimer().Code)
+}
+
+func TestStartTimerAppliesOverlapPolicyToStoppedTimer(t *testing.T) {
+	db := setupHandlerTestDB(t)
+
+	w := sendJSON(t, StartTimer, http.MethodPost, "/api/timer/start", "", pkgmodel.TimerStartRequest{Task: "Development", Category: "project work"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var first pkgmodel.TimerStartResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&first))
+	insertTestEntry(t, db, "Meeting", "project support",
+		pkgutil.FormatTimeForDB(first.Timer.StartTime.Add(-time.Minute)),
+		pkgutil.FormatTimeForDB(first.Timer.StartTime.Add(time.Hour)), 61)
+
+	// Rejecting the overlap refuses the switch and keeps the first timer running
+	w = sendJSON(t, StartTimer, http.MethodPost, "/api/timer/start?overlap=reject", "",
+		pkgmodel.TimerStartRequest{Task: "Review", Category: "project support"})
+	assert.Equal(t, http.StatusConflict, w.Code, w.Body.String())
+	w = runningTimer()
+	require.Equal(t, http.StatusOK, w.Code)
+	var running pkgmodel.TimeEntry
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&running))
+	assert.Equal(t, first.Timer.ID, running.ID)
+}
+
+func TestRunningTimerCountsAsOverlapUntilNow(t *testing.T) {
+	db := setupHandlerTestDB(t)
+
+	w := sendJSON(t, StartTimer, http.MethodPost, "/api/timer/start", "", pkgmodel.TimerStartRequest{Task: "Development", Category: "project work"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var started pkgmodel.TimerStartResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&started))
+	timerStart := started.Timer.StartTime
+
+	w = sendJSON(t, CreateTimeEntry, http.MethodPost, "/api/entries?overlap=reject", "", pkgmodel.TimeEntryRequest{
+		Task:      "Meeting",
+		Category:  "project support",
+		StartTime: pkgutil.FormatTimeForDB(timerStart.Add(-30 * time.Minute)),
+		EndTime:   pkgutil.FormatTimeForDB(timerStart.Add(time.Hour)),
+	})
+	assert.Equal(t, http.StatusConflict, w.Code, w.Body.String())
+
+	// Entries that end before the timer started do not conflict
+	w = sendJSON(t, CreateTimeEntry, http.MethodPost, "/api/entries?overlap=reject", "", pkgmodel.TimeEntryRequest{
+		Task:      "Meeting",
+		Category:  "project support",
+		StartTime: pkgutil.FormatTimeForDB(timerStart.Add(-time.Hour)),
+		EndTime:   pkgutil.FormatTimeForDB(timerStart.Add(-30 * time.Minute)),
+	})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	insertTestEntry(t, db, "Meeting", "project support",
+		pkgutil.FormatTimeForDB(timerStart.Add(-30*time.Minute)), pkgutil.FormatTimeForDB(timerStart.Add(time.Hour)), 90)
+	w = httptest.NewRecorder()
+	GetTimeEntryConflicts(w, httptest.NewRequest(http.MethodGet, "/api/entries/conflicts", nil))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var conflicts []pkgmodel.TimeEntryConflict
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&conflicts))
+	require.Len(t, conflicts, 1)
+	assert.Equal(t, started.Timer.ID, conflicts[0].First.ID)
+	assert.Nil(t, conflicts[0].First.EndTime)
+}
@@ -0,0 +1,321 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	pkgglobal "timesheet/go/global"
+	pkgmodel "timesheet/go/model"
+	pkgutil "timesheet/go/util"
+
+	"github.com/gorilla/mux"
+)
+
+// trashTypes maps the item types of the trash API onto the audited entity types
+var trashTypes = map[string]string{
+	"entries":    AuditEntry,
+	"tasks":      AuditTask,
+	"categories": AuditCategory,
+}
+
+// ParseTrashRetention validates the retention period given in days; 0 disables the purge
+func ParseTrashRetention(days string) (time.Duration, error) {
+	n, err := strconv.Atoi(days)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid trash retention '%s'. Expected a number of days, 0 to keep deleted items", days)
+	}
+	return time.Duration(n) * 24 * time.Hour, nil
+}
+
+// trashItemFromRequest returns the entity type and ID addressed by /api/trash/{type}/{id}
+func trashItemFromRequest(r *http.Request) (string, int, error) {
+	vars := mux.Vars(r)
+	entityType, ok := trashTypes[vars["type"]]
+	if !ok {
+		return "", 0, fmt.Errorf("invalid type '%s'. Expected entries, tasks or categories", vars["type"])
+	}
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		return "", 0, fmt.Errorf("Invalid ID")
+	}
+	return entityType, id, nil
+}
+
+// queryTrash lists the deleted rows of all tables, most recently deleted first
+func queryTrash(q Querier, retention time.Duration) ([]pkgmodel.TrashItem, error) {
+	rows, err := q.Query(`
+		SELECT 'entries', id, task, deleted_at FROM time_entries WHERE deleted_at IS NOT NULL
+		UNION ALL
+		SELECT 'tasks', id, name, deleted_at FROM tasks WHERE deleted_at IS NOT NULL
+		UNION ALL
+		SELECT 'categories', id, name, deleted_at FROM categories WHERE deleted_at IS NOT NULL
+		ORDER BY 4 DESC, 1, 2`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []pkgmodel.TrashItem{}
+	for rows.Next() {
+		var item pkgmodel.TrashItem
+		var deletedAt string
+		if err := rows.Scan(&item.Type, &item.ID, &item.Label, &deletedAt); err != nil {
+			return nil, err
+		}
+		if item.DeletedAt, err = time.Parse(time.RFC3339, deletedAt); err != nil {
+			log.Printf("Error parsing deleted_at '%s': %v", deletedAt, err)
+		}
+		if retention > 0 {
+			purgeAt := item.DeletedAt.Add(retention)
+			item.PurgeAt = &purgeAt
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+func GetTrash(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	items, err := queryTrash(pkgglobal.Db, pkgglobal.TrashRetention)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(items)
+}
+
+// trashedCategoryOf returns the name of the deleted category an entry or task depends on,
+// or an empty string if its category is not in the trash
+func trashedCategoryOf(q Querier, entityType string, id int) (string, error) {
+	query := `SELECT c.name FROM time_entries e JOIN categories c ON c.name = e.category
+		WHERE e.id = ? AND c.deleted_at IS NOT NULL`
+	if entityType == AuditTask {
+		query = `SELECT c.name FROM tasks t JOIN categories c ON c.id = t.category_id
+			WHERE t.id = ? AND c.deleted_at IS NOT NULL`
+	}
+	var name string
+	err := q.QueryRow(query, id).Scan(&name)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return name, err
+}
+
+// RestoreTrashItem moves a deleted entry, task or category out of the trash. Entries and
+// tasks whose category is still in the trash cannot be restored before the category.
+func RestoreTrashItem(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	entityType, id, err := trashItemFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tx, err := BeginChange()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	if entityType != AuditCategory {
+		category, err := trashedCategoryOf(tx, entityType, id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if category != "" {
+			http.Error(w, fmt.Sprintf("Category '%s' is in the trash. Restore it first", category), http.StatusConflict)
+			return
+		}
+	}
+
+	ids, err := matchingIDs(tx, entityType, "id = ? AND deleted_at IS NOT NULL", id)
+	if err == nil && len(ids) > 0 {
+		err = AuditedWrite(tx, entityType, id, AuditRestore, func() error {
+			_, err := tx.Exec("UPDATE "+auditTables[entityType]+" SET deleted_at = NULL WHERE id = ?", id)
+			return err
+		})
+	}
+	if err != nil {
+		log.Printf("ERROR: Failed to restore %s ID %d - Error: %v", entityType, id, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if len(ids) == 0 {
+		http.Error(w, "Item not found in the trash", http.StatusNotFound)
+		return
+	}
+
+	if err := tx.Commit(w, r); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("UPDATE: Restored %s ID %d from the trash", entityType, id)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// purgeRows permanently deletes the trashed rows of one entity type matching the condition.
+// Tasks of purged categories are unlinked since foreign keys are not enforced.
+func purgeRows(q Querier, entityType, condition string, args ...interface{}) (int, error) {
+	ids, err := matchingIDs(q, entityType, "deleted_at IS NOT NULL AND "+condition, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, id := range ids {
+		if entityType == AuditCategory {
+			taskIDs, err := matchingIDs(q, AuditTask, "category_id = ?", id)
+			if err != nil {
+				return 0, err
+			}
+			for _, taskID := range taskIDs {
+				err := AuditedWrite(q, AuditTask, taskID, AuditUpdate, func() error {
+					_, err := q.Exec("UPDATE tasks SET category_id = NULL WHERE id = ?", taskID)
+					return err
+				})
+				if err != nil {
+					return 0, err
+				}
+			}
+		}
+
+		err := AuditedWrite(q, entityType, id, AuditPurge, func() error {
+			if entityType == AuditEntry {
+				if err := setEntryTags(q, id, nil); err != nil {
+					return err
+				}
+			}
+			_, err := q.Exec("DELETE FROM "+auditTables[entityType]+" WHERE id = ?", id)
+			return err
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(ids), nil
+}
+
+// PurgeTrash permanently deletes all rows that were moved to the trash before the given time
+// and returns their number
+func PurgeTrash(q Querier, before time.Time) (int, error) {
+	total := 0
+	for _, entityType := range []string{AuditEntry, AuditTask, AuditCategory} {
+		n, err := purgeRows(q, entityType, "datetime(deleted_at) < datetime(?)", pkgutil.FormatTimeForDB(before))
+		if err != nil {
+			return total, fmt.Errorf("failed to purge %s rows: %w", entityType, err)
+		}
+		total += n
+	}
+	return total, nil
+}
+
+// PurgeTrashItem permanently deletes one item of the trash
+func PurgeTrashItem(w http.ResponseWriter, r *http.Request) {
+	entityType, id, err := trashItemFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tx, err := BeginChange()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	n, err := purgeRows(tx, entityType, "id = ?", id)
+	if err != nil {
+		log.Printf("ERROR: Failed to purge %s ID %d - Error: %v", entityType, id, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if n == 0 {
+		http.Error(w, "Item not found in the trash", http.StatusNotFound)
+		return
+	}
+
+	if err := tx.Commit(w, r); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("DELETE: Purged %s ID %d from the trash", entityType, id)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// EmptyTrash permanently deletes everything in the trash
+func EmptyTrash(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	tx, err := BeginChange()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	// Rows deleted within the current second are included as well
+	n, err := PurgeTrash(tx, pkgutil.CurrentWallClockTime().Add(time.Second))
+	if err != nil {
+		log.Printf("ERROR: Failed to empty the trash - Error: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(w, r); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("DELETE: Emptied the trash, purged %d items", n)
+
+	json.NewEncoder(w).Encode(map[string]int{"purged": n})
+}
+
+// RunTrashRetention purges items older than the retention period at startup and then
+// once per interval. It does nothing if retention is 0.
+func RunTrashRetention(db *sql.DB, retention, interval time.Duration) {
+	if retention <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		n, err := purgeExpiredTrash(db, retention)
+		if err != nil {
+			log.Printf("ERROR: Trash retention failed - Error: %v", err)
+		} else if n > 0 {
+			log.Printf("DELETE: Purged %d items older than %s from the trash", n, retention)
+		}
+		<-ticker.C
+	}
+}
+
+// purgeExpiredTrash purges the items older than the retention period in one transaction
+func purgeExpiredTrash(db *sql.DB, retention time.Duration) (int, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	n, err := PurgeTrash(tx, pkgutil.CurrentWallClockTime().Add(-retention))
+	if err != nil {
+		return 0, err
+	}
+	return n, tx.Commit()
+}
@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pkgglobal "timesheet/go/global"
+	pkgmodel "timesheet/go/model"
+)
+
+// trashRequest calls a trash handler for the given item
+func trashRequest(handler http.HandlerFunc, method, itemType, id string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/api/trash/"+itemType+"/"+id, nil)
+	req = mux.SetURLVars(req, map[string]string{"type": itemType, "id": id})
+	w := httptest.NewRecorder()
+	handler(w, req)
+	return w
+}
+
+// deleteTimeEntry calls DeleteTimeEntry for the given ID
+func deleteTimeEntry(id string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodDelete, "/api/entries/"+id, nil)
+	req = mux.SetURLVars(req, map[string]string{"id": id})
+	w := httptest.NewRecorder()
+	DeleteTimeEntry(w, req)
+	return w
+}
+
+// listEntries calls GetTimeEntries with the given query string
+func listEntries(t *testing.T, query string) pkgmodel.TimeEntryPage {
+	w := httptest.NewRecorder()
+	GetTimeEntries(w, httptest.NewRequest(http.MethodGet, "/api/entries"+query, nil))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var page pkgmodel.TimeEntryPage
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&page))
+	return page
+}
+
+// getTrash calls GetTrash and decodes the listed items
+func getTrash(t *testing.T) []pkgmodel.TrashItem {
+	w := httptest.NewRecorder()
+	GetTrash(w, httptest.NewRequest(http.MethodGet, "/api/trash", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var items []pkgmodel.TrashItem
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&items))
+	return items
+}
+
+func TestDeletedEntryMovesToTrashAndIsRestored(t *testing.T) {
+	db := setupHandlerTestDB(t)
+	insertTestEntry(t, db, "Development", "project work", "2025-11-10T09:00:00Z", "2025-11-10T10:30:00Z", 90)
+
+	require.Equal(t, http.StatusNoContent, deleteTimeEntry("1").Code)
+	assert.Equal(t, http.StatusNotFound, deleteTimeEntry("1").Code)
+
+	entries := listEntries(t, "")
+	assert.Empty(t, entries.Entries)
+
+	items := getTrash(t)
+	require.Len(t, items, 1)
+	assert.Equal(t, "entries", items[0].Type)
+	assert.Equal(t, "Development", items[0].Label)
+	require.NotNil(t, items[0].PurgeAt)
+	assert.Equal(t, pkgglobal.TrashRetention, items[0].PurgeAt.Sub(items[0].DeletedAt))
+
+	require.Equal(t, http.StatusNoContent, trashRequest(RestoreTrashItem, http.MethodPost, "entries", "1").Code)
+	assert.Equal(t, http.StatusNotFound, trashRequest(RestoreTrashItem, http.MethodPost, "entries", "1").Code)
+	assert.Len(t, listEntries(t, "").Entries, 1)
+	assert.Empty(t, getTrash(t))
+}
+
+func TestRestoreEntryOfTrashedCategoryIsRejected(t *testing.T) {
+	db := setupHandlerTestDB(t)
+	insertTestEntry(t, db, "Support", "project support", "2025-11-10T09:00:00Z", "2025-11-10T09:30:00Z", 30)
+
+	require.Equal(t, http.StatusNoContent, deleteTimeEntry("1").Code)
+	// The API refuses to trash a category its trashed entries still use, but databases
+	// from before that check can hold such categories
+	require.Equal(t, http.StatusConflict, deleteCategory("2", "").Code)
+	_, err := db.Exec("UPDATE categories SET deleted_at = '2025-11-20T12:00:00Z' WHERE id = 2")
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusConflict, trashRequest(RestoreTrashItem, http.MethodPost, "entries", "1").Code)
+	require.Equal(t, http.StatusNoContent, trashRequest(RestoreTrashItem, http.MethodPost, "categories", "2").Code)
+	assert.Equal(t, http.StatusNoContent, trashRequest(RestoreTrashItem, http.MethodPost, "entries", "1").Code)
+}
+
+func TestTrashRejectsUnknownType(t *testing.T) {
+	setupHandlerTestDB(t)
+
+	assert.Equal(t, http.StatusBadRequest, trashRequest(RestoreTrashItem, http.MethodPost, "users", "1").Code)
+	assert.Equal(t, http.StatusBadRequest, trashRequest(PurgeTrashItem, http.MethodDelete, "entries", "x").Code)
+}
+
+func TestPurgeTrashItem(t *testing.T) {
+	db := setupHandlerTestDB(t)
+	insertTestEntry(t, db, "Development", "project work", "2025-11-10T09:00:00Z", "2025-11-10T10:30:00Z", 90)
+
+	// Only items in the trash can be purged
+	assert.Equal(t, http.StatusNotFound, trashRequest(PurgeTrashItem, http.MethodDelete, "entries", "1").Code)
+
+	require.Equal(t, http.StatusNoContent, deleteTimeEntry("1").Code)
+	require.Equal(t, http.StatusNoContent, trashRequest(PurgeTrashItem, http.MethodDelete, "entries", "1").Code)
+
+	var count int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM time_entries").Scan(&count))
+	assert.Zero(t, count)
+}
+
+func TestPurgeTrashRespectsRetention(t *testing.T) {
+	db := setupHandlerTestDB(t)
+	_, err := db.Exec(`
+		INSERT INTO tasks (name, category_id, deleted_at) VALUES ('Review', 2, '2025-11-01T12:00:00Z');
+		UPDATE categories SET deleted_at = '2025-10-01T12:00:00Z' WHERE id = 2;`)
+	require.NoError(t, err)
+	insertTestEntry(t, db, "Development", "project work", "2025-11-10T09:00:00Z", "2025-11-10T10:30:00Z", 90)
+	_, err = db.Exec("UPDATE time_entries SET deleted_at = '2025-11-20T12:00:00Z'")
+	require.NoError(t, err)
+
+	n, err := PurgeTrash(db, time.Date(2025, 11, 10, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	var entries, tasks, categories int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM time_entries").Scan(&entries))
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM tasks").Scan(&tasks))
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM categories").Scan(&categories))
+	assert.Equal(t, 1, entries)
+	assert.Zero(t, tasks)
+	assert.Equal(t, 2, categories)
+}
+
+func TestParseTrashRetention(t *testing.T) {
+	retention, err := ParseTrashRetention("7")
+	require.NoError(t, err)
+	assert.Equal(t, 7*24*time.Hour, retention)
+
+	retention, err = ParseTrashRetention("0")
+	require.NoError(t, err)
+	assert.Zero(t, retention)
+
+	_, err = ParseTrashRetention("-1")
+	assert.Error(t, err)
+	_, err = ParseTrashRetention("week")
+	assert.Error(t, err)
+}
@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	pkgglobal "timesheet/go/global"
+	pkgmodel "timesheet/go/model"
+)
+
+// Undo reverts the most recent change of the session
+func Undo(w http.ResponseWriter, r *http.Request) {
+	moveChange(w, r, false)
+}
+
+// Redo reapplies the most recently undone change of the session
+func Redo(w http.ResponseWriter, r *http.Request) {
+	moveChange(w, r, true)
+}
+
+// moveChange reverts the top change of the undo stack and moves it to the redo stack, or
+// the other way round for redo. A change that can no longer be reverted because its rows
+// were changed since is dropped from the stack.
+func moveChange(w http.ResponseWriter, r *http.Request, redo bool) {
+	w.Header().Set("Content-Type", "application/json")
+
+	operation := AuditUndo
+	if redo {
+		operation = AuditRedo
+	}
+
+	undoSessions.mu.Lock()
+	defer undoSessions.mu.Unlock()
+
+	stacks := undoSessions.get(requestSessionID(r))
+	from, to := &stacks.undo, &stacks.redo
+	if redo {
+		from, to = &stacks.redo, &stacks.undo
+	}
+	if len(*from) == 0 {
+		http.Error(w, "Nothing to "+operation, http.StatusConflict)
+		return
+	}
+	change := (*from)[len(*from)-1]
+
+	tx, err := pkgglobal.Db.Begin()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	records, err := loadUndoRecords(tx, change)
+	if err == nil {
+		err = revertChange(tx, records, redo)
+	}
+	if conflict, ok := err.(*UndoConflictError); ok {
+		*from = (*from)[:len(*from)-1]
+		log.Printf("WARNING: Cannot %s change, %v", operation, conflict)
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error":       "Cannot " + operation + " the last change because " + conflict.Error(),
+			"entity_type": conflict.EntityType,
+			"entity_id":   conflict.EntityID,
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR: Failed to %s change - Error: %v", operation, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	*from = (*from)[:len(*from)-1]
+	*to = pushBounded(*to, change)
+
+	result := pkgmodel.UndoResult{
+		Operation:     operation,
+		Changes:       make([]pkgmodel.AuditRecord, 0, len(records)),
+		UndoAvailable: len(stacks.undo),
+		RedoAvailable: len(stacks.redo),
+	}
+	for _, record := range records {
+		log.Printf("UPDATE: %s %s of %s ID %d", operation, record.record.Operation, record.record.EntityType, record.record.EntityID)
+		result.Changes = append(result.Changes, record.record)
+	}
+
+	json.NewEncoder(w).Encode(result)
+}
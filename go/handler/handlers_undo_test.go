@@ -0,0 +1,225 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pkgmodel "timesheet/go/model"
+)
+
+// setupUndoTest prepares the test database and clears the undo stacks of all sessions
+func setupUndoTest(t *testing.T) *sql.DB {
+	db := setupHandlerTestDB(t)
+	undoSessions.reset()
+	t.Cleanup(undoSessions.reset)
+	return db
+}
+
+// callUndo calls Undo or Redo for the given session
+func callUndo(handler http.HandlerFunc, session string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/undo", nil)
+	req.Header.Set("X-Session-ID", session)
+	w := httptest.NewRecorder()
+	handler(w, req)
+	return w
+}
+
+// currentEntries returns the entries shown by the listing
+func currentEntries(t *testing.T) []pkgmodel.TimeEntry {
+	return listEntries(t, "").Entries
+}
+
+func TestUndoAndRedoTimeEntryChanges(t *testing.T) {
+	setupUndoTest(t)
+	entry := pkgmodel.TimeEntryRequest{Task: "Development", Category: "project work",
+		StartTime: "2025-11-10T09:00:00Z", EndTime: "2025-11-10T10:00:00Z"}
+	require.Equal(t, http.StatusOK, sendJSON(t, CreateTimeEntry, http.MethodPost, "/api/entries", "", entry).Code)
+	entry.Category = "project support"
+	require.Equal(t, http.StatusOK, sendJSON(t, UpdateTimeEntry, http.MethodPut, "/api/entries/1", "1", entry).Code)
+
+	w := callUndo(Undo, testSession)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var result pkgmodel.UndoResult
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&result))
+	assert.Equal(t, "undo", result.Operation)
+	require.Len(t, result.Changes, 1)
+	assert.Equal(t, "update", result.Changes[0].Operation)
+	assert.Equal(t, 1, result.UndoAvailable)
+	assert.Equal(t, 1, result.RedoAvailable)
+
+	entries := currentEntries(t)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "project work", entries[0].Category)
+
+	// Undoing the creation removes the entry
+	require.Equal(t, http.StatusOK, callUndo(Undo, testSession).Code)
+	assert.Empty(t, currentEntries(t))
+	assert.Equal(t, http.StatusConflict, callUndo(Undo, testSession).Code)
+
+	require.Equal(t, http.StatusOK, callUndo(Redo, testSession).Code)
+	require.Equal(t, http.StatusOK, callUndo(Redo, testSession).Code)
+	entries = currentEntries(t)
+	require.Len(t, entries, 1)
+	assert.Equal(t, 1, entries[0].ID)
+	assert.Equal(t, "project support", entries[0].Category)
+	assert.Equal(t, http.StatusConflict, callUndo(Redo, testSession).Code)
+}
+
+func TestUndoIsPerSession(t *testing.T) {
+	setupUndoTest(t)
+	entry := pkgmodel.TimeEntryRequest{Task: "Development", Category: "project work",
+		StartTime: "2025-11-10T09:00:00Z", EndTime: "2025-11-10T10:00:00Z"}
+	require.Equal(t, http.StatusOK, sendJSON(t, CreateTimeEntry, http.MethodPost, "/api/entries", "", entry).Code)
+
+	assert.Equal(t, http.StatusConflict, callUndo(Undo, "other-session").Code)
+	assert.Equal(t, http.StatusOK, callUndo(Undo, testSession).Code)
+}
+
+func TestUndoRejectsRowsChangedSince(t *testing.T) {
+	db := setupUndoTest(t)
+	entry := pkgmodel.TimeEntryRequest{Task: "Development", Category: "project work",
+		StartTime: "2025-11-10T09:00:00Z", EndTime: "2025-11-10T10:00:00Z"}
+	require.Equal(t, http.StatusOK, sendJSON(t, CreateTimeEntry, http.MethodPost, "/api/entries", "", entry).Code)
+	_, err := db.Exec("UPDATE time_entries SET description = 'changed elsewhere'")
+	require.NoError(t, err)
+
+	w := callUndo(Undo, testSession)
+	require.Equal(t, http.StatusConflict, w.Code)
+	var body map[string]interface{}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+	assert.Equal(t, "entry", body["entity_type"])
+
+	// The change that can no longer be undone is dropped
+	assert.Len(t, currentEntries(t), 1)
+	assert.Equal(t, http.StatusConflict, callUndo(Undo, testSession).Code)
+}
+
+func TestUndoCategoryDeletionWithReassignment(t *testing.T) {
+	db := setupUndoTest(t)
+	insertTestEntry(t, db, "Development", "project work", "2025-11-10T09:00:00Z", "2025-11-10T10:30:00Z", 90)
+	_, err := db.Exec("UPDATE time_entries SET category_id = 1")
+	require.NoError(t, err)
+
+	require.Equal(t, http.StatusNoContent, deleteCategory("1", "?reassign_to=2").Code)
+
+	w := callUndo(Undo, testSession)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var category string
+	var categoryID int
+	require.NoError(t, db.QueryRow("SELECT category, category_id FROM time_entries").Scan(&category, &categoryID))
+	assert.Equal(t, "project work", category)
+	assert.Equal(t, 1, categoryID)
+	var deleted int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM categories WHERE deleted_at IS NOT NULL").Scan(&deleted))
+	assert.Zero(t, deleted)
+}
+
+func TestUndoAcrossCategoryRename(t *testing.T) {
+	db := setupUndoTest(t)
+	insertTestEntry(t, db, "Development", "project work", "2025-11-10T09:00:00Z", "2025-11-10T10:00:00Z", 60)
+	_, err := db.Exec("UPDATE time_entries SET category_id = 1")
+	require.NoError(t, err)
+
+	entry := pkgmodel.TimeEntryRequest{Task: "Development", Description: "feature", Category: "project work",
+		StartTime: "2025-11-10T09:00:00Z", EndTime: "2025-11-10T10:00:00Z"}
+	require.Equal(t, http.StatusOK, sendJSON(t, UpdateTimeEntry, http.MethodPut, "/api/entries/1", "1", entry).Code)
+	w := sendJSON(t, UpdateCategory, http.MethodPut, "/api/categories/1", "1", pkgmodel.CategoryRequest{Name: "client work"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	// The renamed entry is part of the change and shows up in its history
+	entries := currentEntries(t)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "client work", entries[0].Category)
+	var history []pkgmodel.AuditRecord
+	require.NoError(t, json.NewDecoder(getHistory("1").Body).Decode(&history))
+	require.Len(t, history, 2)
+	assert.Contains(t, string(history[1].After), `"category":"client work"`)
+
+	// Undoing the rename restores the entry, so the edit before it can be undone as well
+	require.Equal(t, http.StatusOK, callUndo(Undo, testSession).Code)
+	w = callUndo(Undo, testSession)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	entries = currentEntries(t)
+	assert.Equal(t, "project work", entries[0].Category)
+	assert.Empty(t, entries[0].Description)
+
+	require.Equal(t, http.StatusOK, callUndo(Redo, testSession).Code)
+	w = callUndo(Redo, testSession)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var category string
+	var categoryID int
+	require.NoError(t, db.QueryRow("SELECT category, category_id FROM time_entries WHERE id = 1").Scan(&category, &categoryID))
+	assert.Equal(t, "client work", category)
+	assert.Equal(t, 1, categoryID)
+}
+
+func TestNewAndRenamedTasksUpdateEntriesInOneStep(t *testing.T) {
+	db := setupUndoTest(t)
+	insertTestEntry(t, db, "Review", "project work", "2025-11-10T09:00:00Z", "2025-11-10T10:00:00Z", 60)
+
+	w := sendJSON(t, CreateTask, http.MethodPost, "/api/tasks", "", pkgmodel.TaskRequest{Name: "Review", CategoryID: 1})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = sendJSON(t, PatchTask, http.MethodPatch, "/api/tasks/1", "1", `{"name": "Code review"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var task string
+	var taskID int
+	require.NoError(t, db.QueryRow("SELECT task, task_id FROM time_entries WHERE id = 1").Scan(&task, &taskID))
+	assert.Equal(t, "Code review", task)
+	assert.Equal(t, 1, taskID)
+
+	rows, err := db.Query("SELECT entity_type, operation FROM audit_log ORDER BY id")
+	require.NoError(t, err)
+	defer rows.Close()
+	var changes []string
+	for rows.Next() {
+		var entityType, operation string
+		require.NoError(t, rows.Scan(&entityType, &operation))
+		changes = append(changes, entityType+" "+operation)
+	}
+	assert.Equal(t, []string{"task create", "entry update", "task update", "entry update"}, changes)
+
+	require.Equal(t, http.StatusOK, callUndo(Undo, testSession).Code)
+	require.Equal(t, http.StatusOK, callUndo(Undo, testSession).Code)
+	var linked *int
+	require.NoError(t, db.QueryRow("SELECT task, task_id FROM time_entries WHERE id = 1").Scan(&task, &linked))
+	assert.Equal(t, "Review", task)
+	assert.Nil(t, linked)
+}
+
+func TestPushBoundedKeepsNewestChanges(t *testing.T) {
+	var stack [][]int64
+	for i := 1; i <= MaxUndoSteps+5; i++ {
+		stack = pushBounded(stack, []int64{int64(i)})
+	}
+	require.Len(t, stack, MaxUndoSteps)
+	assert.Equal(t, []int64{6}, stack[0])
+	assert.Equal(t, []int64{MaxUndoSteps + 5}, stack[MaxUndoSteps-1])
+}
+
+func TestChangesSetSessionCookie(t *testing.T) {
+	setupUndoTest(t)
+	body := `{"name": "meetings"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/categories", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	CreateCategory(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	cookies := w.Result().Cookies()
+	require.Len(t, cookies, 1)
+	assert.Equal(t, SessionCookie, cookies[0].Name)
+
+	undo := httptest.NewRequest(http.MethodPost, "/api/undo", nil)
+	undo.AddCookie(cookies[0])
+	w = httptest.NewRecorder()
+	Undo(w, undo)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
@@ -0,0 +1,27 @@
+package handler
+
+// Entries store the names of their category and task next to the IDs they are linked by.
+// Renames and new categories and tasks are propagated to the entries here rather than by
+// triggers, so every changed entry is recorded in the audit log of the same change.
+
+// SyncEntryLinks writes the name of a category or task to the entries linked to it and links
+// the unlinked entries that already use that name. column is the name column of time_entries,
+// "category" or "task"; the ID is kept in the column of the same name with an _id suffix.
+func SyncEntryLinks(q Querier, column string, id int, name string) error {
+	idColumn := column + "_id"
+	entryIDs, err := matchingIDs(q, AuditEntry,
+		"("+idColumn+" = ? AND "+column+" != ?) OR ("+idColumn+" IS NULL AND "+column+" = ?)", id, name, name)
+	if err != nil {
+		return err
+	}
+	for _, entryID := range entryIDs {
+		err := AuditedWrite(q, AuditEntry, entryID, AuditUpdate, func() error {
+			_, err := q.Exec("UPDATE time_entries SET "+column+" = ?, "+idColumn+" = ? WHERE id = ?", name, id, entryID)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
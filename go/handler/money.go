@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+)
+
+// MoneyPlaces is the number of decimal places of billed quantities and amounts
+const MoneyPlaces = 2
+
+// decimalPattern accepts non-negative decimals with up to four decimal places
+var decimalPattern = regexp.MustCompile(`^\d{1,9}(\.\d{1,4})?$`)
+
+// ParseDecimal parses a non-negative decimal such as 95.50 without loss of precision
+func ParseDecimal(value string) (*big.Rat, error) {
+	r, ok := new(big.Rat).SetString(value)
+	if !ok || !decimalPattern.MatchString(value) {
+		return nil, fmt.Errorf("invalid decimal '%s'. Expected a non-negative number with up to 4 decimal places", value)
+	}
+	return r, nil
+}
+
+// RoundDecimal rounds to the given number of decimal places, halves away from zero
+func RoundDecimal(r *big.Rat, places int) *big.Rat {
+	rounded, _ := new(big.Rat).SetString(r.FloatString(places))
+	return rounded
+}
+
+// hoursOf converts minutes into exact hours
+func hoursOf(minutes int) *big.Rat {
+	return big.NewRat(int64(minutes), 60)
+}
@@ -0,0 +1,29 @@
+package handler
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDecimal(t *testing.T) {
+	r, err := ParseDecimal("95.5")
+	require.NoError(t, err)
+	assert.Equal(t, big.NewRat(191, 2), r)
+
+	for _, value := range []string{"", "-1", "1e3", "1/3", "0.12345", " 5", "1,50"} {
+		_, err := ParseDecimal(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestRoundDecimal(t *testing.T) {
+	// 80 minutes are 1.333... hours, 0.125 rounds half away from zero
+	assert.Equal(t, "1.33", RoundDecimal(hoursOf(80), MoneyPlaces).FloatString(MoneyPlaces))
+	assert.Equal(t, "0.13", RoundDecimal(big.NewRat(1, 8), MoneyPlaces).FloatString(MoneyPlaces))
+	// 0.1 + 0.2 stays exact
+	sum := new(big.Rat).Add(big.NewRat(1, 10), big.NewRat(2, 10))
+	assert.Equal(t, "0.30", sum.FloatString(MoneyPlaces))
+}
@@ -0,0 +1,180 @@
+package handler
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	pkgglobal "timesheet/go/global"
+	pkgmodel "timesheet/go/model"
+	pkgutil "timesheet/go/util"
+)
+
+// OverlapPolicy decides what happens when a time entry overlaps existing entries
+type OverlapPolicy string
+
+const (
+	// OverlapReject refuses the write and reports the conflicting entries
+	OverlapReject OverlapPolicy = "reject"
+	// OverlapAllow stores the entry and reports the conflicts as a warning
+	OverlapAllow OverlapPolicy = "allow"
+	// OverlapTrim shortens the neighbouring entries so they no longer overlap
+	OverlapTrim OverlapPolicy = "trim"
+)
+
+// ParseOverlapPolicy validates an overlap policy name
+func ParseOverlapPolicy(value string) (OverlapPolicy, error) {
+	switch policy := OverlapPolicy(value); policy {
+	case OverlapReject, OverlapAllow, OverlapTrim:
+		return policy, nil
+	}
+	return "", fmt.Errorf("invalid overlap policy '%s'. Expected reject, allow or trim", value)
+}
+
+// RequestOverlapPolicy returns the policy given by the `overlap` query parameter,
+// falling back to the configured default
+func RequestOverlapPolicy(value string) (OverlapPolicy, error) {
+	if value == "" {
+		value = pkgglobal.OverlapPolicy
+	}
+	return ParseOverlapPolicy(value)
+}
+
+// OverlapError is returned when a time entry conflicts with existing entries
+type OverlapError struct {
+	ConflictIDs []int
+}
+
+func (e *OverlapError) Error() string {
+	return fmt.Sprintf("time entry overlaps existing entries %v", e.ConflictIDs)
+}
+
+// Querier is satisfied by both *sql.DB and *sql.Tx
+type Querier interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// FindOverlappingEntries returns all entries overlapping [start, end), except excludeID.
+// A running timer counts as ending at the current wall-clock time.
+func FindOverlappingEntries(q Querier, start, end time.Time, excludeID int) ([]pkgmodel.TimeEntry, error) {
+	rows, err := q.Query(`
+		SELECT `+timeEntryColumns+`
+		FROM time_entries
+		WHERE id != ? AND deleted_at IS NULL
+			AND datetime(start_time) < datetime(?) AND datetime(COALESCE(end_time, ?)) > datetime(?)
+		ORDER BY start_time, id
+	`, excludeID, pkgutil.FormatTimeForDB(end), pkgutil.FormatTimeForDB(pkgutil.CurrentWallClockTime()), pkgutil.FormatTimeForDB(start))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanTimeEntries(rows)
+}
+
+// PlanOverlapTrims computes how the neighbours have to be shortened to make room for [start, end).
+// Neighbours that would vanish or need splitting cannot be trimmed and are returned as unresolved.
+// A running timer is only moved to start at end, so it keeps running; trimming its end would stop it.
+func PlanOverlapTrims(start, end time.Time, neighbours []pkgmodel.TimeEntry) (trimmed []pkgmodel.TimeEntry, unresolved []int) {
+	now := pkgutil.CurrentWallClockTime()
+	for _, n := range neighbours {
+		if n.EndTime == nil {
+			if n.StartTime.Before(start) || end.After(now) {
+				unresolved = append(unresolved, n.ID)
+				continue
+			}
+			n.StartTime = end
+			trimmed = append(trimmed, n)
+			continue
+		}
+
+		nStart, nEnd := n.StartTime, *n.EndTime
+		switch {
+		case nStart.Before(start) && nEnd.After(end):
+			// The neighbour encloses the new entry; trimming would have to split it
+			unresolved = append(unresolved, n.ID)
+			continue
+		case nStart.Before(start):
+			nEnd = start
+		case nEnd.After(end):
+			nStart = end
+		default:
+			// The neighbour lies completely inside the new entry
+			unresolved = append(unresolved, n.ID)
+			continue
+		}
+		n.StartTime = nStart
+		n.EndTime = &nEnd
+		n.Duration = pkgutil.CalculateDurationMinutes(nStart, nEnd)
+		trimmed = append(trimmed, n)
+	}
+	return trimmed, unresolved
+}
+
+// ResolveOverlaps applies the policy for a time entry spanning [start, end).
+// It returns the IDs of the conflicting (allow) or trimmed (trim) entries, or an
+// *OverlapError if the entry must not be written.
+func ResolveOverlaps(q Querier, policy OverlapPolicy, start, end time.Time, excludeID int) ([]int, error) {
+	neighbours, err := FindOverlappingEntries(q, start, end, excludeID)
+	if err != nil || len(neighbours) == 0 {
+		return nil, err
+	}
+
+	var ids []int
+	for _, n := range neighbours {
+		ids = append(ids, n.ID)
+	}
+
+	switch policy {
+	case OverlapAllow:
+		return ids, nil
+	case OverlapTrim:
+		trimmed, unresolved := PlanOverlapTrims(start, end, neighbours)
+		if len(unresolved) > 0 {
+			return nil, &OverlapError{ConflictIDs: unresolved}
+		}
+		for _, n := range trimmed {
+			if n.EndTime == nil {
+				err := AuditedWrite(q, AuditEntry, n.ID, AuditUpdate, func() error {
+					_, err := q.Exec("UPDATE time_entries SET start_time = ? WHERE id = ?",
+						pkgutil.FormatTimeForDB(n.StartTime), n.ID)
+					return err
+				})
+				if err != nil {
+					return nil, err
+				}
+				log.Printf("UPDATE: Moved start of running timer ID %d past overlapping entry - Task: %s, Start: %s",
+					n.ID, n.Task, n.StartTime.Format("2006-01-02 15:04"))
+				continue
+			}
+
+			err := AuditedWrite(q, AuditEntry, n.ID, AuditUpdate, func() error {
+				_, err := q.Exec("UPDATE time_entries SET start_time = ?, end_time = ?, duration = ? WHERE id = ?",
+					pkgutil.FormatTimeForDB(n.StartTime), pkgutil.FormatTimeForDB(*n.EndTime), n.Duration, n.ID)
+				return err
+			})
+			if err != nil {
+				return nil, err
+			}
+			log.Printf("UPDATE: Trimmed overlapping time entry ID %d - Task: %s, Duration: %d min, Start: %s, End: %s",
+				n.ID, n.Task, n.Duration, n.StartTime.Format("2006-01-02 15:04"), n.EndTime.Format("2006-01-02 15:04"))
+		}
+		return ids, nil
+	default:
+		return nil, &OverlapError{ConflictIDs: ids}
+	}
+}
+
+// overlapWarning describes the outcome of ResolveOverlaps for the API response
+func overlapWarning(policy OverlapPolicy, ids []int) string {
+	if len(ids) == 0 {
+		return ""
+	}
+	if policy == OverlapTrim {
+		return fmt.Sprintf("Trimmed %d overlapping time entries", len(ids))
+	}
+	return fmt.Sprintf("Time entry overlaps %d existing entries", len(ids))
+}
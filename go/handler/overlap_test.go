@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pkgmodel "timesheet/go/model"
+)
+
+func testEntry(id int, start, end string) pkgmodel.TimeEntry {
+	startTime, _ := time.Parse(time.RFC3339, start)
+	endTime, _ := time.Parse(time.RFC3339, end)
+	return pkgmodel.TimeEntry{ID: id, StartTime: startTime, EndTime: &endTime}
+}
+
+func TestParseOverlapPolicy(t *testing.T) {
+	for _, value := range []string{"reject", "allow", "trim"} {
+		policy, err := ParseOverlapPolicy(value)
+		require.NoError(t, err)
+		assert.Equal(t, OverlapPolicy(value), policy)
+	}
+
+	_, err := ParseOverlapPolicy("merge")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid overlap policy 'merge'")
+}
+
+func TestPlanOverlapTrims(t *testing.T) {
+	start, _ := time.Parse(time.RFC3339, "2025-11-09T10:00:00Z")
+	end, _ := time.Parse(time.RFC3339, "2025-11-09T11:00:00Z")
+
+	neighbours := []pkgmodel.TimeEntry{
+		testEntry(1, "2025-11-09T09:00:00Z", "2025-11-09T10:30:00Z"), // overlaps the start
+		testEntry(2, "2025-11-09T10:45:00Z", "2025-11-09T12:00:00Z"), // overlaps the end
+		testEntry(3, "2025-11-09T10:15:00Z", "2025-11-09T10:30:00Z"), // inside
+		testEntry(4, "2025-11-09T08:00:00Z", "2025-11-09T13:00:00Z"), // encloses
+	}
+
+	trimmed, unresolved := PlanOverlapTrims(start, end, neighbours)
+
+	require.Len(t, trimmed, 2)
+	assert.Equal(t, 1, trimmed[0].ID)
+	assert.Equal(t, start, *trimmed[0].EndTime)
+	assert.Equal(t, 60, trimmed[0].Duration)
+	assert.Equal(t, 2, trimmed[1].ID)
+	assert.Equal(t, end, trimmed[1].StartTime)
+	assert.Equal(t, 60, trimmed[1].Duration)
+	assert.Equal(t, []int{3, 4}, unresolved)
+
+	// The original neighbours are left untouched
+	assert.Equal(t, 90, int(neighbours[0].EndTime.Sub(neighbours[0].StartTime).Minutes()))
+}
+
+func TestPlanOverlapTrimsKeepsRunningTimerRunning(t *testing.T) {
+	start, _ := time.Parse(time.RFC3339, "2025-11-09T10:00:00Z")
+	end, _ := time.Parse(time.RFC3339, "2025-11-09T11:00:00Z")
+	startedAt := func(id int, start string) pkgmodel.TimeEntry {
+		startTime, _ := time.Parse(time.RFC3339, start)
+		return pkgmodel.TimeEntry{ID: id, StartTime: startTime}
+	}
+
+	trimmed, unresolved := PlanOverlapTrims(start, end, []pkgmodel.TimeEntry{
+		startedAt(1, "2025-11-09T10:30:00Z"), // started inside, moves to the end
+		startedAt(2, "2025-11-09T09:00:00Z"), // started before, trimming would stop it
+	})
+
+	require.Len(t, trimmed, 1)
+	assert.Equal(t, 1, trimmed[0].ID)
+	assert.Equal(t, end, trimmed[0].StartTime)
+	assert.Nil(t, trimmed[0].EndTime)
+	assert.Equal(t, []int{2}, unresolved)
+}
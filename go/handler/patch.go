@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// decodeMergePatch reads a JSON merge patch (RFC 7396) that may only set the given fields.
+// Unknown fields are rejected so that a typo cannot silently turn into a no-op.
+func decodeMergePatch(body io.Reader, fields ...string) (map[string]json.RawMessage, error) {
+	var patch map[string]json.RawMessage
+	if err := json.NewDecoder(body).Decode(&patch); err != nil {
+		return nil, err
+	}
+	if patch == nil {
+		return nil, fmt.Errorf("expected a JSON object")
+	}
+
+	allowed := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		allowed[field] = true
+	}
+	var unknown []string
+	for field := range patch {
+		if !allowed[field] {
+			unknown = append(unknown, field)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("unknown fields %s. Expected %s", strings.Join(unknown, ", "), strings.Join(fields, ", "))
+	}
+	return patch, nil
+}
+
+// applyMergePatch merges the patch into the JSON form of current and decodes the result
+// into target. Members set to null are removed, leaving the zero value in target.
+func applyMergePatch(patch map[string]json.RawMessage, current, target interface{}) error {
+	data, err := json.Marshal(current)
+	if err != nil {
+		return err
+	}
+	var merged map[string]json.RawMessage
+	if err := json.Unmarshal(data, &merged); err != nil {
+		return err
+	}
+
+	for field, value := range patch {
+		if string(value) == "null" {
+			delete(merged, field)
+		} else {
+			merged[field] = value
+		}
+	}
+
+	if data, err = json.Marshal(merged); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, target); err != nil {
+		return fmt.Errorf("invalid patch: %w", err)
+	}
+	return nil
+}
+
+// patches reports whether the patch sets or removes any of the fields
+func patches(patch map[string]json.RawMessage, fields ...string) bool {
+	for _, field := range fields {
+		if _, ok := patch[field]; ok {
+			return true
+		}
+	}
+	return false
+}
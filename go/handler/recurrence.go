@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Recurrence is the schedule of a recurring template: FREQ=DAILY or FREQ=WEEKLY with
+// INTERVAL and BYDAY weekdays. Intervals are counted from the first day of the template.
+type Recurrence struct {
+	Freq     string
+	Interval int
+	Weekdays []time.Weekday
+}
+
+// recurrenceWeekdays maps the BYDAY codes onto weekdays
+var recurrenceWeekdays = map[string]time.Weekday{
+	"MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday, "TH": time.Thursday,
+	"FR": time.Friday, "SA": time.Saturday, "SU": time.Sunday,
+}
+
+// ParseRecurrence reads a schedule such as FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH
+func ParseRecurrence(value string) (Recurrence, error) {
+	r := Recurrence{Interval: 1}
+	for _, part := range strings.Split(value, ";") {
+		key, val, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			r.Freq = strings.ToUpper(val)
+		case "INTERVAL":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 {
+				return r, fmt.Errorf("invalid INTERVAL '%s'", val)
+			}
+			r.Interval = n
+		case "BYDAY":
+			for _, code := range strings.Split(val, ",") {
+				day, ok := recurrenceWeekdays[strings.ToUpper(code)]
+				if !ok {
+					return r, fmt.Errorf("invalid BYDAY '%s'. Expected MO, TU, WE, TH, FR, SA or SU", code)
+				}
+				r.Weekdays = append(r.Weekdays, day)
+			}
+		default:
+			return r, fmt.Errorf("unsupported schedule part '%s'. Expected FREQ, INTERVAL or BYDAY", part)
+		}
+	}
+
+	if r.Freq != "DAILY" && r.Freq != "WEEKLY" {
+		return r, fmt.Errorf("invalid FREQ '%s'. Expected DAILY or WEEKLY", r.Freq)
+	}
+	return r, nil
+}
+
+// mondayOf returns the Monday of the ISO week containing day
+func mondayOf(day time.Time) time.Time {
+	return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+}
+
+// Due reports whether the schedule books an entry on day, counting intervals from first.
+// Both are dates at midnight UTC. Weekly schedules without BYDAY repeat on the weekday of first.
+func (r Recurrence) Due(first, day time.Time) bool {
+	if day.Before(first) {
+		return false
+	}
+
+	if r.Freq == "DAILY" {
+		days := int(day.Sub(first).Hours() / 24)
+		return days%r.Interval == 0 && (len(r.Weekdays) == 0 || containsWeekday(r.Weekdays, day.Weekday()))
+	}
+
+	weeks := int(mondayOf(day).Sub(mondayOf(first)).Hours() / 24 / 7)
+	if weeks%r.Interval != 0 {
+		return false
+	}
+	if len(r.Weekdays) == 0 {
+		return day.Weekday() == first.Weekday()
+	}
+	return containsWeekday(r.Weekdays, day.Weekday())
+}
+
+// containsWeekday reports whether day is one of days
+func containsWeekday(days []time.Weekday, day time.Weekday) bool {
+	for _, d := range days {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// dueDays returns the days of the two weeks from first on which the schedule is due
+func dueDays(t *testing.T, schedule string, first time.Time) []string {
+	r, err := ParseRecurrence(schedule)
+	require.NoError(t, err)
+	var days []string
+	for day := first; day.Before(first.AddDate(0, 0, 14)); day = day.AddDate(0, 0, 1) {
+		if r.Due(first, day) {
+			days = append(days, day.Format("01-02"))
+		}
+	}
+	return days
+}
+
+func TestRecurrenceDue(t *testing.T) {
+	// A Wednesday
+	first := time.Date(2025, 11, 5, 0, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, []string{"11-05", "11-06", "11-07", "11-10", "11-11", "11-12", "11-13", "11-14", "11-17", "11-18"},
+		dueDays(t, "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR", first))
+	assert.Equal(t, []string{"11-06", "11-17"}, dueDays(t, "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH", first))
+	assert.Equal(t, []string{"11-05", "11-12"}, dueDays(t, "FREQ=WEEKLY", first))
+	assert.Equal(t, []string{"11-05", "11-08", "11-11", "11-14", "11-17"}, dueDays(t, "FREQ=DAILY;INTERVAL=3", first))
+	assert.False(t, Recurrence{Freq: "DAILY", Interval: 1}.Due(first, first.AddDate(0, 0, -1)))
+}
+
+func TestParseRecurrenceRejectsUnsupportedRules(t *testing.T) {
+	for _, schedule := range []string{"", "FREQ=MONTHLY", "FREQ=WEEKLY;INTERVAL=0", "FREQ=WEEKLY;BYDAY=1MO", "FREQ=DAILY;COUNT=3"} {
+		_, err := ParseRecurrence(schedule)
+		assert.Error(t, err, schedule)
+	}
+}
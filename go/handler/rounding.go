@@ -0,0 +1,244 @@
+package handler
+
+import (
+	"fmt"
+	"strings"
+
+	pkgmodel "timesheet/go/model"
+)
+
+// Rounding modes
+const (
+	RoundNone    = "none"
+	RoundUp      = "up"
+	RoundDown    = "down"
+	RoundNearest = "nearest"
+)
+
+// What a rounding rule rounds: each entry or the total of a day
+const (
+	RoundPerEntry = "entry"
+	RoundPerDay   = "day"
+)
+
+// maxRoundingIncrement is one day in minutes
+const maxRoundingIncrement = 24 * 60
+
+// ValidateRoundingRule checks a rounding rule and fills in the defaults. Rules that do not
+// round carry neither increment nor aggregate.
+func ValidateRoundingRule(rule *pkgmodel.RoundingRule) error {
+	switch rule.Mode {
+	case RoundNone:
+		rule.Increment, rule.Per = 0, ""
+		return nil
+	case RoundUp, RoundDown, RoundNearest:
+	default:
+		return fmt.Errorf("invalid mode '%s'. Expected none, up, down or nearest", rule.Mode)
+	}
+	if rule.Increment < 1 || rule.Increment > maxRoundingIncrement {
+		return fmt.Errorf("invalid increment %d. Expected 1 to %d minutes", rule.Increment, maxRoundingIncrement)
+	}
+	if rule.Per == "" {
+		rule.Per = RoundPerEntry
+	}
+	if rule.Per != RoundPerEntry && rule.Per != RoundPerDay {
+		return fmt.Errorf("invalid per '%s'. Expected entry or day", rule.Per)
+	}
+	return nil
+}
+
+// roundMinutes rounds minutes to a multiple of the rule's increment; nearest rounds halves up
+func roundMinutes(rule pkgmodel.RoundingRule, minutes int) int {
+	n := rule.Increment
+	switch rule.Mode {
+	case RoundUp:
+		return (minutes + n - 1) / n * n
+	case RoundDown:
+		return minutes / n * n
+	case RoundNearest:
+		return (minutes + n/2) / n * n
+	}
+	return minutes
+}
+
+// describeRoundingRule formats a rule for humans, e.g. "Acme: up to 15 min per day"
+func describeRoundingRule(rule pkgmodel.RoundingRule) string {
+	description := "exact minutes"
+	if rule.Mode != RoundNone {
+		description = fmt.Sprintf("%s to %d min per %s", rule.Mode, rule.Increment, rule.Per)
+	}
+	if rule.Client != "" {
+		return rule.Client + ": " + description
+	}
+	return description
+}
+
+// ParseRoundingParam reads the `rounding` query parameter: empty applies the configured
+// rules and none reports exact minutes
+func ParseRoundingParam(value string) (exact bool, err error) {
+	switch value {
+	case "":
+		return false, nil
+	case RoundNone:
+		return true, nil
+	}
+	return false, fmt.Errorf("invalid rounding '%s'. Expected none", value)
+}
+
+// roundingBook finds the rounding rule of an entry by its project
+type roundingBook struct {
+	// rules holds the default rule first, followed by the client rules ordered by client
+	rules    []pkgmodel.RoundingRule
+	projects map[int]pkgmodel.RoundingRule
+}
+
+// queryRoundingRules reads the default rule and the rules of existing clients
+func queryRoundingRules(q Querier) ([]pkgmodel.RoundingRule, error) {
+	rows, err := q.Query(`
+		SELECT r.client_id, COALESCE(c.name, ''), r.mode, r.increment, r.per
+		FROM rounding_rules r
+		LEFT JOIN clients c ON c.id = r.client_id
+		WHERE r.client_id = 0 OR c.id IS NOT NULL
+		ORDER BY r.client_id != 0, c.name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := []pkgmodel.RoundingRule{}
+	for rows.Next() {
+		var rule pkgmodel.RoundingRule
+		if err := rows.Scan(&rule.ClientID, &rule.Client, &rule.Mode, &rule.Increment, &rule.Per); err != nil {
+			return nil, err
+		}
+		if rule.Mode == RoundNone {
+			rule.Per = ""
+		}
+		rules = append(rules, rule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(rules) == 0 || rules[0].ClientID != 0 {
+		rules = append([]pkgmodel.RoundingRule{{Mode: RoundNone}}, rules...)
+	}
+	return rules, nil
+}
+
+// newRoundingBook loads the configured rules, or a book that keeps exact minutes
+func newRoundingBook(q Querier, exact bool) (roundingBook, error) {
+	book := roundingBook{rules: []pkgmodel.RoundingRule{{Mode: RoundNone}}, projects: map[int]pkgmodel.RoundingRule{}}
+	if exact {
+		return book, nil
+	}
+
+	var err error
+	if book.rules, err = queryRoundingRules(q); err != nil {
+		return book, err
+	}
+	clients := map[int]pkgmodel.RoundingRule{}
+	for _, rule := range book.rules[1:] {
+		clients[rule.ClientID] = rule
+	}
+	if len(clients) == 0 {
+		return book, nil
+	}
+
+	rows, err := q.Query("SELECT id, client_id FROM projects")
+	if err != nil {
+		return book, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var projectID, clientID int
+		if err := rows.Scan(&projectID, &clientID); err != nil {
+			return book, err
+		}
+		if rule, ok := clients[clientID]; ok {
+			book.projects[projectID] = rule
+		}
+	}
+	return book, rows.Err()
+}
+
+// keepsExactMinutes reports whether the book has no rules besides a default that does not
+// round, so durations can be summed without looking at single entries
+func (b roundingBook) keepsExactMinutes() bool {
+	return len(b.rules) == 1 && b.rules[0].Mode == RoundNone
+}
+
+// ruleFor returns the rule of the client of a project, or the default rule for entries
+// without project (0) or whose client has no rule of its own
+func (b roundingBook) ruleFor(projectID int) pkgmodel.RoundingRule {
+	if rule, ok := b.projects[projectID]; ok {
+		return rule
+	}
+	return b.rules[0]
+}
+
+// describeRoundingRules formats a list of rules, separated by semicolons
+func describeRoundingRules(rules []pkgmodel.RoundingRule) string {
+	var descriptions []string
+	for _, rule := range rules {
+		descriptions = append(descriptions, describeRoundingRule(rule))
+	}
+	return strings.Join(descriptions, "; ")
+}
+
+// describe formats all rules of the book
+func (b roundingBook) describe() string {
+	return describeRoundingRules(b.rules)
+}
+
+// durationRounder rounds the durations of a sequence of entries
+type durationRounder struct {
+	book roundingBook
+	day  string
+	// raw and rounded hold the running totals of the current day per rule, by client ID
+	raw, rounded map[int]int
+	applied      map[int]bool
+}
+
+func (b roundingBook) newRounder() *durationRounder {
+	return &durationRounder{book: b, raw: map[int]int{}, rounded: map[int]int{}, applied: map[int]bool{}}
+}
+
+// round returns the rounded duration of the next entry. Entries must come in chronological
+// order: a per-day rule rounds the running total of the day and attributes the change to the
+// entry, so that the rounded entries of a day add up to the rounded day total.
+func (r *durationRounder) round(day string, projectID int, minutes int) int {
+	rule := r.book.ruleFor(projectID)
+	r.applied[rule.ClientID] = true
+	if rule.Mode == RoundNone || rule.Per != RoundPerDay {
+		return roundMinutes(rule, minutes)
+	}
+
+	if day != r.day {
+		r.day = day
+		r.raw, r.rounded = map[int]int{}, map[int]int{}
+	}
+	r.raw[rule.ClientID] += minutes
+	total := roundMinutes(rule, r.raw[rule.ClientID])
+	rounded := total - r.rounded[rule.ClientID]
+	r.rounded[rule.ClientID] = total
+	return rounded
+}
+
+// appliedRules returns the default rule and the client rules used so far
+func (r *durationRounder) appliedRules() []pkgmodel.RoundingRule {
+	rules := []pkgmodel.RoundingRule{r.book.rules[0]}
+	for _, rule := range r.book.rules[1:] {
+		if r.applied[rule.ClientID] {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// projectOf returns the project ID of an entry, 0 for none
+func projectOf(entry pkgmodel.TimeEntry) int {
+	if entry.ProjectID == nil {
+		return 0
+	}
+	return *entry.ProjectID
+}
@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	pkgmodel "timesheet/go/model"
+)
+
+func TestRoundMinutes(t *testing.T) {
+	tests := []struct {
+		mode      string
+		increment int
+		minutes   int
+		expected  int
+	}{
+		{RoundUp, 15, 1, 15},
+		{RoundUp, 15, 15, 15},
+		{RoundUp, 15, 0, 0},
+		{RoundDown, 15, 29, 15},
+		{RoundNearest, 15, 7, 0},
+		{RoundNearest, 15, 8, 15},
+		{RoundNearest, 10, 5, 10},
+		{RoundNone, 0, 7, 7},
+	}
+	for _, tt := range tests {
+		rule := pkgmodel.RoundingRule{Mode: tt.mode, Increment: tt.increment}
+		assert.Equal(t, tt.expected, roundMinutes(rule, tt.minutes), "%s %d of %d", tt.mode, tt.increment, tt.minutes)
+	}
+}
+
+func TestValidateRoundingRule(t *testing.T) {
+	rule := pkgmodel.RoundingRule{Mode: RoundUp, Increment: 15}
+	assert.NoError(t, ValidateRoundingRule(&rule))
+	assert.Equal(t, RoundPerEntry, rule.Per)
+
+	rule = pkgmodel.RoundingRule{Mode: RoundNone, Increment: 15, Per: RoundPerDay}
+	assert.NoError(t, ValidateRoundingRule(&rule))
+	assert.Equal(t, pkgmodel.RoundingRule{Mode: RoundNone}, rule)
+
+	for _, rule := range []pkgmodel.RoundingRule{
+		{Mode: "ceil", Increment: 15},
+		{Mode: RoundUp},
+		{Mode: RoundUp, Increment: 1441},
+		{Mode: RoundUp, Increment: 15, Per: "week"},
+	} {
+		assert.Error(t, ValidateRoundingRule(&rule), "%+v", rule)
+	}
+}
+
+func TestDurationRounderPerDay(t *testing.T) {
+	acme := pkgmodel.RoundingRule{ClientID: 1, Client: "Acme", Mode: RoundUp, Increment: 15, Per: RoundPerDay}
+	book := roundingBook{
+		rules:    []pkgmodel.RoundingRule{{Mode: RoundNearest, Increment: 15, Per: RoundPerEntry}, acme},
+		projects: map[int]pkgmodel.RoundingRule{7: acme},
+	}
+	rounder := book.newRounder()
+
+	// The day total of Acme is rounded up, the other entries each to the nearest 15 minutes
+	assert.Equal(t, 15, rounder.round("2025-11-10", 7, 10))
+	assert.Equal(t, 15, rounder.round("2025-11-10", 0, 10))
+	assert.Equal(t, 0, rounder.round("2025-11-10", 7, 5))
+	assert.Equal(t, 15, rounder.round("2025-11-10", 7, 1))
+	// A new day starts a new total
+	assert.Equal(t, 15, rounder.round("2025-11-11", 7, 1))
+
+	assert.Equal(t, book.rules, rounder.appliedRules())
+	assert.Equal(t, "nearest to 15 min per entry; Acme: up to 15 min per day", book.describe())
+}
@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	pkgmodel "timesheet/go/model"
+)
+
+// MaxTagLength is the longest accepted tag name
+const MaxTagLength = 50
+
+// NormalizeTags trims and lower-cases tag names and removes duplicates. The result is sorted;
+// nil stays nil so that requests can tell "keep the tags" from "no tags".
+func NormalizeTags(tags []string) ([]string, error) {
+	if tags == nil {
+		return nil, nil
+	}
+	seen := make(map[string]bool, len(tags))
+	normalized := []string{}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || len(tag) > MaxTagLength || strings.Contains(tag, ",") {
+			return nil, fmt.Errorf("invalid tag '%s'. Expected 1 to %d characters without commas", tag, MaxTagLength)
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+	sort.Strings(normalized)
+	return normalized, nil
+}
+
+// setEntryTags replaces the tags of an entry, creating unknown tags
+func setEntryTags(q Querier, entryID int, tags []string) error {
+	if _, err := q.Exec("DELETE FROM time_entry_tags WHERE entry_id = ?", entryID); err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		_, err := q.Exec("INSERT INTO tags (name) SELECT ? WHERE NOT EXISTS (SELECT 1 FROM tags WHERE name = ?)", tag, tag)
+		if err != nil {
+			return err
+		}
+		_, err = q.Exec("INSERT INTO time_entry_tags (entry_id, tag_id) SELECT ?, id FROM tags WHERE name = ?", entryID, tag)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tagQueryBatch bounds the number of IDs per query, staying below SQLite's variable limit
+const tagQueryBatch = 500
+
+// queryEntryTags returns the sorted tags of the given entries by entry ID
+func queryEntryTags(q Querier, ids []int) (map[int][]string, error) {
+	tags := make(map[int][]string)
+	for len(ids) > 0 {
+		batch := ids
+		if len(batch) > tagQueryBatch {
+			batch = ids[:tagQueryBatch]
+		}
+		ids = ids[len(batch):]
+
+		args := make([]interface{}, len(batch))
+		for i, id := range batch {
+			args[i] = id
+		}
+		rows, err := q.Query(`
+			SELECT et.entry_id, t.name FROM time_entry_tags et JOIN tags t ON t.id = et.tag_id
+			WHERE et.entry_id IN (`+strings.TrimSuffix(strings.Repeat("?, ", len(batch)), ", ")+`)
+			ORDER BY t.name`, args...)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var id int
+			var name string
+			if err := rows.Scan(&id, &name); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			tags[id] = append(tags[id], name)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return tags, nil
+}
+
+// attachTags fills in the tags of the entries
+func attachTags(q Querier, entries []pkgmodel.TimeEntry) error {
+	ids := make([]int, len(entries))
+	for i, entry := range entries {
+		ids[i] = entry.ID
+	}
+	tags, err := queryEntryTags(q, ids)
+	if err != nil {
+		return err
+	}
+	for i := range entries {
+		if entryTags, ok := tags[entries[i].ID]; ok {
+			entries[i].Tags = entryTags
+		}
+	}
+	return nil
+}
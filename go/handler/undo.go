@@ -0,0 +1,334 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	pkgglobal "timesheet/go/global"
+	pkgmodel "timesheet/go/model"
+)
+
+// MaxUndoSteps bounds the undo and redo stacks of each session
+const MaxUndoSteps = 50
+
+// maxUndoSessions bounds the number of sessions whose stacks are kept; the least recently
+// used session is dropped first
+const maxUndoSessions = 100
+
+// SessionCookie identifies the session whose changes can be undone. API clients without
+// cookies can send the X-Session-ID header instead.
+const SessionCookie = "timesheet_session"
+
+// ChangeTx is a transaction that remembers the audit records written through it, so that
+// all writes of one request can be undone as a single step
+type ChangeTx struct {
+	*sql.Tx
+	auditIDs []int64
+}
+
+// BeginChange starts a transaction whose writes can be undone
+func BeginChange() (*ChangeTx, error) {
+	tx, err := pkgglobal.Db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	return &ChangeTx{Tx: tx}, nil
+}
+
+// Commit commits the transaction and pushes its changes onto the undo stack of the session.
+// It must be called before the response is written since it may set the session cookie.
+func (c *ChangeTx) Commit(w http.ResponseWriter, r *http.Request) error {
+	if err := c.Tx.Commit(); err != nil {
+		return err
+	}
+	if len(c.auditIDs) > 0 {
+		undoSessions.push(sessionID(w, r), c.auditIDs)
+	}
+	return nil
+}
+
+// sessionID returns the session of the request, starting a new one if there is none
+func sessionID(w http.ResponseWriter, r *http.Request) string {
+	if id := requestSessionID(r); id != "" {
+		return id
+	}
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	id := hex.EncodeToString(buf)
+	http.SetCookie(w, &http.Cookie{Name: SessionCookie, Value: id, Path: "/", HttpOnly: true, SameSite: http.SameSiteLaxMode})
+	return id
+}
+
+// requestSessionID returns the session sent with the request or an empty string
+func requestSessionID(r *http.Request) string {
+	if id := r.Header.Get("X-Session-ID"); id != "" {
+		return id
+	}
+	if cookie, err := r.Cookie(SessionCookie); err == nil {
+		return cookie.Value
+	}
+	return ""
+}
+
+// undoStacks holds the changes of one session; each change is a list of audit record IDs
+type undoStacks struct {
+	undo, redo [][]int64
+	lastUsed   time.Time
+}
+
+// sessionStacks keeps the undo and redo stacks of all sessions in memory
+type sessionStacks struct {
+	mu       sync.Mutex
+	sessions map[string]*undoStacks
+}
+
+var undoSessions = &sessionStacks{sessions: make(map[string]*undoStacks)}
+
+// get returns the stacks of a session, creating them if needed. The caller holds the lock.
+func (s *sessionStacks) get(session string) *undoStacks {
+	stacks, ok := s.sessions[session]
+	if !ok {
+		if len(s.sessions) >= maxUndoSessions {
+			s.evictOldest()
+		}
+		stacks = &undoStacks{}
+		s.sessions[session] = stacks
+	}
+	stacks.lastUsed = time.Now()
+	return stacks
+}
+
+// evictOldest drops the least recently used session. The caller holds the lock.
+func (s *sessionStacks) evictOldest() {
+	oldest := ""
+	for session, stacks := range s.sessions {
+		if oldest == "" || stacks.lastUsed.Before(s.sessions[oldest].lastUsed) {
+			oldest = session
+		}
+	}
+	delete(s.sessions, oldest)
+}
+
+// push records a new change; it drops the oldest change beyond MaxUndoSteps and clears redo
+func (s *sessionStacks) push(session string, change []int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stacks := s.get(session)
+	stacks.undo = pushBounded(stacks.undo, change)
+	stacks.redo = nil
+}
+
+// pushBounded appends a change and keeps at most MaxUndoSteps changes
+func pushBounded(stack [][]int64, change []int64) [][]int64 {
+	stack = append(stack, change)
+	if len(stack) > MaxUndoSteps {
+		stack = stack[len(stack)-MaxUndoSteps:]
+	}
+	return stack
+}
+
+// reset drops the stacks of all sessions
+func (s *sessionStacks) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions = make(map[string]*undoStacks)
+}
+
+// decodeSnapshot parses a row stored in the audit log; numbers keep their exact value
+func decodeSnapshot(data json.RawMessage) (map[string]interface{}, error) {
+	if data == nil {
+		return nil, nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var row map[string]interface{}
+	if err := decoder.Decode(&row); err != nil {
+		return nil, err
+	}
+	for column, value := range row {
+		if n, ok := value.(json.Number); ok {
+			if i, err := n.Int64(); err == nil {
+				row[column] = i
+			} else if f, err := n.Float64(); err == nil {
+				row[column] = f
+			}
+		}
+	}
+	return row, nil
+}
+
+// sameSnapshot reports whether two snapshots hold the same values
+func sameSnapshot(a, b map[string]interface{}) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	encodedA, errA := json.Marshal(a)
+	encodedB, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(encodedA, encodedB)
+}
+
+// applySnapshot writes a snapshot back: nil deletes the row, otherwise the row is updated or
+// inserted with all columns of the snapshot
+func applySnapshot(q Querier, entityType string, id int, row map[string]interface{}) error {
+	table, key := auditTables[entityType], auditKey(entityType)
+	if row == nil {
+		if entityType == AuditEntry {
+			if err := setEntryTags(q, id, nil); err != nil {
+				return err
+			}
+		}
+		_, err := q.Exec("DELETE FROM "+table+" WHERE "+key+" = ?", id)
+		return err
+	}
+	if entityType == AuditEntry {
+		if err := applySnapshotTags(q, id, row["tags"]); err != nil {
+			return err
+		}
+	}
+
+	current, err := SnapshotRow(q, entityType, id)
+	if err != nil {
+		return err
+	}
+
+	var columns []string
+	for column := range row {
+		if column != key && column != "tags" {
+			columns = append(columns, column)
+		}
+	}
+	sort.Strings(columns)
+	args := make([]interface{}, 0, len(columns)+1)
+	for _, column := range columns {
+		args = append(args, row[column])
+	}
+	args = append(args, id)
+
+	if current != nil {
+		_, err = q.Exec("UPDATE "+table+" SET "+strings.Join(columns, " = ?, ")+" = ? WHERE "+key+" = ?", args...)
+	} else {
+		columns = append(columns, key)
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ")
+		_, err = q.Exec("INSERT INTO "+table+" ("+strings.Join(columns, ", ")+") VALUES ("+placeholders+")", args...)
+	}
+	if err != nil || entityType != AuditEntry {
+		return err
+	}
+
+	// The link triggers resolve the names against the current categories and tasks, which
+	// other records of the same change may not have reverted yet; keep the stored links
+	_, err = q.Exec("UPDATE time_entries SET category_id = ?, task_id = ? WHERE id = ?", row["category_id"], row["task_id"], id)
+	return err
+}
+
+// applySnapshotTags restores the tags stored in an entry snapshot
+func applySnapshotTags(q Querier, id int, value interface{}) error {
+	var tags []string
+	switch v := value.(type) {
+	case []string:
+		tags = v
+	case []interface{}:
+		for _, tag := range v {
+			name, ok := tag.(string)
+			if !ok {
+				return fmt.Errorf("invalid tag %v in snapshot of entry %d", tag, id)
+			}
+			tags = append(tags, name)
+		}
+	}
+	return setEntryTags(q, id, tags)
+}
+
+// undoRecord is an audit record with its decoded snapshots
+type undoRecord struct {
+	record        pkgmodel.AuditRecord
+	before, after map[string]interface{}
+}
+
+// loadUndoRecords reads the audit records of one change in the order they were written
+func loadUndoRecords(q Querier, ids []int64) ([]undoRecord, error) {
+	var records []undoRecord
+	for _, id := range ids {
+		found, err := queryAuditLog(q, " WHERE id = ?", id)
+		if err != nil {
+			return nil, err
+		}
+		if len(found) == 0 {
+			return nil, fmt.Errorf("audit record %d not found", id)
+		}
+
+		entry := undoRecord{record: found[0]}
+		if entry.before, err = decodeSnapshot(entry.record.Before); err != nil {
+			return nil, err
+		}
+		if entry.after, err = decodeSnapshot(entry.record.After); err != nil {
+			return nil, err
+		}
+		records = append(records, entry)
+	}
+	return records, nil
+}
+
+// UndoConflictError reports a row that was changed after the change being undone or redone
+type UndoConflictError struct {
+	EntityType string
+	EntityID   int
+}
+
+func (e *UndoConflictError) Error() string {
+	return fmt.Sprintf("%s %d has been changed since", e.EntityType, e.EntityID)
+}
+
+// revertChange undoes a change by writing back the state before each record, newest first.
+// With redo set it writes the state after each record, oldest first. Every row must still be
+// in the state the change left it in, otherwise an *UndoConflictError is returned.
+func revertChange(q Querier, records []undoRecord, redo bool) error {
+	order := make([]undoRecord, len(records))
+	copy(order, records)
+	if !redo {
+		for i, j := 0, len(order)-1; i < j; i, j = i+1, j-1 {
+			order[i], order[j] = order[j], order[i]
+		}
+	}
+
+	operation := AuditUndo
+	if redo {
+		operation = AuditRedo
+	}
+	for _, r := range order {
+		expected, target := r.after, r.before
+		if redo {
+			expected, target = r.before, r.after
+		}
+
+		id := r.record.EntityID
+		current, err := SnapshotRow(q, r.record.EntityType, id)
+		if err != nil {
+			return err
+		}
+		if !sameSnapshot(current, expected) {
+			return &UndoConflictError{EntityType: r.record.EntityType, EntityID: id}
+		}
+
+		if err := applySnapshot(q, r.record.EntityType, id, target); err != nil {
+			return err
+		}
+		written, err := SnapshotRow(q, r.record.EntityType, id)
+		if err != nil {
+			return err
+		}
+		if err := RecordAudit(q, r.record.EntityType, id, operation, current, written); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -25,6 +25,17 @@ func ValidateTimeEntryRequest(req pkgmodel.TimeEntryRequest) error {
 	return nil
 }
 
+// ValidateTimerStartRequest validates the required fields of a timer start request
+func ValidateTimerStartRequest(req pkgmodel.TimerStartRequest) error {
+	if req.Task == "" {
+		return errors.New("task is required")
+	}
+	if req.Category == "" {
+		return errors.New("category is required")
+	}
+	return nil
+}
+
 // ParseAndValidateTimeEntry parses and validates time entry times, returning parsed times and calculated duration
 func ParseAndValidateTimeEntry(req pkgmodel.TimeEntryRequest) (startTime, endTime time.Time, duration int, err error) {
 	// First validate required fields
@@ -96,6 +96,46 @@ func TestValidateTimeEntryRequest(t *testing.T) {
 	}
 }
 
+func TestValidateTimerStartRequest(t *testing.T) {
+	tests := []struct {
+		name        string
+		req         pkgmodel.TimerStartRequest
+		expectError bool
+		errorMsg    string
+	}{
+		{
+			name:        "valid request",
+			req:         pkgmodel.TimerStartRequest{Task: "Development", Category: "project work"},
+			expectError: false,
+		},
+		{
+			name:        "missing task",
+			req:         pkgmodel.TimerStartRequest{Category: "project work"},
+			expectError: true,
+			errorMsg:    "task is required",
+		},
+		{
+			name:        "missing category",
+			req:         pkgmodel.TimerStartRequest{Task: "Development", Description: "no category"},
+			expectError: true,
+			errorMsg:    "category is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateTimerStartRequest(tt.req)
+
+			if tt.expectError {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errorMsg)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
 func TestParseAndValidateTimeEntry(t *testing.T) {
 	tests := []struct {
 		name             string
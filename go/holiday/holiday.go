@@ -0,0 +1,150 @@
+// Package holiday computes public holidays per region, including the movable feasts that
+// depend on Easter. Regions are ISO 3166-2 codes of the German federal states such as DE-BY;
+// DE covers the nationwide holidays only.
+package holiday
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Holiday is a public holiday on a day
+type Holiday struct {
+	Date time.Time
+	Name string
+}
+
+// germanStates lists the codes of the federal states without the DE- prefix
+var germanStates = []string{"BB", "BE", "BW", "BY", "HB", "HE", "HH", "MV", "NI", "NW", "RP", "SH", "SL", "SN", "ST", "TH"}
+
+// feast is a holiday of some regions, observed from one year up to another
+type feast struct {
+	name string
+	date func(year int) time.Time
+	// states lists the federal states observing the feast; nil means nationwide
+	states []string
+	// from and until bound the years the feast is observed in, 0 leaves them open
+	from, until int
+}
+
+// fixed returns the date function of a feast on the same day every year
+func fixed(month time.Month, day int) func(int) time.Time {
+	return func(year int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+}
+
+// afterEaster returns the date function of a feast the given number of days after Easter Sunday
+func afterEaster(days int) func(int) time.Time {
+	return func(year int) time.Time {
+		return Easter(year).AddDate(0, 0, days)
+	}
+}
+
+// repentanceDay is the Wednesday before November 23
+func repentanceDay(year int) time.Time {
+	day := time.Date(year, time.November, 22, 0, 0, 0, 0, time.UTC)
+	return day.AddDate(0, 0, -((int(day.Weekday()) - int(time.Wednesday) + 7) % 7))
+}
+
+// feasts follows the holiday laws of the federal states. Feasts observed only in some
+// municipalities, such as Assumption Day in Catholic parts of Bavaria, are left out.
+var feasts = []feast{
+	{name: "New Year's Day", date: fixed(time.January, 1)},
+	{name: "Epiphany", date: fixed(time.January, 6), states: []string{"BW", "BY", "ST"}},
+	{name: "International Women's Day", date: fixed(time.March, 8), states: []string{"BE"}, from: 2019},
+	{name: "International Women's Day", date: fixed(time.March, 8), states: []string{"MV"}, from: 2023},
+	{name: "Good Friday", date: afterEaster(-2)},
+	{name: "Easter Sunday", date: afterEaster(0), states: []string{"BB"}},
+	{name: "Easter Monday", date: afterEaster(1)},
+	{name: "Labour Day", date: fixed(time.May, 1)},
+	{name: "Liberation Day", date: fixed(time.May, 8), states: []string{"BE"}, from: 2020, until: 2020},
+	{name: "Liberation Day", date: fixed(time.May, 8), states: []string{"BE"}, from: 2025, until: 2025},
+	{name: "Ascension Day", date: afterEaster(39)},
+	{name: "Whit Sunday", date: afterEaster(49), states: []string{"BB"}},
+	{name: "Whit Monday", date: afterEaster(50)},
+	{name: "Corpus Christi", date: afterEaster(60), states: []string{"BW", "BY", "HE", "NW", "RP", "SL"}},
+	{name: "Assumption Day", date: fixed(time.August, 15), states: []string{"SL"}},
+	{name: "World Children's Day", date: fixed(time.September, 20), states: []string{"TH"}, from: 2019},
+	{name: "German Unity Day", date: fixed(time.October, 3), from: 1990},
+	{name: "Reformation Day", date: fixed(time.October, 31), states: []string{"BB", "MV", "SN", "ST", "TH"}},
+	{name: "Reformation Day", date: fixed(time.October, 31), states: []string{"HB", "HH", "NI", "SH"}, from: 2018},
+	{name: "Reformation Day", date: fixed(time.October, 31), from: 2017, until: 2017},
+	{name: "All Saints' Day", date: fixed(time.November, 1), states: []string{"BW", "BY", "NW", "RP", "SL"}},
+	{name: "Day of Repentance and Prayer", date: repentanceDay, states: []string{"SN"}},
+	{name: "Christmas Day", date: fixed(time.December, 25)},
+	{name: "Boxing Day", date: fixed(time.December, 26)},
+}
+
+// Easter returns Easter Sunday of the Gregorian calendar (anonymous Gregorian algorithm)
+func Easter(year int) time.Time {
+	a := year % 19
+	b, c := year/100, year%100
+	d, e := b/4, b%4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i, k := c/4, c%4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+}
+
+// Regions lists the supported region codes
+func Regions() []string {
+	regions := []string{"DE"}
+	for _, state := range germanStates {
+		regions = append(regions, "DE-"+state)
+	}
+	return regions
+}
+
+// ValidRegion reports whether the calendar knows a region
+func ValidRegion(region string) bool {
+	for _, r := range Regions() {
+		if r == region {
+			return true
+		}
+	}
+	return false
+}
+
+// Calendar returns the public holidays of a region in a year, ordered by date
+func Calendar(region string, year int) ([]Holiday, error) {
+	if !ValidRegion(region) {
+		return nil, fmt.Errorf("invalid region '%s'. Expected one of %s", region, strings.Join(Regions(), ", "))
+	}
+	state := strings.TrimPrefix(strings.TrimPrefix(region, "DE"), "-")
+
+	var holidays []Holiday
+	for _, f := range feasts {
+		if (f.from != 0 && year < f.from) || (f.until != 0 && year > f.until) {
+			continue
+		}
+		if f.states != nil && !observedIn(f.states, state) {
+			continue
+		}
+		// A feast observed nationwide for one year may already be a holiday of the state
+		day := f.date(year)
+		if len(holidays) > 0 && holidays[len(holidays)-1].Name == f.name && holidays[len(holidays)-1].Date.Equal(day) {
+			continue
+		}
+		holidays = append(holidays, Holiday{Date: day, Name: f.name})
+	}
+	sort.SliceStable(holidays, func(i, j int) bool { return holidays[i].Date.Before(holidays[j].Date) })
+	return holidays, nil
+}
+
+// observedIn reports whether the state is one of the states; the nationwide region has none
+func observedIn(states []string, state string) bool {
+	for _, s := range states {
+		if s == state {
+			return true
+		}
+	}
+	return false
+}
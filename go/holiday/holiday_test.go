@@ -0,0 +1,83 @@
+package holiday
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEaster(t *testing.T) {
+	for year, expected := range map[int]string{
+		1818: "1818-03-22", // earliest possible date
+		1943: "1943-04-25", // latest possible date
+		2024: "2024-03-31",
+		2025: "2025-04-20",
+		2026: "2026-04-05",
+		2038: "2038-04-25",
+	} {
+		assert.Equal(t, expected, Easter(year).Format("2006-01-02"), year)
+	}
+}
+
+// dates returns the holidays as "date name" lines
+func dates(t *testing.T, region string, year int) []string {
+	holidays, err := Calendar(region, year)
+	require.NoError(t, err)
+	var lines []string
+	for _, h := range holidays {
+		lines = append(lines, h.Date.Format("2006-01-02")+" "+h.Name)
+	}
+	return lines
+}
+
+func TestCalendarNationwide(t *testing.T) {
+	assert.Equal(t, []string{
+		"2025-01-01 New Year's Day",
+		"2025-04-18 Good Friday",
+		"2025-04-21 Easter Monday",
+		"2025-05-01 Labour Day",
+		"2025-05-29 Ascension Day",
+		"2025-06-09 Whit Monday",
+		"2025-10-03 German Unity Day",
+		"2025-12-25 Christmas Day",
+		"2025-12-26 Boxing Day",
+	}, dates(t, "DE", 2025))
+}
+
+func TestCalendarStates(t *testing.T) {
+	bavaria := dates(t, "DE-BY", 2025)
+	assert.Len(t, bavaria, 12)
+	assert.Contains(t, bavaria, "2025-01-06 Epiphany")
+	assert.Contains(t, bavaria, "2025-06-19 Corpus Christi")
+	assert.Contains(t, bavaria, "2025-11-01 All Saints' Day")
+
+	assert.Contains(t, dates(t, "DE-SN", 2025), "2025-11-19 Day of Repentance and Prayer")
+	assert.Contains(t, dates(t, "DE-SN", 2026), "2026-11-18 Day of Repentance and Prayer")
+	assert.Contains(t, dates(t, "DE-BE", 2025), "2025-05-08 Liberation Day")
+	assert.NotContains(t, dates(t, "DE-BE", 2026), "2026-05-08 Liberation Day")
+
+	// Reformation Day became a holiday in the northern states in 2018 and was observed
+	// nationwide in 2017
+	assert.NotContains(t, dates(t, "DE-HH", 2016), "2016-10-31 Reformation Day")
+	assert.Contains(t, dates(t, "DE-HH", 2018), "2018-10-31 Reformation Day")
+	assert.Contains(t, dates(t, "DE-BY", 2017), "2017-10-31 Reformation Day")
+	assert.Contains(t, dates(t, "DE", 2017), "2017-10-31 Reformation Day")
+	assert.Len(t, dates(t, "DE-SN", 2017), 11)
+}
+
+func TestCalendarInvalidRegion(t *testing.T) {
+	_, err := Calendar("DE-XX", 2025)
+	assert.Error(t, err)
+	_, err = Calendar("", 2025)
+	assert.Error(t, err)
+}
+
+func TestRepentanceDayIsWednesday(t *testing.T) {
+	for year := 2000; year < 2040; year++ {
+		day := repentanceDay(year)
+		assert.Equal(t, time.Wednesday, day.Weekday(), year)
+		assert.True(t, day.Day() >= 16 && day.Day() <= 22, year)
+	}
+}
@@ -0,0 +1,132 @@
+// Package ical reads and writes iCalendar (RFC 5545) data.
+package ical
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxLineOctets is the longest content line allowed before folding
+const maxLineOctets = 75
+
+// Event is a VEVENT with the properties used by the time entry feed and the calendar import
+type Event struct {
+	UID         string
+	Summary     string
+	Description string
+	Categories  []string
+	Start       time.Time
+	End         time.Time
+	// Floating writes start and end without time zone, i.e. in the viewer's local time
+	Floating bool
+
+	// The following properties are only filled in by Parse
+	AllDay    bool
+	Cancelled bool
+	// RRule is the raw recurrence rule, see Expand for the supported subset
+	RRule   string
+	ExDates []time.Time
+	// RecurrenceID marks an event that replaces one instance of a recurring event
+	RecurrenceID time.Time
+}
+
+// EscapeText escapes a TEXT property value
+func EscapeText(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, ";", `\;`)
+	s = strings.ReplaceAll(s, ",", `\,`)
+	s = strings.ReplaceAll(s, "\r\n", `\n`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return s
+}
+
+// FoldLine splits a content line into lines of at most 75 octets, continuing each
+// with a single space and never splitting a UTF-8 sequence. Lines end with CRLF.
+func FoldLine(line string) string {
+	var b strings.Builder
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// The leading space of a continuation line counts towards its length
+		limit = maxLineOctets - 1
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+	return b.String()
+}
+
+// formatDateTime formats a DATE-TIME value, in UTC or as floating local time
+func formatDateTime(t time.Time, floating bool) string {
+	if floating {
+		return t.Format("20060102T150405")
+	}
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// Writer streams a VCALENDAR
+type Writer struct {
+	w     io.Writer
+	stamp time.Time
+	err   error
+}
+
+// NewWriter starts a calendar. stamp is used as DTSTAMP of all events.
+func NewWriter(w io.Writer, productID, name string, stamp time.Time) *Writer {
+	cw := &Writer{w: w, stamp: stamp}
+	cw.line("BEGIN:VCALENDAR")
+	cw.line("VERSION:2.0")
+	cw.line("PRODID:" + productID)
+	cw.line("CALSCALE:GREGORIAN")
+	if name != "" {
+		cw.line("X-WR-CALNAME:" + EscapeText(name))
+	}
+	return cw
+}
+
+// line writes one folded content line, remembering the first error
+func (cw *Writer) line(content string) {
+	if cw.err != nil {
+		return
+	}
+	_, cw.err = io.WriteString(cw.w, FoldLine(content))
+}
+
+// WriteEvent appends a VEVENT
+func (cw *Writer) WriteEvent(e Event) error {
+	cw.line("BEGIN:VEVENT")
+	cw.line("UID:" + EscapeText(e.UID))
+	cw.line("DTSTAMP:" + formatDateTime(cw.stamp, false))
+	cw.line("DTSTART:" + formatDateTime(e.Start, e.Floating))
+	cw.line("DTEND:" + formatDateTime(e.End, e.Floating))
+	cw.line("SUMMARY:" + EscapeText(e.Summary))
+	if e.Description != "" {
+		cw.line("DESCRIPTION:" + EscapeText(e.Description))
+	}
+	if len(e.Categories) > 0 {
+		escaped := make([]string, len(e.Categories))
+		for i, category := range e.Categories {
+			escaped[i] = EscapeText(category)
+		}
+		cw.line("CATEGORIES:" + strings.Join(escaped, ","))
+	}
+	cw.line("END:VEVENT")
+	return cw.err
+}
+
+// Close ends the calendar. It does not close the underlying writer.
+func (cw *Writer) Close() error {
+	cw.line("END:VCALENDAR")
+	if cw.err != nil {
+		return fmt.Errorf("failed to write calendar: %w", cw.err)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEscapeText(t *testing.T) {
+	assert.Equal(t, `a\\b\;c\,d\ne\nf`, EscapeText("a\\b;c,d\r\ne\nf"))
+}
+
+func TestFoldLine(t *testing.T) {
+	assert.Equal(t, "SUMMARY:short\r\n", FoldLine("SUMMARY:short"))
+
+	long := "DESCRIPTION:" + strings.Repeat("x", 150)
+	folded := FoldLine(long)
+	lines := strings.Split(strings.TrimSuffix(folded, "\r\n"), "\r\n")
+	require.Len(t, lines, 3)
+	assert.Len(t, lines[0], 75)
+	assert.Len(t, lines[1], 75)
+	assert.True(t, strings.HasPrefix(lines[1], " "))
+	// Unfolding restores the original line
+	assert.Equal(t, long, strings.ReplaceAll(strings.TrimSuffix(folded, "\r\n"), "\r\n ", ""))
+}
+
+func TestFoldLineKeepsMultiByteCharacters(t *testing.T) {
+	long := "SUMMARY:" + strings.Repeat("ä", 60)
+
+	folded := FoldLine(long)
+
+	for _, line := range strings.Split(strings.TrimSuffix(folded, "\r\n"), "\r\n") {
+		assert.LessOrEqual(t, len(line), 75)
+		assert.True(t, strings.ToValidUTF8(line, "?") == line, "line splits a character: %q", line)
+	}
+	assert.Equal(t, long, strings.ReplaceAll(strings.TrimSuffix(folded, "\r\n"), "\r\n ", ""))
+}
+
+func TestWriterWritesEvents(t *testing.T) {
+	var buf bytes.Buffer
+	stamp := time.Date(2025, 11, 12, 8, 0, 0, 0, time.UTC)
+	cw := NewWriter(&buf, "-//test//EN", "Test", stamp)
+
+	err := cw.WriteEvent(Event{
+		UID:         "time-entry-1@timesheet",
+		Summary:     "Review, part 1",
+		Description: "PR #42",
+		Categories:  []string{"project work"},
+		Start:       time.Date(2025, 11, 10, 9, 0, 0, 0, time.UTC),
+		End:         time.Date(2025, 11, 10, 10, 30, 0, 0, time.UTC),
+		Floating:    true,
+	})
+	require.NoError(t, err)
+	require.NoError(t, cw.Close())
+
+	assert.Equal(t, "BEGIN:VCALENDAR\r\n"+
+		"VERSION:2.0\r\n"+
+		"PRODID:-//test//EN\r\n"+
+		"CALSCALE:GREGORIAN\r\n"+
+		"X-WR-CALNAME:Test\r\n"+
+		"BEGIN:VEVENT\r\n"+
+		"UID:time-entry-1@timesheet\r\n"+
+		"DTSTAMP:20251112T080000Z\r\n"+
+		"DTSTART:20251110T090000\r\n"+
+		"DTEND:20251110T103000\r\n"+
+		"SUMMARY:Review\\, part 1\r\n"+
+		"DESCRIPTION:PR #42\r\n"+
+		"CATEGORIES:project work\r\n"+
+		"END:VEVENT\r\n"+
+		"END:VCALENDAR\r\n", buf.String())
+}
@@ -0,0 +1,252 @@
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// property is one unfolded content line
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// unfoldLines reads content lines, joining continuation lines that start with a space or tab
+func unfoldLines(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read calendar: %w", err)
+	}
+	return lines, nil
+}
+
+// parseProperty splits a content line into name, parameters and value
+func parseProperty(line string) (property, error) {
+	// The value starts after the first colon that is not inside a quoted parameter value
+	quoted, colon := false, -1
+	for i, c := range line {
+		if c == '"' {
+			quoted = !quoted
+		} else if c == ':' && !quoted {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return property{}, fmt.Errorf("invalid content line '%s'", line)
+	}
+
+	p := property{value: line[colon+1:], params: make(map[string]string)}
+	parts := strings.Split(line[:colon], ";")
+	p.name = strings.ToUpper(parts[0])
+	for _, part := range parts[1:] {
+		if key, value, ok := strings.Cut(part, "="); ok {
+			p.params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+		}
+	}
+	return p, nil
+}
+
+// UnescapeText reverses EscapeText
+func UnescapeText(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+			switch s[i] {
+			case 'n', 'N':
+				b.WriteByte('\n')
+			default:
+				b.WriteByte(s[i])
+			}
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// parseDateTime reads a DATE or DATE-TIME value. UTC values keep their instant, values with
+// a known TZID are read in that zone and all others are floating times read in loc.
+func parseDateTime(p property, loc *time.Location) (t time.Time, allDay bool, err error) {
+	value := p.value
+	// Only the first value of a list is used
+	if i := strings.IndexByte(value, ','); i >= 0 {
+		value = value[:i]
+	}
+
+	if p.params["VALUE"] == "DATE" || len(value) == 8 {
+		t, err = time.ParseInLocation("20060102", value, loc)
+		if err != nil {
+			return t, false, fmt.Errorf("invalid %s date '%s'", p.name, value)
+		}
+		return t, true, nil
+	}
+
+	if strings.HasSuffix(value, "Z") {
+		t, err = time.Parse("20060102T150405Z", value)
+	} else {
+		zone := loc
+		if tzid := p.params["TZID"]; tzid != "" {
+			// Unknown zones (e.g. Outlook's "W. Europe Standard Time") are treated as floating
+			if tz, err := time.LoadLocation(tzid); err == nil {
+				zone = tz
+			}
+		}
+		t, err = time.ParseInLocation("20060102T150405", value, zone)
+	}
+	if err != nil {
+		return t, false, fmt.Errorf("invalid %s date-time '%s'", p.name, value)
+	}
+	return t, false, nil
+}
+
+// durationPattern matches the dur-value of RFC 5545 section 3.3.6
+var durationPattern = regexp.MustCompile(`^([+-])?P(?:(\d+)W|(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?)$`)
+
+// parseDuration reads a DURATION value such as PT1H30M
+func parseDuration(value string) (time.Duration, error) {
+	m := durationPattern.FindStringSubmatch(value)
+	if m == nil || value == "P" || value == "PT" {
+		return 0, fmt.Errorf("invalid DURATION '%s'", value)
+	}
+	units := []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second}
+	var d time.Duration
+	for i, unit := range units {
+		if m[i+2] != "" {
+			n, _ := strconv.Atoi(m[i+2])
+			d += time.Duration(n) * unit
+		}
+	}
+	if m[1] == "-" {
+		d = -d
+	}
+	return d, nil
+}
+
+// Parse reads all VEVENTs of a calendar. Floating times are read in loc. Events without
+// DTEND or DURATION last no time, or one day if they are all-day events.
+func Parse(r io.Reader, loc *time.Location) ([]Event, error) {
+	lines, err := unfoldLines(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []Event
+	var current *Event
+	var duration *time.Duration
+	hasEnd := false
+	// Nested components such as VALARM must not overwrite the event's properties
+	depth := 0
+
+	for _, line := range lines {
+		p, err := parseProperty(line)
+		if err != nil {
+			return nil, err
+		}
+
+		switch p.name {
+		case "BEGIN":
+			if strings.EqualFold(p.value, "VEVENT") && current == nil {
+				current, duration, hasEnd = &Event{}, nil, false
+			} else if current != nil {
+				depth++
+			}
+			continue
+		case "END":
+			if current == nil {
+				continue
+			}
+			if depth > 0 {
+				depth--
+				continue
+			}
+			if current.Start.IsZero() {
+				return nil, fmt.Errorf("event '%s' has no DTSTART", current.UID)
+			}
+			switch {
+			case duration != nil:
+				current.End = current.Start.Add(*duration)
+			case !hasEnd && current.AllDay:
+				current.End = current.Start.AddDate(0, 0, 1)
+			case !hasEnd:
+				current.End = current.Start
+			}
+			events = append(events, *current)
+			current = nil
+			continue
+		}
+
+		if current == nil || depth > 0 {
+			continue
+		}
+
+		switch p.name {
+		case "UID":
+			current.UID = p.value
+		case "SUMMARY":
+			current.Summary = UnescapeText(p.value)
+		case "DESCRIPTION":
+			current.Description = UnescapeText(p.value)
+		case "CATEGORIES":
+			for _, category := range strings.Split(p.value, ",") {
+				current.Categories = append(current.Categories, UnescapeText(category))
+			}
+		case "STATUS":
+			current.Cancelled = strings.EqualFold(p.value, "CANCELLED")
+		case "DTSTART":
+			if current.Start, current.AllDay, err = parseDateTime(p, loc); err != nil {
+				return nil, err
+			}
+		case "DTEND":
+			if current.End, _, err = parseDateTime(p, loc); err != nil {
+				return nil, err
+			}
+			hasEnd = true
+		case "DURATION":
+			d, err := parseDuration(p.value)
+			if err != nil {
+				return nil, err
+			}
+			duration = &d
+		case "RRULE":
+			current.RRule = p.value
+		case "EXDATE":
+			for _, value := range strings.Split(p.value, ",") {
+				exdate, _, err := parseDateTime(property{name: p.name, params: p.params, value: value}, loc)
+				if err != nil {
+					return nil, err
+				}
+				current.ExDates = append(current.ExDates, exdate)
+			}
+		case "RECURRENCE-ID":
+			if current.RecurrenceID, _, err = parseDateTime(p, loc); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if current != nil {
+		return nil, fmt.Errorf("event '%s' is not terminated by END:VEVENT", current.UID)
+	}
+	return events, nil
+}
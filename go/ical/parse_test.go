@@ -0,0 +1,176 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testCalendar = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:standup\r\n" +
+	"SUMMARY:Daily standup\r\n" +
+	"DTSTART;TZID=Europe/Berlin:20251103T093000\r\n" +
+	"DURATION:PT15M\r\n" +
+	"RRULE:FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR;UNTIL=20251130T235959Z\r\n" +
+	"EXDATE;TZID=Europe/Berlin:20251105T093000\r\n" +
+	"BEGIN:VALARM\r\n" +
+	"DESCRIPTION:Reminder\r\n" +
+	"END:VALARM\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:standup\r\n" +
+	"RECURRENCE-ID;TZID=Europe/Berlin:20251106T093000\r\n" +
+	"SUMMARY:Daily standup (moved)\r\n" +
+	"DTSTART;TZID=Europe/Berlin:20251106T110000\r\n" +
+	"DTEND;TZID=Europe/Berlin:20251106T111500\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:review\r\n" +
+	"SUMMARY:Code review\\, backend\r\n" +
+	"DESCRIPTION:Line one\\nLine two with a long text that is folded onto the next\r\n" +
+	"  line\r\n" +
+	"DTSTART:20251104T130000Z\r\n" +
+	"DTEND:20251104T140000Z\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:holiday\r\n" +
+	"SUMMARY:Holiday\r\n" +
+	"DTSTART;VALUE=DATE:20251103\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:cancelled\r\n" +
+	"SUMMARY:Cancelled meeting\r\n" +
+	"STATUS:CANCELLED\r\n" +
+	"DTSTART:20251104T150000\r\n" +
+	"DTEND:20251104T160000\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestParse(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	events, err := Parse(strings.NewReader(testCalendar), time.UTC)
+
+	require.NoError(t, err)
+	require.Len(t, events, 5)
+	standup := events[0]
+	assert.Equal(t, "Daily standup", standup.Summary)
+	assert.True(t, standup.Start.Equal(time.Date(2025, 11, 3, 9, 30, 0, 0, berlin)))
+	assert.Equal(t, 15*time.Minute, standup.End.Sub(standup.Start))
+	assert.Equal(t, "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR;UNTIL=20251130T235959Z", standup.RRule)
+	require.Len(t, standup.ExDates, 1)
+	assert.False(t, events[1].RecurrenceID.IsZero())
+
+	review := events[2]
+	assert.Equal(t, "Code review, backend", review.Summary)
+	assert.Equal(t, "Line one\nLine two with a long text that is folded onto the next line", review.Description)
+
+	assert.True(t, events[3].AllDay)
+	assert.Equal(t, 24*time.Hour, events[3].End.Sub(events[3].Start))
+	assert.True(t, events[4].Cancelled)
+}
+
+func TestParseRejectsUnterminatedEvent(t *testing.T) {
+	_, err := Parse(strings.NewReader("BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nUID:x\r\n"), time.UTC)
+	assert.Error(t, err)
+}
+
+func TestParseDuration(t *testing.T) {
+	for value, expected := range map[string]time.Duration{
+		"PT15M": 15 * time.Minute, "PT1H30M": 90 * time.Minute, "P1D": 24 * time.Hour,
+		"P1W": 7 * 24 * time.Hour, "-PT5M": -5 * time.Minute, "P1DT2H": 26 * time.Hour,
+	} {
+		d, err := parseDuration(value)
+		require.NoError(t, err, value)
+		assert.Equal(t, expected, d, value)
+	}
+	for _, value := range []string{"", "P", "PT", "1H", "PT1.5H"} {
+		_, err := parseDuration(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestUnescapeText(t *testing.T) {
+	assert.Equal(t, "a\\b;c,d\ne", UnescapeText(`a\\b\;c\,d\Ne`))
+	assert.Equal(t, "a\\b;c,d\ne", UnescapeText(EscapeText("a\\b;c,d\ne")))
+}
+
+func TestExpand(t *testing.T) {
+	events, err := Parse(strings.NewReader(testCalendar), time.UTC)
+	require.NoError(t, err)
+	from := time.Date(2025, 11, 3, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 11, 10, 0, 0, 0, 0, time.UTC)
+
+	instances, skipped := Expand(events, from, to)
+
+	var got []string
+	for _, e := range instances {
+		got = append(got, e.Start.UTC().Format("Mon 15:04")+" "+e.Summary)
+	}
+	assert.Equal(t, []string{
+		"Mon 08:30 Daily standup",
+		"Tue 08:30 Daily standup",
+		"Tue 13:00 Code review, backend",
+		"Thu 10:00 Daily standup (moved)",
+		"Fri 08:30 Daily standup",
+	}, got)
+	for _, e := range instances {
+		assert.Empty(t, e.RRule)
+	}
+	require.Len(t, skipped, 1)
+	assert.Equal(t, "holiday", skipped[0].Event.UID)
+	assert.Equal(t, "all-day event", skipped[0].Reason)
+}
+
+func TestExpandRules(t *testing.T) {
+	start := time.Date(2025, 1, 31, 10, 0, 0, 0, time.UTC)
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		rule     string
+		expected []string
+	}{
+		{"FREQ=DAILY;COUNT=3", []string{"2025-01-31", "2025-02-01", "2025-02-02"}},
+		{"FREQ=DAILY;INTERVAL=2;BYDAY=MO,WE;COUNT=3", []string{"2025-02-10", "2025-02-12", "2025-02-24"}},
+		{"FREQ=WEEKLY;INTERVAL=2;COUNT=3", []string{"2025-01-31", "2025-02-14", "2025-02-28"}},
+		{"FREQ=WEEKLY;BYDAY=MO,FR;UNTIL=20250207", []string{"2025-01-31", "2025-02-03", "2025-02-07"}},
+		{"FREQ=MONTHLY;COUNT=3", []string{"2025-01-31", "2025-03-31", "2025-05-31"}},
+		{"FREQ=YEARLY", []string{"2025-01-31"}},
+	}
+	for _, tt := range tests {
+		instances, skipped := Expand([]Event{{UID: "x", Start: start, End: start.Add(time.Hour), RRule: tt.rule}}, from, to)
+		require.Empty(t, skipped, tt.rule)
+		var got []string
+		for _, e := range instances {
+			got = append(got, e.Start.Format("2006-01-02"))
+			assert.Equal(t, time.Hour, e.End.Sub(e.Start))
+		}
+		assert.Equal(t, tt.expected, got, tt.rule)
+	}
+}
+
+func TestExpandSkipsUnsupportedRules(t *testing.T) {
+	start := time.Date(2025, 1, 6, 10, 0, 0, 0, time.UTC)
+	for _, rule := range []string{"FREQ=MONTHLY;BYDAY=1MO", "FREQ=HOURLY", "FREQ=MONTHLY;BYSETPOS=-1", "COUNT=2"} {
+		instances, skipped := Expand([]Event{{UID: "x", Start: start, End: start, RRule: rule}}, start, start.AddDate(1, 0, 0))
+		assert.Empty(t, instances, rule)
+		assert.Len(t, skipped, 1, rule)
+	}
+}
+
+func TestExpandLimitsEndlessRulesToRange(t *testing.T) {
+	start := time.Date(2020, 1, 1, 9, 0, 0, 0, time.UTC)
+	from := time.Date(2025, 11, 1, 0, 0, 0, 0, time.UTC)
+
+	instances, _ := Expand([]Event{{UID: "x", Start: start, End: start, RRule: "FREQ=DAILY"}}, from, from.AddDate(0, 0, 30))
+
+	require.Len(t, instances, 30)
+	assert.Equal(t, "2025-11-01T09:00:00Z", instances[0].Start.Format(time.RFC3339))
+}
@@ -0,0 +1,245 @@
+package ical
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// rule is the supported subset of RRULE: FREQ=DAILY|WEEKLY|MONTHLY|YEARLY with INTERVAL,
+// COUNT, UNTIL and plain weekdays in BYDAY
+type rule struct {
+	freq     string
+	interval int
+	count    int
+	until    time.Time
+	byDay    []time.Weekday
+}
+
+// weekdays maps the BYDAY codes onto weekdays
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday, "TH": time.Thursday,
+	"FR": time.Friday, "SA": time.Saturday, "SU": time.Sunday,
+}
+
+// parseRule reads an RRULE value. UNTIL dates without time are read in loc.
+func parseRule(value string, loc *time.Location) (*rule, error) {
+	r := &rule{interval: 1}
+	for _, part := range strings.Split(value, ";") {
+		key, val, _ := strings.Cut(part, "=")
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			r.freq = strings.ToUpper(val)
+		case "INTERVAL":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid INTERVAL '%s'", val)
+			}
+			r.interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid COUNT '%s'", val)
+			}
+			r.count = n
+		case "UNTIL":
+			until, allDay, err := parseDateTime(property{name: "UNTIL", value: val}, loc)
+			if err != nil {
+				return nil, err
+			}
+			if allDay {
+				// A date includes the whole day
+				until = until.AddDate(0, 0, 1).Add(-time.Second)
+			}
+			r.until = until
+		case "BYDAY":
+			for _, code := range strings.Split(val, ",") {
+				day, ok := weekdays[strings.ToUpper(code)]
+				if !ok {
+					return nil, fmt.Errorf("unsupported BYDAY '%s'", code)
+				}
+				r.byDay = append(r.byDay, day)
+			}
+		case "WKST":
+			// Only matters for WEEKLY rules with INTERVAL > 1 and weekdays before WKST; ignored
+		default:
+			return nil, fmt.Errorf("unsupported RRULE part '%s'", part)
+		}
+	}
+
+	switch r.freq {
+	case "DAILY", "WEEKLY", "MONTHLY", "YEARLY":
+	case "":
+		return nil, fmt.Errorf("RRULE without FREQ")
+	default:
+		return nil, fmt.Errorf("unsupported FREQ '%s'", r.freq)
+	}
+	if len(r.byDay) > 0 && r.freq != "DAILY" && r.freq != "WEEKLY" {
+		return nil, fmt.Errorf("BYDAY is only supported with DAILY or WEEKLY")
+	}
+	return r, nil
+}
+
+// starts calls yield with the start times of the rule's instances, beginning at start and
+// ending before limit, until it returns false or the rule ends
+func (r *rule) starts(start, limit time.Time, yield func(time.Time) bool) {
+	emitted := 0
+	emit := func(t time.Time) bool {
+		if !t.Before(limit) || (!r.until.IsZero() && t.After(r.until)) {
+			return false
+		}
+		emitted++
+		if !yield(t) {
+			return false
+		}
+		return r.count == 0 || emitted < r.count
+	}
+
+	// at keeps the wall-clock time of start on another day, also across DST changes
+	at := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, start.Hour(), start.Minute(), start.Second(), 0, start.Location())
+	}
+
+	switch r.freq {
+	case "DAILY":
+		for k := 0; ; k += r.interval {
+			t := start.AddDate(0, 0, k)
+			if !t.Before(limit) {
+				return
+			}
+			if len(r.byDay) > 0 && !containsWeekday(r.byDay, t.Weekday()) {
+				continue
+			}
+			if !emit(t) {
+				return
+			}
+		}
+	case "WEEKLY":
+		days := r.byDay
+		if len(days) == 0 {
+			days = []time.Weekday{start.Weekday()}
+		}
+		// Walk the weeks from Monday to Sunday
+		offsets := make([]int, len(days))
+		for i, day := range days {
+			offsets[i] = (int(day) + 6) % 7
+		}
+		sort.Ints(offsets)
+		monday := at(start.Year(), start.Month(), start.Day()-(int(start.Weekday())+6)%7)
+		for week := 0; ; week += r.interval {
+			for _, offset := range offsets {
+				t := at(monday.Year(), monday.Month(), monday.Day()+7*week+offset)
+				if t.Before(start) {
+					continue
+				}
+				if !emit(t) {
+					return
+				}
+			}
+		}
+	case "MONTHLY", "YEARLY":
+		for k := 0; ; k += r.interval {
+			months := k
+			if r.freq == "YEARLY" {
+				months = 12 * k
+			}
+			t := at(start.Year(), start.Month()+time.Month(months), start.Day())
+			if !t.Before(limit) {
+				return
+			}
+			// Days that do not exist in a month (e.g. the 31st) are skipped
+			if t.Day() != start.Day() {
+				continue
+			}
+			if !emit(t) {
+				return
+			}
+		}
+	}
+}
+
+// containsWeekday reports whether day is in days
+func containsWeekday(days []time.Weekday, day time.Weekday) bool {
+	for _, d := range days {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+// excluded reports whether start is one of the EXDATEs
+func excluded(exdates []time.Time, start time.Time) bool {
+	for _, exdate := range exdates {
+		if exdate.Equal(start) {
+			return true
+		}
+	}
+	return false
+}
+
+// Skipped is an event that could not be expanded
+type Skipped struct {
+	Event  Event
+	Reason string
+}
+
+// Expand returns the instances of the events that start within [from, to), ordered by
+// start time. Recurring events are expanded, instances listed in EXDATE are left out and
+// instances with a RECURRENCE-ID override replace the generated ones. Cancelled instances
+// are dropped. All-day events and events with unsupported rules are returned as skipped.
+func Expand(events []Event, from, to time.Time) ([]Event, []Skipped) {
+	// Overrides are keyed by UID and the start of the instance they replace
+	overridden := make(map[string]bool)
+	for _, e := range events {
+		if !e.RecurrenceID.IsZero() {
+			overridden[e.UID+"|"+e.RecurrenceID.UTC().Format(time.RFC3339)] = true
+		}
+	}
+
+	var instances []Event
+	var skipped []Skipped
+	inRange := func(t time.Time) bool { return !t.Before(from) && t.Before(to) }
+
+	for _, e := range events {
+		if e.Cancelled {
+			continue
+		}
+		if e.AllDay {
+			if inRange(e.Start) || e.RRule != "" {
+				skipped = append(skipped, Skipped{Event: e, Reason: "all-day event"})
+			}
+			continue
+		}
+
+		if e.RRule == "" || !e.RecurrenceID.IsZero() {
+			if inRange(e.Start) {
+				instances = append(instances, e)
+			}
+			continue
+		}
+
+		r, err := parseRule(e.RRule, e.Start.Location())
+		if err != nil {
+			skipped = append(skipped, Skipped{Event: e, Reason: err.Error()})
+			continue
+		}
+
+		duration := e.End.Sub(e.Start)
+		r.starts(e.Start, to, func(start time.Time) bool {
+			if !inRange(start) || overridden[e.UID+"|"+start.UTC().Format(time.RFC3339)] || excluded(e.ExDates, start) {
+				return true
+			}
+			instance := e
+			instance.Start, instance.End = start, start.Add(duration)
+			instance.RRule, instance.ExDates = "", nil
+			instances = append(instances, instance)
+			return true
+		})
+	}
+
+	sort.SliceStable(instances, func(i, j int) bool { return instances[i].Start.Before(instances[j].Start) })
+	return instances, skipped
+}
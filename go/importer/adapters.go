@@ -0,0 +1,208 @@
+package importer
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	pkgmodel "timesheet/go/model"
+)
+
+// AdapterOptions controls how the columns of other trackers map onto categories
+type AdapterOptions struct {
+	// CategoryFrom selects the source of the category: client, project or tag
+	CategoryFrom string
+	// DefaultCategory is used when the selected source is empty
+	DefaultCategory string
+	// DayStart is the time of day where entries without clock times (Harvest) begin
+	DayStart string
+}
+
+// adapter describes the CSV export of another time tracker
+type adapter struct {
+	// columns must all be present in the header
+	columns   []string
+	newParser func(options AdapterOptions) RowParser
+}
+
+// adapters maps the accepted import formats onto their adapters
+var adapters = map[string]adapter{
+	"toggl": {
+		columns:   []string{"Project", "Description", "Start date", "Start time", "End date", "End time"},
+		newParser: togglParser,
+	},
+	"clockify": {
+		columns:   []string{"Project", "Description", "Start Date", "Start Time", "End Date", "End Time"},
+		newParser: clockifyParser,
+	},
+	"harvest": {
+		columns:   []string{"Date", "Project", "Task", "Notes", "Hours"},
+		newParser: harvestParser,
+	},
+}
+
+// ParseAdapterOptions validates the adapter options and fills in defaults
+func ParseAdapterOptions(categoryFrom, defaultCategory, dayStart string) (AdapterOptions, error) {
+	options := AdapterOptions{CategoryFrom: categoryFrom, DefaultCategory: defaultCategory, DayStart: dayStart}
+	if options.CategoryFrom == "" {
+		options.CategoryFrom = "client"
+	}
+	if options.CategoryFrom != "client" && options.CategoryFrom != "project" && options.CategoryFrom != "tag" {
+		return options, fmt.Errorf("invalid category_from '%s'. Expected client, project or tag", categoryFrom)
+	}
+	if options.DefaultCategory == "" {
+		options.DefaultCategory = "other"
+	}
+	if options.DayStart == "" {
+		options.DayStart = "09:00"
+	}
+	if _, err := time.Parse("15:04", options.DayStart); err != nil {
+		return options, fmt.Errorf("invalid day_start '%s'. Expected HH:MM", dayStart)
+	}
+	return options, nil
+}
+
+// AdapterParser returns the row parser for an import format after checking the CSV header
+func AdapterParser(format string, table *csvTable, options AdapterOptions) (RowParser, error) {
+	a, ok := adapters[format]
+	if !ok {
+		return nil, fmt.Errorf("invalid format '%s'. Expected mapping, toggl, clockify or harvest", format)
+	}
+	for _, column := range a.columns {
+		if !table.hasColumn(column) {
+			return nil, fmt.Errorf("column '%s' not found in CSV header; is this a %s export?", column, format)
+		}
+	}
+	return a.newParser(options), nil
+}
+
+// foreignTask names the task after the project, adding the tracker's own task if there is one
+func foreignTask(project, task, description string) string {
+	switch {
+	case project != "" && task != "":
+		return project + " - " + task
+	case project != "":
+		return project
+	case task != "":
+		return task
+	}
+	return description
+}
+
+// foreignCategory picks the category according to the options
+func foreignCategory(options AdapterOptions, client, project, tags string) string {
+	category := ""
+	switch options.CategoryFrom {
+	case "client":
+		category = client
+	case "project":
+		category = project
+	case "tag":
+		// Only the first of several comma separated tags is used
+		category = strings.TrimSpace(strings.Split(tags, ",")[0])
+	}
+	if category == "" {
+		return options.DefaultCategory
+	}
+	return category
+}
+
+// parseForeignDateTime combines separate date and time columns using the given date layouts
+func parseForeignDateTime(date, clock string, dateLayouts []string) (time.Time, error) {
+	for _, dateLayout := range dateLayouts {
+		for _, timeLayout := range timeLayouts {
+			if t, err := time.Parse(dateLayout+" "+timeLayout, date+" "+clock); err == nil {
+				return t, nil
+			}
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized date/time '%s %s'", date, clock)
+}
+
+// startEndParser builds a parser for exports with separate start/end date and time columns
+func startEndParser(options AdapterOptions, dateLayouts []string, columns map[string]string) RowParser {
+	return func(table *csvTable, record []string) (pkgmodel.TimeEntryRequest, []string) {
+		value := func(field string) string { return table.value(record, columns[field]) }
+
+		description := value("description")
+		req := pkgmodel.TimeEntryRequest{
+			Task:        foreignTask(value("project"), value("task"), description),
+			Description: description,
+			Category:    foreignCategory(options, value("client"), value("project"), value("tags")),
+		}
+
+		start, err := parseForeignDateTime(value("start_date"), value("start_time"), dateLayouts)
+		if err != nil {
+			return req, []string{"start: " + err.Error()}
+		}
+		end, err := parseForeignDateTime(value("end_date"), value("end_time"), dateLayouts)
+		if err != nil {
+			return req, []string{"end: " + err.Error()}
+		}
+		req.StartTime = start.Format(time.RFC3339)
+		req.EndTime = end.Format(time.RFC3339)
+		return req, nil
+	}
+}
+
+// togglParser reads the detailed CSV export of Toggl Track
+func togglParser(options AdapterOptions) RowParser {
+	return startEndParser(options, []string{"2006-01-02"}, map[string]string{
+		"project": "Project", "client": "Client", "task": "Task", "description": "Description", "tags": "Tags",
+		"start_date": "Start date", "start_time": "Start time", "end_date": "End date", "end_time": "End time",
+	})
+}
+
+// clockifyParser reads the detailed CSV report of Clockify, which uses US dates by default
+func clockifyParser(options AdapterOptions) RowParser {
+	return startEndParser(options, []string{"01/02/2006", "2006-01-02", "02.01.2006"}, map[string]string{
+		"project": "Project", "client": "Client", "task": "Task", "description": "Description", "tags": "Tags",
+		"start_date": "Start Date", "start_time": "Start Time", "end_date": "End Date", "end_time": "End Time",
+	})
+}
+
+// parseHours accepts decimal hours ("1.5") or hours and minutes ("1:30") and returns minutes
+func parseHours(value string) (int, error) {
+	if hours, err := strconv.ParseFloat(value, 64); err == nil && hours > 0 {
+		return int(math.Round(hours * 60)), nil
+	}
+	return parseDurationMinutes(value)
+}
+
+// harvestParser reads the detailed time report of Harvest. Harvest only records hours per
+// day, so the entries of a day are laid out back to back starting at options.DayStart.
+func harvestParser(options AdapterOptions) RowParser {
+	nextStart := make(map[string]time.Time)
+	return func(table *csvTable, record []string) (pkgmodel.TimeEntryRequest, []string) {
+		notes := table.value(record, "Notes")
+		project := table.value(record, "Project")
+		req := pkgmodel.TimeEntryRequest{
+			Task:        foreignTask(project, table.value(record, "Task"), notes),
+			Description: notes,
+			Category:    foreignCategory(options, table.value(record, "Client"), project, ""),
+		}
+
+		date := table.value(record, "Date")
+		start, ok := nextStart[date]
+		if !ok {
+			var err error
+			start, err = parseForeignDateTime(date, options.DayStart, []string{"2006-01-02", "01/02/2006", "02.01.2006"})
+			if err != nil {
+				return req, []string{"date: " + err.Error()}
+			}
+		}
+
+		minutes, err := parseHours(table.value(record, "Hours"))
+		if err != nil {
+			return req, []string{"hours: " + err.Error()}
+		}
+		end := start.Add(time.Duration(minutes) * time.Minute)
+		nextStart[date] = end
+
+		req.StartTime = start.Format(time.RFC3339)
+		req.EndTime = end.Format(time.RFC3339)
+		return req, nil
+	}
+}
@@ -0,0 +1,119 @@
+package importer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pkgmodel "timesheet/go/model"
+)
+
+// parseFixture reads a tracker export from testdata and parses it with the format's adapter
+func parseFixture(t *testing.T, format string, options AdapterOptions) []pkgmodel.ImportRow {
+	f, err := os.Open(filepath.Join("testdata", format+".csv"))
+	require.NoError(t, err)
+	defer f.Close()
+
+	table, err := readCSV(f, ',')
+	require.NoError(t, err)
+
+	parser, err := AdapterParser(format, table, options)
+	require.NoError(t, err)
+
+	rows := parseRows(table, parser)
+	for _, row := range rows {
+		require.Empty(t, row.Errors, "line %d", row.Line)
+	}
+	return rows
+}
+
+func defaultAdapterOptions(t *testing.T) AdapterOptions {
+	options, err := ParseAdapterOptions("", "", "")
+	require.NoError(t, err)
+	return options
+}
+
+func TestTogglAdapter(t *testing.T) {
+	rows := parseFixture(t, "toggl", defaultAdapterOptions(t))
+
+	require.Len(t, rows, 3)
+	assert.Equal(t, pkgmodel.TimeEntryRequest{
+		Task: "Website Relaunch", Description: "Landing page layout", Category: "Acme Corp",
+		StartTime: "2025-11-10T09:00:00Z", EndTime: "2025-11-10T10:30:00Z",
+	}, rows[0].Entry)
+	assert.Equal(t, "Internal - Admin", rows[1].Entry.Task)
+	assert.Equal(t, "other", rows[1].Entry.Category)
+	assert.Equal(t, "2025-11-11T00:15:00Z", rows[2].Entry.EndTime)
+}
+
+func TestTogglAdapterCategoryFromTag(t *testing.T) {
+	options, err := ParseAdapterOptions("tag", "untagged", "")
+	require.NoError(t, err)
+
+	rows := parseFixture(t, "toggl", options)
+
+	assert.Equal(t, "design", rows[0].Entry.Category)
+	assert.Equal(t, "untagged", rows[1].Entry.Category)
+	assert.Equal(t, "ops", rows[2].Entry.Category)
+}
+
+func TestClockifyAdapter(t *testing.T) {
+	options, err := ParseAdapterOptions("project", "", "")
+	require.NoError(t, err)
+
+	rows := parseFixture(t, "clockify", options)
+
+	require.Len(t, rows, 2)
+	assert.Equal(t, pkgmodel.TimeEntryRequest{
+		Task: "Mobile App - Planning", Description: "Sprint planning", Category: "Mobile App",
+		StartTime: "2025-11-10T09:00:00Z", EndTime: "2025-11-10T10:00:00Z",
+	}, rows[0].Entry)
+	assert.Equal(t, "Mobile App", rows[1].Entry.Task)
+	assert.Equal(t, "2025-11-10T13:15:00Z", rows[1].Entry.StartTime)
+	assert.Equal(t, "2025-11-10T15:45:00Z", rows[1].Entry.EndTime)
+}
+
+func TestHarvestAdapterStacksEntriesPerDay(t *testing.T) {
+	options, err := ParseAdapterOptions("", "", "08:30")
+	require.NoError(t, err)
+
+	rows := parseFixture(t, "harvest", options)
+
+	require.Len(t, rows, 3)
+	assert.Equal(t, pkgmodel.TimeEntryRequest{
+		Task: "TPS Reports - Development", Description: "Cover sheet", Category: "Initech",
+		StartTime: "2025-11-10T08:30:00Z", EndTime: "2025-11-10T10:00:00Z",
+	}, rows[0].Entry)
+	assert.Equal(t, "2025-11-10T10:00:00Z", rows[1].Entry.StartTime)
+	assert.Equal(t, "2025-11-10T10:15:00Z", rows[1].Entry.EndTime)
+	assert.Equal(t, "other", rows[2].Entry.Category)
+	assert.Equal(t, "2025-11-11T08:30:00Z", rows[2].Entry.StartTime)
+	assert.Equal(t, "2025-11-11T10:30:00Z", rows[2].Entry.EndTime)
+}
+
+func TestAdapterParserRejectsWrongExport(t *testing.T) {
+	f, err := os.Open(filepath.Join("testdata", "harvest.csv"))
+	require.NoError(t, err)
+	defer f.Close()
+	table, err := readCSV(f, ',')
+	require.NoError(t, err)
+
+	_, err = AdapterParser("toggl", table, defaultAdapterOptions(t))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "is this a toggl export?")
+
+	_, err = AdapterParser("kimai", table, defaultAdapterOptions(t))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid format 'kimai'")
+}
+
+func TestParseAdapterOptionsInvalid(t *testing.T) {
+	_, err := ParseAdapterOptions("user", "", "")
+	assert.Error(t, err)
+
+	_, err = ParseAdapterOptions("", "", "9am")
+	assert.Error(t, err)
+}
@@ -0,0 +1,105 @@
+package importer
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	pkghandler "timesheet/go/handler"
+	"timesheet/go/ical"
+	pkgmodel "timesheet/go/model"
+	pkgutil "timesheet/go/util"
+)
+
+// maxCalendarRange limits how far recurring events are expanded in one import
+const maxCalendarRange = 366 * 24 * time.Hour
+
+// ParseCategoryRules reads the keyword rules of a calendar import from JSON
+func ParseCategoryRules(value string) ([]pkgmodel.CategoryRule, error) {
+	var rules []pkgmodel.CategoryRule
+	if value == "" {
+		return rules, nil
+	}
+	if err := json.Unmarshal([]byte(value), &rules); err != nil {
+		return nil, fmt.Errorf("invalid rules: %w", err)
+	}
+	for i, rule := range rules {
+		if strings.TrimSpace(rule.Keyword) == "" || strings.TrimSpace(rule.Category) == "" {
+			return nil, fmt.Errorf("rule %d needs a keyword and a category", i+1)
+		}
+	}
+	return rules, nil
+}
+
+// GuessCategory returns the category of the first rule whose keyword occurs in the summary
+// or description, ignoring case, together with the matched keyword
+func GuessCategory(rules []pkgmodel.CategoryRule, defaultCategory, summary, description string) (string, string) {
+	text := strings.ToLower(summary + "\n" + description)
+	for _, rule := range rules {
+		if strings.Contains(text, strings.ToLower(rule.Keyword)) {
+			return rule.Category, rule.Keyword
+		}
+	}
+	return defaultCategory, ""
+}
+
+// CalendarProposals turns event instances into proposed time entries. Instances are converted
+// to local wall-clock time and validated like imported rows.
+func CalendarProposals(db *sql.DB, instances []ical.Event, rules []pkgmodel.CategoryRule, defaultCategory string, createMissing bool) ([]pkgmodel.CalendarProposal, error) {
+	proposals := make([]pkgmodel.CalendarProposal, 0, len(instances))
+	rows := make([]pkgmodel.ImportRow, 0, len(instances))
+
+	for i, e := range instances {
+		category, keyword := GuessCategory(rules, defaultCategory, e.Summary, e.Description)
+		req := pkgmodel.TimeEntryRequest{
+			Task:        strings.TrimSpace(e.Summary),
+			Description: e.Description,
+			Category:    category,
+			StartTime:   wallClock(e.Start.In(time.Local)).Format(time.RFC3339),
+			EndTime:     wallClock(e.End.In(time.Local)).Format(time.RFC3339),
+		}
+		proposals = append(proposals, pkgmodel.CalendarProposal{UID: e.UID, Entry: req, MatchedKeyword: keyword})
+		rows = append(rows, validatedRow(i+1, req))
+	}
+
+	imp, err := ValidateAgainstDB(db, rows, createMissing)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range proposals {
+		proposals[i].Errors = imp.Rows[i].Errors
+		if len(proposals[i].Errors) > 0 {
+			continue
+		}
+		startTime, _ := time.Parse(time.RFC3339, proposals[i].Entry.StartTime)
+		var count int
+		err := db.QueryRow("SELECT COUNT(*) FROM time_entries WHERE task = ? AND start_time = ? AND deleted_at IS NULL",
+			proposals[i].Entry.Task, pkgutil.FormatTimeForDB(startTime)).Scan(&count)
+		if err != nil {
+			return nil, err
+		}
+		proposals[i].Exists = count > 0
+	}
+	return proposals, nil
+}
+
+// calendarSkips describes the events that were left out of the proposals
+func calendarSkips(skipped []ical.Skipped) []pkgmodel.CalendarSkip {
+	skips := make([]pkgmodel.CalendarSkip, 0, len(skipped))
+	for _, s := range skipped {
+		skips = append(skips, pkgmodel.CalendarSkip{UID: s.Event.UID, Summary: s.Event.Summary, Reason: s.Reason})
+	}
+	return skips
+}
+
+// validatedRow wraps a time entry request as an import row with its validation errors
+func validatedRow(line int, req pkgmodel.TimeEntryRequest) pkgmodel.ImportRow {
+	row := pkgmodel.ImportRow{Line: line, Entry: req}
+	if _, _, _, err := pkghandler.ParseAndValidateTimeEntry(req); err != nil {
+		row.Errors = []string{err.Error()}
+	}
+	return row
+}
@@ -0,0 +1,131 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pkgmodel "timesheet/go/model"
+)
+
+func TestParseCategoryRules(t *testing.T) {
+	rules, err := ParseCategoryRules(`[{"keyword": "standup", "category": "meetings"}]`)
+	require.NoError(t, err)
+	assert.Equal(t, []pkgmodel.CategoryRule{{Keyword: "standup", Category: "meetings"}}, rules)
+
+	_, err = ParseCategoryRules(`[{"keyword": "", "category": "meetings"}]`)
+	assert.Error(t, err)
+	_, err = ParseCategoryRules(`{"standup": "meetings"}`)
+	assert.Error(t, err)
+}
+
+func TestGuessCategory(t *testing.T) {
+	rules := []pkgmodel.CategoryRule{
+		{Keyword: "standup", Category: "meetings"},
+		{Keyword: "review", Category: "project work"},
+	}
+
+	category, keyword := GuessCategory(rules, "other", "Team Standup", "")
+	assert.Equal(t, "meetings", category)
+	assert.Equal(t, "standup", keyword)
+
+	category, _ = GuessCategory(rules, "other", "Sync", "Code REVIEW of the importer")
+	assert.Equal(t, "project work", category)
+
+	category, keyword = GuessCategory(rules, "other", "Lunch", "")
+	assert.Equal(t, "other", category)
+	assert.Empty(t, keyword)
+}
+
+// postCalendar uploads the calendar fixture to the preview endpoint
+func postCalendar(t *testing.T, fields map[string]string) *httptest.ResponseRecorder {
+	data, err := os.ReadFile("testdata/calendar.ics")
+	require.NoError(t, err)
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	for name, value := range fields {
+		require.NoError(t, form.WriteField(name, value))
+	}
+	file, err := form.CreateFormFile("file", "calendar.ics")
+	require.NoError(t, err)
+	_, err = file.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, form.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/api/import/ics", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	w := httptest.NewRecorder()
+	PreviewCalendarImport(w, req)
+	return w
+}
+
+func TestPreviewCalendarImport(t *testing.T) {
+	db := setupTestDB(t)
+	_, err := db.Exec(`INSERT INTO time_entries (task, category, start_time, end_time, duration, date)
+		VALUES ('Team Standup', 'other', '2025-11-11T09:30:00Z', '2025-11-11T09:45:00Z', 15, '2025-11-11')`)
+	require.NoError(t, err)
+
+	w := postCalendar(t, map[string]string{
+		"from":  "2025-11-10",
+		"to":    "2025-11-11",
+		"rules": `[{"keyword": "review", "category": "project work"}]`,
+	})
+
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var preview pkgmodel.CalendarPreview
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&preview))
+	require.Len(t, preview.Proposals, 3)
+
+	first := preview.Proposals[0]
+	assert.Equal(t, "standup-1", first.UID)
+	assert.Equal(t, pkgmodel.TimeEntryRequest{
+		Task: "Team Standup", Category: "other", StartTime: "2025-11-10T09:30:00Z", EndTime: "2025-11-10T09:45:00Z",
+	}, first.Entry)
+	assert.False(t, first.Exists)
+	assert.True(t, preview.Proposals[1].Exists)
+
+	review := preview.Proposals[2]
+	assert.Equal(t, "project work", review.Entry.Category)
+	assert.Equal(t, "review", review.MatchedKeyword)
+	assert.Equal(t, "2025-11-11T15:00:00Z", review.Entry.EndTime)
+	assert.Empty(t, review.Errors)
+
+	assert.Empty(t, preview.Skipped)
+}
+
+func TestPreviewCalendarImportRequiresRange(t *testing.T) {
+	setupTestDB(t)
+
+	w := postCalendar(t, map[string]string{"from": "2025-11-10"})
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestConfirmCalendarImport(t *testing.T) {
+	db := setupTestDB(t)
+	body := `{"entries": [
+		{"task": "Team Standup", "category": "meetings", "start_time": "2025-11-10T09:30:00Z", "end_time": "2025-11-10T09:45:00Z"}
+	], "create_missing": true}`
+
+	req := httptest.NewRequest(http.MethodPost, "/api/import/ics/confirm", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+	ConfirmCalendarImport(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var result pkgmodel.ImportResult
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&result))
+	assert.Equal(t, 1, result.Imported)
+	assert.Equal(t, []string{"meetings"}, result.CreatedCategories)
+
+	var duration int
+	require.NoError(t, db.QueryRow("SELECT duration FROM time_entries WHERE task = 'Team Standup'").Scan(&duration))
+	assert.Equal(t, 15, duration)
+}
@@ -0,0 +1,238 @@
+// Package importer turns CSV files into time entries, validates them against
+// the database and stores them in a single transaction.
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	pkghandler "timesheet/go/handler"
+	pkgmodel "timesheet/go/model"
+)
+
+// csvTable is a CSV file split into its header and records
+type csvTable struct {
+	columns map[string]int
+	records [][]string
+	lines   []int
+}
+
+// readCSV reads a CSV file whose first record is the header
+func readCSV(r io.Reader, delimiter rune) (*csvTable, error) {
+	reader := csv.NewReader(r)
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("CSV file is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV header: %w", err)
+	}
+
+	table := &csvTable{columns: make(map[string]int)}
+	for i, name := range header {
+		// Spreadsheet programs like to prepend a byte order mark
+		name = strings.TrimPrefix(name, "\ufeff")
+		table.columns[normalizeColumn(name)] = i
+	}
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+		table.records = append(table.records, record)
+		table.lines = append(table.lines, line)
+	}
+	return table, nil
+}
+
+// normalizeColumn makes column lookups case and whitespace insensitive
+func normalizeColumn(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// hasColumn reports whether the header contains the column
+func (t *csvTable) hasColumn(name string) bool {
+	_, ok := t.columns[normalizeColumn(name)]
+	return ok
+}
+
+// value returns the trimmed cell of the named column, or "" if the column is not present
+func (t *csvTable) value(record []string, name string) string {
+	if name == "" {
+		return ""
+	}
+	i, ok := t.columns[normalizeColumn(name)]
+	if !ok || i >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[i])
+}
+
+// RowParser converts one CSV record into a time entry request, collecting problems as messages
+type RowParser func(table *csvTable, record []string) (pkgmodel.TimeEntryRequest, []string)
+
+// parseRows applies the row parser to every record and validates the result
+func parseRows(table *csvTable, parser RowParser) []pkgmodel.ImportRow {
+	rows := make([]pkgmodel.ImportRow, 0, len(table.records))
+	for i, record := range table.records {
+		req, problems := parser(table, record)
+		if len(problems) == 0 {
+			if _, _, _, err := pkghandler.ParseAndValidateTimeEntry(req); err != nil {
+				problems = append(problems, err.Error())
+			}
+		}
+		rows = append(rows, pkgmodel.ImportRow{Line: table.lines[i], Entry: req, Errors: problems})
+	}
+	return rows
+}
+
+// dateTimeLayouts are tried in order for timestamps without an explicit layout
+var dateTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"02.01.2006 15:04:05",
+	"02.01.2006 15:04",
+}
+
+// dateLayouts and timeLayouts are combined when date and time come from separate columns
+var dateLayouts = []string{"2006-01-02", "02.01.2006"}
+var timeLayouts = []string{"15:04:05", "15:04", "3:04:05 PM", "3:04 PM", "3:04PM"}
+
+// wallClock keeps the local clock reading of t but marks it as UTC, the way the frontend stores times
+func wallClock(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+}
+
+// parseDateTime parses a timestamp using layout, or the known layouts if layout is empty
+func parseDateTime(value, layout string) (time.Time, error) {
+	if layout != "" {
+		t, err := time.Parse(layout, value)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("'%s' does not match layout '%s'", value, layout)
+		}
+		return wallClock(t), nil
+	}
+	for _, layout := range dateTimeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return wallClock(t), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized date/time '%s'", value)
+}
+
+// parseDateAndTime combines a date and a time of day from separate columns
+func parseDateAndTime(date, clock, layout string) (time.Time, error) {
+	if layout != "" {
+		return parseDateTime(date+" "+clock, layout)
+	}
+	for _, dateLayout := range dateLayouts {
+		for _, timeLayout := range timeLayouts {
+			if t, err := time.Parse(dateLayout+" "+timeLayout, date+" "+clock); err == nil {
+				return t, nil
+			}
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized date/time '%s %s'", date, clock)
+}
+
+// parseDurationMinutes accepts plain minutes ("90") or hours and minutes ("1:30", "1:30:00")
+func parseDurationMinutes(value string) (int, error) {
+	if minutes, err := strconv.Atoi(value); err == nil && minutes > 0 {
+		return minutes, nil
+	}
+	parts := strings.Split(value, ":")
+	if len(parts) == 2 || len(parts) == 3 {
+		h, errH := strconv.Atoi(parts[0])
+		m, errM := strconv.Atoi(parts[1])
+		if errH == nil && errM == nil && h >= 0 && m >= 0 && m < 60 && h*60+m > 0 {
+			return h*60 + m, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid duration '%s'", value)
+}
+
+// ValidateMapping checks that the mapping names existing columns and covers the required fields
+func ValidateMapping(table *csvTable, mapping pkgmodel.ImportMapping) error {
+	if mapping.Task == "" || mapping.Start == "" {
+		return errors.New("mapping requires the task and start columns")
+	}
+	if mapping.End == "" && mapping.Duration == "" {
+		return errors.New("mapping requires an end or duration column")
+	}
+	if mapping.Category == "" && mapping.DefaultCategory == "" {
+		return errors.New("mapping requires a category column or a default_category")
+	}
+
+	mapped := []string{mapping.Task, mapping.Description, mapping.Category, mapping.Start, mapping.End, mapping.Date, mapping.Duration}
+	for _, column := range mapped {
+		if column != "" && !table.hasColumn(column) {
+			return fmt.Errorf("column '%s' not found in CSV header", column)
+		}
+	}
+	return nil
+}
+
+// MappingParser returns a RowParser that reads the columns named by the mapping
+func MappingParser(mapping pkgmodel.ImportMapping) RowParser {
+	return func(table *csvTable, record []string) (pkgmodel.TimeEntryRequest, []string) {
+		var problems []string
+		req := pkgmodel.TimeEntryRequest{
+			Task:        table.value(record, mapping.Task),
+			Description: table.value(record, mapping.Description),
+			Category:    table.value(record, mapping.Category),
+		}
+		if req.Category == "" {
+			req.Category = mapping.DefaultCategory
+		}
+
+		parse := func(value string) (time.Time, error) {
+			if mapping.Date != "" {
+				return parseDateAndTime(table.value(record, mapping.Date), value, mapping.TimeLayout)
+			}
+			return parseDateTime(value, mapping.TimeLayout)
+		}
+
+		start, err := parse(table.value(record, mapping.Start))
+		if err != nil {
+			return req, append(problems, "start: "+err.Error())
+		}
+		req.StartTime = start.Format(time.RFC3339)
+
+		if endValue := table.value(record, mapping.End); endValue != "" {
+			end, err := parse(endValue)
+			if err != nil {
+				return req, append(problems, "end: "+err.Error())
+			}
+			// An end time of day before the start time means the entry ran past midnight
+			if mapping.Date != "" && end.Before(start) {
+				end = end.AddDate(0, 0, 1)
+			}
+			req.EndTime = end.Format(time.RFC3339)
+		} else if durationValue := table.value(record, mapping.Duration); durationValue != "" {
+			minutes, err := parseDurationMinutes(durationValue)
+			if err != nil {
+				return req, append(problems, "duration: "+err.Error())
+			}
+			req.EndTime = start.Add(time.Duration(minutes) * time.Minute).Format(time.RFC3339)
+		}
+
+		return req, problems
+	}
+}
@@ -0,0 +1,264 @@
+package importer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	pkgglobal "timesheet/go/global"
+	pkghandler "timesheet/go/handler"
+	"timesheet/go/ical"
+	pkgmodel "timesheet/go/model"
+)
+
+// maxImportSize limits the size of uploaded import files
+const maxImportSize = 32 << 20
+
+// importOptions holds the form fields shared by all import formats
+type importOptions struct {
+	dryRun        bool
+	createMissing bool
+	delimiter     rune
+	overlap       pkghandler.OverlapPolicy
+}
+
+// parseImportOptions reads mode, create_missing, delimiter and overlap from the query or form
+func parseImportOptions(r *http.Request) (importOptions, error) {
+	options := importOptions{dryRun: true, delimiter: ','}
+
+	policy, err := pkghandler.RequestOverlapPolicy(r.FormValue("overlap"))
+	if err != nil {
+		return options, err
+	}
+	options.overlap = policy
+
+	switch mode := r.FormValue("mode"); mode {
+	case "", "dry_run":
+	case "commit":
+		options.dryRun = false
+	default:
+		return options, fmt.Errorf("invalid mode '%s'. Expected dry_run or commit", mode)
+	}
+
+	if v := r.FormValue("create_missing"); v != "" {
+		createMissing, err := strconv.ParseBool(v)
+		if err != nil {
+			return options, fmt.Errorf("invalid create_missing value. Expected true or false")
+		}
+		options.createMissing = createMissing
+	}
+
+	if v := r.FormValue("delimiter"); v != "" {
+		if v == "\\t" || v == "tab" {
+			v = "\t"
+		}
+		if utf8.RuneCountInString(v) != 1 {
+			return options, fmt.Errorf("invalid delimiter '%s'. Expected a single character", v)
+		}
+		options.delimiter, _ = utf8.DecodeRuneInString(v)
+	}
+
+	return options, nil
+}
+
+// runImport validates the parsed rows and commits them as one undoable change unless this
+// is a dry run
+func runImport(w http.ResponseWriter, r *http.Request, rows []pkgmodel.ImportRow, options importOptions) {
+	imp, err := ValidateAgainstDB(pkgglobal.Db, rows, options.createMissing)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if options.dryRun {
+		json.NewEncoder(w).Encode(imp.Result(true, 0))
+		return
+	}
+
+	if !imp.Valid() {
+		// Nothing is imported unless every row is valid
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(imp.Result(false, 0))
+		return
+	}
+
+	tx, err := pkghandler.BeginChange()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	imported, err := Commit(tx, imp, options.overlap)
+	if errors.Is(err, errRowsRejected) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(imp.Result(false, 0))
+		return
+	}
+	if err == nil {
+		err = tx.Commit(w, r)
+	}
+	if err != nil {
+		log.Printf("ERROR: Failed to commit import - Error: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(imp.Result(false, imported))
+}
+
+// Import handlers
+func ImportTimeEntries(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := r.ParseMultipartForm(maxImportSize); err != nil {
+		http.Error(w, "Expected multipart form with a CSV file: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	options, err := parseImportOptions(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, "CSV file is required", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	table, err := readCSV(file, options.delimiter)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var parser RowParser
+	switch format := r.FormValue("format"); format {
+	case "", "mapping":
+		var mapping pkgmodel.ImportMapping
+		if err := json.Unmarshal([]byte(r.FormValue("mapping")), &mapping); err != nil {
+			http.Error(w, "Invalid mapping: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := ValidateMapping(table, mapping); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		parser = MappingParser(mapping)
+	default:
+		adapterOptions, err := ParseAdapterOptions(r.FormValue("category_from"), r.FormValue("default_category"), r.FormValue("day_start"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if parser, err = AdapterParser(format, table, adapterOptions); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	runImport(w, r, parseRows(table, parser), options)
+}
+
+// PreviewCalendarImport expands the events of an uploaded .ics file within from and to and
+// proposes a time entry for each instance. Nothing is stored.
+func PreviewCalendarImport(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := r.ParseMultipartForm(maxImportSize); err != nil {
+		http.Error(w, "Expected multipart form with an .ics file: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	options, err := parseImportOptions(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if r.FormValue("from") == "" || r.FormValue("to") == "" {
+		http.Error(w, "from and to are required to expand recurring events", http.StatusBadRequest)
+		return
+	}
+	from, to, err := pkghandler.ParseDateRange(r.Form)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !to.After(from) || to.Sub(from) > maxCalendarRange {
+		http.Error(w, "date range must be positive and not exceed 366 days", http.StatusBadRequest)
+		return
+	}
+
+	rules, err := ParseCategoryRules(r.FormValue("rules"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defaultCategory := r.FormValue("default_category")
+	if defaultCategory == "" {
+		defaultCategory = "other"
+	}
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, ".ics file is required", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	events, err := ical.Parse(file, time.Local)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// The range is given in wall-clock dates, so it is compared in local time
+	localFrom := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.Local)
+	localTo := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.Local)
+	instances, skipped := ical.Expand(events, localFrom, localTo)
+
+	proposals, err := CalendarProposals(pkgglobal.Db, instances, rules, defaultCategory, options.createMissing)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(pkgmodel.CalendarPreview{Proposals: proposals, Skipped: calendarSkips(skipped)})
+}
+
+// ConfirmCalendarImport inserts the proposed time entries the user selected
+func ConfirmCalendarImport(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req pkgmodel.CalendarConfirmRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(req.Entries) == 0 {
+		http.Error(w, "No entries selected", http.StatusBadRequest)
+		return
+	}
+
+	policy, err := pkghandler.RequestOverlapPolicy(r.URL.Query().Get("overlap"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rows := make([]pkgmodel.ImportRow, 0, len(req.Entries))
+	for i, entry := range req.Entries {
+		rows = append(rows, validatedRow(i+1, entry))
+	}
+
+	runImport(w, r, rows, importOptions{createMissing: req.CreateMissing, overlap: policy})
+}
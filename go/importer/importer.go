@@ -0,0 +1,231 @@
+package importer
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	pkgdb "timesheet/go/db"
+	pkghandler "timesheet/go/handler"
+	pkgmodel "timesheet/go/model"
+	pkgutil "timesheet/go/util"
+)
+
+// Import holds parsed rows together with the categories and tasks they need
+type Import struct {
+	Rows              []pkgmodel.ImportRow
+	MissingCategories []string
+	MissingTasks      []string
+	// taskCategories remembers the category of the first row using a missing task
+	taskCategories map[string]string
+}
+
+// Valid reports whether every row passed validation
+func (imp *Import) Valid() bool {
+	for _, row := range imp.Rows {
+		if len(row.Errors) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Result summarizes the import for the API response
+func (imp *Import) Result(dryRun bool, imported int) pkgmodel.ImportResult {
+	result := pkgmodel.ImportResult{
+		DryRun:            dryRun,
+		Rows:              imp.Rows,
+		Imported:          imported,
+		CreatedCategories: append([]string{}, imp.MissingCategories...),
+		CreatedTasks:      append([]string{}, imp.MissingTasks...),
+	}
+	for _, row := range imp.Rows {
+		if len(row.Errors) > 0 {
+			result.InvalidRows++
+		} else {
+			result.ValidRows++
+		}
+	}
+	return result
+}
+
+// ValidateAgainstDB checks the categories of all parsed rows. Unknown categories are
+// errors unless createMissing is set, in which case they and all unknown task names
+// are collected for creation.
+func ValidateAgainstDB(db *sql.DB, rows []pkgmodel.ImportRow, createMissing bool) (*Import, error) {
+	imp := &Import{Rows: rows, taskCategories: make(map[string]string)}
+	knownCategories := make(map[string]bool)
+	seenTasks := make(map[string]bool)
+
+	for i := range imp.Rows {
+		row := &imp.Rows[i]
+		if len(row.Errors) > 0 {
+			continue
+		}
+
+		category := row.Entry.Category
+		known, checked := knownCategories[category]
+		if !checked {
+			known = pkgdb.ValidateCategoryExists(db, category) == nil
+			knownCategories[category] = known
+			if !known && createMissing {
+				imp.MissingCategories = append(imp.MissingCategories, category)
+			}
+		}
+		if !known && !createMissing {
+			row.Errors = append(row.Errors, fmt.Sprintf("invalid category '%s': category does not exist in the system", category))
+			continue
+		}
+
+		if createMissing && !seenTasks[row.Entry.Task] {
+			seenTasks[row.Entry.Task] = true
+			var exists bool
+			err := db.QueryRow("SELECT EXISTS(SELECT 1 FROM tasks WHERE name = ? AND deleted_at IS NULL)", row.Entry.Task).Scan(&exists)
+			if err != nil {
+				return nil, fmt.Errorf("database error while validating task: %w", err)
+			}
+			if !exists {
+				imp.MissingTasks = append(imp.MissingTasks, row.Entry.Task)
+				imp.taskCategories[row.Entry.Task] = category
+			}
+		}
+	}
+	return imp, nil
+}
+
+// errRowsRejected is returned by Commit when rows were rejected while writing them; the
+// rows carry the reasons
+var errRowsRejected = errors.New("import contains rejected rows")
+
+// Commit creates the missing categories and tasks and inserts all rows through q, which
+// should be a transaction. Every row is subject to the overlap policy, so rows also conflict
+// with the rows imported before them. It returns the number of inserted time entries.
+func Commit(q pkghandler.Querier, imp *Import, policy pkghandler.OverlapPolicy) (int, error) {
+	if !imp.Valid() {
+		return 0, fmt.Errorf("import contains invalid rows")
+	}
+
+	for _, name := range imp.MissingCategories {
+		if err := createCategory(q, name); err != nil {
+			return 0, fmt.Errorf("failed to create category '%s': %w", name, err)
+		}
+	}
+
+	for _, name := range imp.MissingTasks {
+		result, err := q.Exec("INSERT INTO tasks (name, category_id) VALUES (?, (SELECT id FROM categories WHERE name = ?))",
+			name, imp.taskCategories[name])
+		if err == nil {
+			err = auditInsert(q, pkghandler.AuditTask, result)
+		}
+		if err == nil {
+			id, _ := result.LastInsertId()
+			err = pkghandler.SyncEntryLinks(q, "task", int(id), name)
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to create task '%s': %w", name, err)
+		}
+	}
+
+	currentDate := pkgutil.GetCurrentDateForDB()
+	rejected := false
+	// importedLines maps the IDs of the inserted entries to their lines, since a rejected
+	// import rolls them back
+	importedLines := make(map[int]int)
+	for i := range imp.Rows {
+		row := &imp.Rows[i]
+		startTime, _ := time.Parse(time.RFC3339, row.Entry.StartTime)
+		endTime, _ := time.Parse(time.RFC3339, row.Entry.EndTime)
+		duration := pkgutil.CalculateDurationMinutes(startTime, endTime)
+
+		conflicts, err := pkghandler.ResolveOverlaps(q, policy, startTime, endTime, 0)
+		var overlapErr *pkghandler.OverlapError
+		if errors.As(err, &overlapErr) {
+			// Keep checking the other rows so the response lists every rejected one
+			row.Errors = append(row.Errors, "overlaps "+describeConflicts(overlapErr.ConflictIDs, importedLines))
+			rejected = true
+			continue
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to check overlaps of line %d: %w", row.Line, err)
+		}
+		if len(conflicts) > 0 {
+			verb := "overlaps "
+			if policy == pkghandler.OverlapTrim {
+				verb = "trimmed "
+			}
+			row.Warnings = append(row.Warnings, verb+describeConflicts(conflicts, importedLines))
+		}
+
+		// Imported entries belong to the project of their task
+		result, err := q.Exec(`
+			INSERT INTO time_entries (task, description, category, start_time, end_time, duration, date, project_id)
+			VALUES (?, ?, ?, ?, ?, ?, ?,
+				(SELECT project_id FROM tasks WHERE name = ? AND deleted_at IS NULL ORDER BY id LIMIT 1))
+		`, row.Entry.Task, row.Entry.Description, row.Entry.Category, pkgutil.FormatTimeForDB(startTime),
+			pkgutil.FormatTimeForDB(endTime), duration, currentDate, row.Entry.Task)
+		if err == nil {
+			err = auditInsert(q, pkghandler.AuditEntry, result)
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to import line %d: %w", row.Line, err)
+		}
+		id, _ := result.LastInsertId()
+		importedLines[int(id)] = row.Line
+	}
+	if rejected {
+		return 0, errRowsRejected
+	}
+
+	log.Printf("IMPORT: Imported %d time entries, created %d categories and %d tasks",
+		len(imp.Rows), len(imp.MissingCategories), len(imp.MissingTasks))
+	return len(imp.Rows), nil
+}
+
+// describeConflicts names the conflicting entries, using the line of those from the import
+func describeConflicts(ids []int, importedLines map[int]int) string {
+	names := make([]string, len(ids))
+	for i, id := range ids {
+		if line, ok := importedLines[id]; ok {
+			names[i] = fmt.Sprintf("line %d", line)
+		} else {
+			names[i] = fmt.Sprintf("entry %d", id)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// createCategory inserts a category, or restores it if a category of the same name is in the trash
+func createCategory(tx pkghandler.Querier, name string) error {
+	var id int
+	err := tx.QueryRow("SELECT id FROM categories WHERE name = ?", name).Scan(&id)
+	if err == sql.ErrNoRows {
+		result, err := tx.Exec("INSERT INTO categories (name) VALUES (?)", name)
+		if err != nil {
+			return err
+		}
+		if err := auditInsert(tx, pkghandler.AuditCategory, result); err != nil {
+			return err
+		}
+		id, _ := result.LastInsertId()
+		return pkghandler.SyncEntryLinks(tx, "category", int(id), name)
+	}
+	if err != nil {
+		return err
+	}
+	return pkghandler.AuditedWrite(tx, pkghandler.AuditCategory, id, pkghandler.AuditRestore, func() error {
+		_, err := tx.Exec("UPDATE categories SET deleted_at = NULL WHERE id = ?", id)
+		return err
+	})
+}
+
+// auditInsert records the row created by an INSERT statement
+func auditInsert(tx pkghandler.Querier, entityType string, result sql.Result) error {
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	return pkghandler.AuditInsert(tx, entityType, int(id))
+}
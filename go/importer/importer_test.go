@@ -0,0 +1,309 @@
+package importer
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pkghandler "timesheet/go/handler"
+	pkgmodel "timesheet/go/model"
+	"timesheet/go/testdb"
+)
+
+// setupTestDB creates an in-memory database with the real schema and a task, and makes it
+// the global connection
+func setupTestDB(t *testing.T) *sql.DB {
+	db := testdb.OpenGlobal(t)
+	_, err := db.Exec("INSERT INTO tasks (name, category_id) VALUES ('Development', 1)")
+	require.NoError(t, err)
+	return db
+}
+
+const mappedCSV = "\ufeffWhat,Notes,Kind,Day,From,To,Minutes\n" +
+	"Development,feature x,project work,2025-11-10,09:00,10:30,\n" +
+	"Standup,,,10.11.2025,10:30,,15\n" +
+	"Night shift,,other,2025-11-10,23:00,01:00,\n" +
+	"Broken,,other,2025-11-10,25:00,26:00,\n"
+
+var testMapping = pkgmodel.ImportMapping{
+	Task:            "what",
+	Description:     "Notes",
+	Category:        "Kind",
+	Date:            "Day",
+	Start:           "From",
+	End:             "To",
+	Duration:        "Minutes",
+	DefaultCategory: "meetings",
+}
+
+func TestMappingParser(t *testing.T) {
+	table, err := readCSV(strings.NewReader(mappedCSV), ',')
+	require.NoError(t, err)
+	require.NoError(t, ValidateMapping(table, testMapping))
+
+	rows := parseRows(table, MappingParser(testMapping))
+
+	require.Len(t, rows, 4)
+	assert.Equal(t, pkgmodel.ImportRow{Line: 2, Entry: pkgmodel.TimeEntryRequest{
+		Task: "Development", Description: "feature x", Category: "project work",
+		StartTime: "2025-11-10T09:00:00Z", EndTime: "2025-11-10T10:30:00Z",
+	}}, rows[0])
+	assert.Equal(t, "meetings", rows[1].Entry.Category)
+	assert.Equal(t, "2025-11-10T10:45:00Z", rows[1].Entry.EndTime)
+	assert.Equal(t, "2025-11-11T01:00:00Z", rows[2].Entry.EndTime)
+	assert.Empty(t, rows[2].Errors)
+	assert.Equal(t, 5, rows[3].Line)
+	assert.Equal(t, []string{"start: unrecognized date/time '2025-11-10 25:00'"}, rows[3].Errors)
+}
+
+func TestValidateMapping(t *testing.T) {
+	table, err := readCSV(strings.NewReader(mappedCSV), ',')
+	require.NoError(t, err)
+
+	err = ValidateMapping(table, pkgmodel.ImportMapping{Task: "What", Start: "From", End: "Until", Category: "Kind"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "column 'Until' not found")
+
+	err = ValidateMapping(table, pkgmodel.ImportMapping{Task: "What", Start: "From", Category: "Kind"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "end or duration")
+}
+
+func TestParseDurationMinutes(t *testing.T) {
+	for value, expected := range map[string]int{"90": 90, "1:30": 90, "2:05:00": 125} {
+		minutes, err := parseDurationMinutes(value)
+		require.NoError(t, err)
+		assert.Equal(t, expected, minutes)
+	}
+	for _, value := range []string{"", "0", "-5", "1:75", "abc"} {
+		_, err := parseDurationMinutes(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestValidateAgainstDBRejectsUnknownCategory(t *testing.T) {
+	db := setupTestDB(t)
+	rows := []pkgmodel.ImportRow{
+		{Line: 2, Entry: pkgmodel.TimeEntryRequest{Task: "Development", Category: "project work"}},
+		{Line: 3, Entry: pkgmodel.TimeEntryRequest{Task: "Standup", Category: "meetings"}},
+	}
+
+	imp, err := ValidateAgainstDB(db, rows, false)
+
+	require.NoError(t, err)
+	assert.False(t, imp.Valid())
+	assert.Empty(t, imp.Rows[0].Errors)
+	assert.Equal(t, []string{"invalid category 'meetings': category does not exist in the system"}, imp.Rows[1].Errors)
+	assert.Empty(t, imp.MissingCategories)
+	assert.Empty(t, imp.MissingTasks)
+}
+
+func TestCommitCreatesMissingCategoriesAndTasks(t *testing.T) {
+	db := setupTestDB(t)
+	rows := []pkgmodel.ImportRow{
+		{Line: 2, Entry: pkgmodel.TimeEntryRequest{Task: "Development", Category: "project work",
+			StartTime: "2025-11-10T09:00:00Z", EndTime: "2025-11-10T10:30:00Z"}},
+		{Line: 3, Entry: pkgmodel.TimeEntryRequest{Task: "Standup", Category: "meetings",
+			StartTime: "2025-11-10T10:30:00Z", EndTime: "2025-11-10T10:45:00Z"}},
+	}
+
+	imp, err := ValidateAgainstDB(db, rows, true)
+	require.NoError(t, err)
+	require.True(t, imp.Valid())
+	assert.Equal(t, []string{"meetings"}, imp.MissingCategories)
+	assert.Equal(t, []string{"Standup"}, imp.MissingTasks)
+
+	imported, err := Commit(db, imp, pkghandler.OverlapReject)
+	require.NoError(t, err)
+	assert.Equal(t, 2, imported)
+
+	var taskCategory string
+	err = db.QueryRow("SELECT c.name FROM tasks t JOIN categories c ON c.id = t.category_id WHERE t.name = 'Standup'").Scan(&taskCategory)
+	require.NoError(t, err)
+	assert.Equal(t, "meetings", taskCategory)
+
+	var total int
+	require.NoError(t, db.QueryRow("SELECT SUM(duration) FROM time_entries").Scan(&total))
+	assert.Equal(t, 105, total)
+
+	// Every created row is recorded in the audit log
+	var audited int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM audit_log WHERE operation = 'create'").Scan(&audited))
+	assert.Equal(t, 4, audited)
+}
+
+func TestCommitRestoresTrashedCategory(t *testing.T) {
+	db := setupTestDB(t)
+	_, err := db.Exec("UPDATE categories SET deleted_at = '2025-11-01T12:00:00Z' WHERE name = 'project work'")
+	require.NoError(t, err)
+	rows := []pkgmodel.ImportRow{
+		{Line: 2, Entry: pkgmodel.TimeEntryRequest{Task: "Development", Category: "project work",
+			StartTime: "2025-11-10T09:00:00Z", EndTime: "2025-11-10T10:30:00Z"}},
+	}
+
+	imp, err := ValidateAgainstDB(db, rows, true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"project work"}, imp.MissingCategories)
+	_, err = Commit(db, imp, pkghandler.OverlapReject)
+	require.NoError(t, err)
+
+	var count int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM categories WHERE name = 'project work' AND deleted_at IS NULL").Scan(&count))
+	assert.Equal(t, 1, count)
+	var operation string
+	require.NoError(t, db.QueryRow("SELECT operation FROM audit_log WHERE entity_type = 'category'").Scan(&operation))
+	assert.Equal(t, "restore", operation)
+}
+
+// importSession is the undo session of the import requests
+const importSession = "import-test"
+
+// postImport sends a multipart import request with the given form fields and CSV file
+func postImport(t *testing.T, target string, fields map[string]string, csvData string) *httptest.ResponseRecorder {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	for name, value := range fields {
+		require.NoError(t, form.WriteField(name, value))
+	}
+	file, err := form.CreateFormFile("file", "import.csv")
+	require.NoError(t, err)
+	_, err = file.Write([]byte(csvData))
+	require.NoError(t, err)
+	require.NoError(t, form.Close())
+
+	req := httptest.NewRequest(http.MethodPost, target, &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("X-Session-ID", importSession)
+	w := httptest.NewRecorder()
+	ImportTimeEntries(w, req)
+	return w
+}
+
+func TestImportTimeEntriesDryRunAndCommit(t *testing.T) {
+	db := setupTestDB(t)
+	csvData := "Task;Category;Start;End\n" +
+		"Development;project work;2025-11-10 09:00;2025-11-10 10:00\n" +
+		"Review;other;2025-11-10 10:00;2025-11-10 10:30\n"
+	fields := map[string]string{
+		"mapping":   `{"task": "Task", "category": "Category", "start": "Start", "end": "End"}`,
+		"delimiter": ";",
+	}
+
+	w := postImport(t, "/api/import", fields, csvData)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var result pkgmodel.ImportResult
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&result))
+	assert.True(t, result.DryRun)
+	assert.Equal(t, 2, result.ValidRows)
+	assert.Equal(t, 0, result.Imported)
+
+	var count int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM time_entries").Scan(&count))
+	assert.Equal(t, 0, count)
+
+	w = postImport(t, "/api/import?mode=commit", fields, csvData)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&result))
+	assert.False(t, result.DryRun)
+	assert.Equal(t, 2, result.Imported)
+
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM time_entries").Scan(&count))
+	assert.Equal(t, 2, count)
+}
+
+func TestImportTimeEntriesCommitRejectsInvalidRows(t *testing.T) {
+	db := setupTestDB(t)
+	csvData := "Task,Category,Start,End\n" +
+		"Development,project work,2025-11-10 09:00,2025-11-10 10:00\n" +
+		"Review,unknown,2025-11-10 10:00,2025-11-10 10:30\n"
+	fields := map[string]string{
+		"mapping": `{"task": "Task", "category": "Category", "start": "Start", "end": "End"}`,
+		"mode":    "commit",
+	}
+
+	w := postImport(t, "/api/import", fields, csvData)
+
+	require.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	var result pkgmodel.ImportResult
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&result))
+	assert.Equal(t, 1, result.InvalidRows)
+	assert.Equal(t, 3, result.Rows[1].Line)
+
+	var count int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM time_entries").Scan(&count))
+	assert.Equal(t, 0, count)
+}
+
+func TestImportTimeEntriesCommitAppliesOverlapPolicy(t *testing.T) {
+	db := setupTestDB(t)
+	_, err := db.Exec(`INSERT INTO time_entries (task, category, start_time, end_time, duration, date)
+		VALUES ('Meeting', 'other', '2025-11-10T09:30:00Z', '2025-11-10T09:45:00Z', 15, '2025-11-10')`)
+	require.NoError(t, err)
+	// The second row overlaps the existing entry, the third the imported first row
+	csvData := "Task,Category,Start,End\n" +
+		"Development,project work,2025-11-10 08:00,2025-11-10 09:00\n" +
+		"Development,project work,2025-11-10 09:00,2025-11-10 10:00\n" +
+		"Review,other,2025-11-10 08:30,2025-11-10 08:45\n"
+	fields := map[string]string{
+		"mapping": `{"task": "Task", "category": "Category", "start": "Start", "end": "End"}`,
+		"mode":    "commit",
+		"overlap": "reject",
+	}
+
+	w := postImport(t, "/api/import", fields, csvData)
+
+	require.Equal(t, http.StatusUnprocessableEntity, w.Code, w.Body.String())
+	var result pkgmodel.ImportResult
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&result))
+	assert.Equal(t, 0, result.Imported)
+	assert.Empty(t, result.Rows[0].Errors)
+	assert.Equal(t, []string{"overlaps entry 1"}, result.Rows[1].Errors)
+	assert.Equal(t, []string{"overlaps line 2"}, result.Rows[2].Errors)
+	var count int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM time_entries").Scan(&count))
+	assert.Equal(t, 1, count)
+
+	fields["overlap"] = "allow"
+	w = postImport(t, "/api/import", fields, csvData)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&result))
+	assert.Equal(t, 3, result.Imported)
+	assert.Equal(t, []string{"overlaps entry 1"}, result.Rows[1].Warnings)
+	assert.Equal(t, []string{"overlaps line 2"}, result.Rows[2].Warnings)
+}
+
+func TestImportTimeEntriesCommitIsUndoable(t *testing.T) {
+	db := setupTestDB(t)
+	csvData := "Task,Category,Start,End\n" +
+		"Standup,meetings,2025-11-10 09:00,2025-11-10 09:15\n"
+	fields := map[string]string{
+		"mapping":        `{"task": "Task", "category": "Category", "start": "Start", "end": "End"}`,
+		"mode":           "commit",
+		"create_missing": "true",
+	}
+	w := postImport(t, "/api/import", fields, csvData)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	// The entry and the created category and task are undone in one step
+	req := httptest.NewRequest(http.MethodPost, "/api/undo", nil)
+	req.Header.Set("X-Session-ID", importSession)
+	w = httptest.NewRecorder()
+	pkghandler.Undo(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var entries, categories, tasks int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM time_entries WHERE deleted_at IS NULL").Scan(&entries))
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM categories WHERE name = 'meetings' AND deleted_at IS NULL").Scan(&categories))
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM tasks WHERE name = 'Standup' AND deleted_at IS NULL").Scan(&tasks))
+	assert.Zero(t, entries)
+	assert.Zero(t, categories)
+	assert.Zero(t, tasks)
+}
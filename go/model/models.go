@@ -1,22 +1,237 @@
 package model
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
+// TimeEntry is a stored time entry. TaskID and CategoryID link it to the predefined task
+// and category of the same name; they are null for ad-hoc task names and deleted categories.
+// ProjectID is the project the work was done for, if any, and Billable tells whether it is
+// charged to the client.
 type TimeEntry struct {
-	ID          int       `json:"id"`
-	Task        string    `json:"task"`
-	Description string    `json:"description"`
-	Category    string    `json:"category"`
-	StartTime   time.Time `json:"start_time"`
-	EndTime     time.Time `json:"end_time"`
+	ID          int        `json:"id"`
+	Task        string     `json:"task"`
+	TaskID      *int       `json:"task_id"`
+	Description string     `json:"description"`
+	Category    string     `json:"category"`
+	CategoryID  *int       `json:"category_id"`
+	ProjectID   *int       `json:"project_id"`
+	Billable    bool       `json:"billable"`
+	Tags        []string   `json:"tags"`
+	StartTime   time.Time  `json:"start_time"`
+	EndTime     *time.Time `json:"end_time"`
+	Duration    int        `json:"duration"`
 }
 
+// TimeEntryRequest is the body of a create or update. Tags replace the tags of the entry;
+// if they are omitted, an update keeps the current tags. The same holds for ProjectID, where
+// 0 removes the project and a new entry without one gets the project of its task. New
+// entries without Billable are billable.
 type TimeEntryRequest struct {
+	Task        string   `json:"task"`
+	Description string   `json:"description"`
+	Category    string   `json:"category"`
+	ProjectID   *int     `json:"project_id"`
+	Billable    *bool    `json:"billable"`
+	Tags        []string `json:"tags"`
+	StartTime   string   `json:"start_time"`
+	EndTime     string   `json:"end_time"`
+}
+
+// TimeEntryPage is the response envelope of the entries listing
+type TimeEntryPage struct {
+	Entries    []TimeEntry `json:"entries"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+	Total      int         `json:"total"`
+}
+
+// TimeEntryResult is a written time entry together with overlap warnings
+type TimeEntryResult struct {
+	TimeEntry
+	Warning   string `json:"warning,omitempty"`
+	Conflicts []int  `json:"conflicts,omitempty"`
+}
+
+// BulkOperation is one create, update or delete of a bulk request. Create and update take
+// the complete entry; update and delete take the ID.
+type BulkOperation struct {
+	Op    string            `json:"op"`
+	ID    int               `json:"id,omitempty"`
+	Entry *TimeEntryRequest `json:"entry,omitempty"`
+}
+
+// BulkFilter selects entries like the query parameters of the entries listing
+type BulkFilter struct {
+	From     string   `json:"from"`
+	To       string   `json:"to"`
+	Category string   `json:"category"`
+	Task     string   `json:"task"`
+	Query    string   `json:"q"`
+	Tags     []string `json:"tags"`
+}
+
+// BulkPatch lists the fields set on all entries matching a bulk filter; nil fields are kept
+type BulkPatch struct {
+	Task        *string `json:"task"`
+	Description *string `json:"description"`
+	Category    *string `json:"category"`
+}
+
+// BulkRequest holds either a list of operations or a filter with a patch
+type BulkRequest struct {
+	Operations []BulkOperation `json:"operations"`
+	Filter     *BulkFilter     `json:"filter"`
+	Patch      *BulkPatch      `json:"patch"`
+}
+
+// BulkItemResult is the outcome of one operation or patched entry
+type BulkItemResult struct {
+	Index     int        `json:"index"`
+	Op        string     `json:"op"`
+	ID        int        `json:"id,omitempty"`
+	Entry     *TimeEntry `json:"entry,omitempty"`
+	Warning   string     `json:"warning,omitempty"`
+	Conflicts []int      `json:"conflicts,omitempty"`
+	Error     string     `json:"error,omitempty"`
+}
+
+// BulkResponse reports the per-item results; nothing is applied if any item failed
+type BulkResponse struct {
+	Applied bool             `json:"applied"`
+	Results []BulkItemResult `json:"results"`
+}
+
+// TimeEntryConflict is a pair of overlapping time entries
+type TimeEntryConflict struct {
+	First          TimeEntry `json:"first"`
+	Second         TimeEntry `json:"second"`
+	OverlapMinutes int       `json:"overlap_minutes"`
+}
+
+// SummaryReport is the response of the summary report
+type SummaryReport struct {
+	From         string          `json:"from,omitempty"`
+	To           string          `json:"to,omitempty"`
+	GroupBy      string          `json:"group_by"`
+	TotalMinutes int             `json:"total_minutes"`
+	EntryCount   int             `json:"entry_count"`
+	Buckets      []SummaryBucket `json:"buckets"`
+	// Tags holds the totals per tag; an entry with several tags counts for each of them
+	Tags []TagTotal `json:"tags"`
+	// Rounding holds the rounding rules applied to the durations
+	Rounding []RoundingRule `json:"rounding"`
+}
+
+// SummaryBucket holds the totals of one group of the summary report
+type SummaryBucket struct {
+	Key          string          `json:"key"`
+	Start        string          `json:"start,omitempty"`
+	Color        string          `json:"color,omitempty"`
+	TotalMinutes int             `json:"total_minutes"`
+	EntryCount   int             `json:"entry_count"`
+	Categories   []CategoryTotal `json:"categories,omitempty"`
+}
+
+// TagTotal holds the totals of the entries carrying a tag
+type TagTotal struct {
+	Name         string `json:"name"`
+	TotalMinutes int    `json:"total_minutes"`
+	EntryCount   int    `json:"entry_count"`
+}
+
+// CategoryTotal holds the totals of one category within a summary bucket
+type CategoryTotal struct {
+	Name         string `json:"name"`
+	Color        string `json:"color"`
+	TotalMinutes int    `json:"total_minutes"`
+	EntryCount   int    `json:"entry_count"`
+}
+
+// ImportMapping maps time entry fields onto CSV column headers. If Date is set,
+// Start and End hold times of day; without End the entry lasts Duration minutes.
+type ImportMapping struct {
+	Task            string `json:"task"`
+	Description     string `json:"description"`
+	Category        string `json:"category"`
+	Start           string `json:"start"`
+	End             string `json:"end"`
+	Date            string `json:"date"`
+	Duration        string `json:"duration"`
+	DefaultCategory string `json:"default_category"`
+	TimeLayout      string `json:"time_layout"`
+}
+
+// ImportRow is one parsed CSV line with its validation errors and the overlap warnings of
+// its committed entry
+type ImportRow struct {
+	Line     int              `json:"line"`
+	Entry    TimeEntryRequest `json:"entry"`
+	Errors   []string         `json:"errors,omitempty"`
+	Warnings []string         `json:"warnings,omitempty"`
+}
+
+// ImportResult reports the outcome of a dry run or committed import
+type ImportResult struct {
+	DryRun            bool        `json:"dry_run"`
+	Rows              []ImportRow `json:"rows"`
+	ValidRows         int         `json:"valid_rows"`
+	InvalidRows       int         `json:"invalid_rows"`
+	Imported          int         `json:"imported"`
+	CreatedCategories []string    `json:"created_categories"`
+	CreatedTasks      []string    `json:"created_tasks"`
+}
+
+// CategoryRule assigns Category to calendar events whose summary or description contains Keyword
+type CategoryRule struct {
+	Keyword  string `json:"keyword"`
+	Category string `json:"category"`
+}
+
+// CalendarProposal is a time entry proposed for one instance of a calendar event
+type CalendarProposal struct {
+	UID   string           `json:"uid"`
+	Entry TimeEntryRequest `json:"entry"`
+	// MatchedKeyword is the keyword of the rule that chose the category, if any
+	MatchedKeyword string `json:"matched_keyword,omitempty"`
+	// Exists is set when an entry with the same task and start time is already stored
+	Exists bool     `json:"exists"`
+	Errors []string `json:"errors,omitempty"`
+}
+
+// CalendarSkip is a calendar event that cannot be turned into time entries
+type CalendarSkip struct {
+	UID     string `json:"uid"`
+	Summary string `json:"summary"`
+	Reason  string `json:"reason"`
+}
+
+// CalendarPreview lists the proposed time entries of an uploaded calendar
+type CalendarPreview struct {
+	Proposals []CalendarProposal `json:"proposals"`
+	Skipped   []CalendarSkip     `json:"skipped"`
+}
+
+// CalendarConfirmRequest inserts the proposals the user selected
+type CalendarConfirmRequest struct {
+	Entries       []TimeEntryRequest `json:"entries"`
+	CreateMissing bool               `json:"create_missing"`
+}
+
+// TimerStartRequest starts a running timer, i.e. a time entry without end time
+type TimerStartRequest struct {
 	Task        string `json:"task"`
 	Description string `json:"description"`
 	Category    string `json:"category"`
-	StartTime   string `json:"start_time"`
-	EndTime     string `json:"end_time"`
+}
+
+// TimerStartResponse reports the started timer and the one it replaced, if any, together with
+// the overlap warnings of the stopped timer
+type TimerStartResponse struct {
+	Timer     TimeEntry  `json:"timer"`
+	Stopped   *TimeEntry `json:"stopped"`
+	Warning   string     `json:"warning,omitempty"`
+	Conflicts []int      `json:"conflicts,omitempty"`
 }
 
 type Category struct {
@@ -29,9 +244,130 @@ type Task struct {
 	ID          int    `json:"id"`
 	Name        string `json:"name"`
 	CategoryID  int    `json:"category_id"`
+	ProjectID   int    `json:"project_id"`
 	Description string `json:"description"`
 }
 
+// Client is a customer the work is done for
+type Client struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// Project belongs to a client and groups tasks
+type Project struct {
+	ID       int    `json:"id"`
+	ClientID int    `json:"client_id"`
+	Name     string `json:"name"`
+}
+
+// ProjectNode is a project with its tasks in the project tree
+type ProjectNode struct {
+	Project
+	Tasks []Task `json:"tasks"`
+}
+
+// ClientNode is a client with its projects in the project tree
+type ClientNode struct {
+	Client
+	Projects []ProjectNode `json:"projects"`
+}
+
+// ProjectUsage counts the tasks and entries that reference a project
+type ProjectUsage struct {
+	ProjectID int `json:"project_id"`
+	Tasks     int `json:"tasks"`
+	Entries   int `json:"entries"`
+}
+
+// Tag is a label of time entries with the number of entries carrying it
+type Tag struct {
+	ID      int    `json:"id"`
+	Name    string `json:"name"`
+	Entries int    `json:"entries"`
+}
+
+// CategoryUsage counts the entries and tasks that depend on a category, including those in the trash
+type CategoryUsage struct {
+	CategoryID   int    `json:"category_id"`
+	Name         string `json:"name"`
+	Entries      int    `json:"entries"`
+	TotalMinutes int    `json:"total_minutes"`
+	Tasks        int    `json:"tasks"`
+	Trashed      int    `json:"trashed"`
+}
+
+// TrashItem is a deleted time entry, task or category that can still be restored
+type TrashItem struct {
+	Type      string    `json:"type"`
+	ID        int       `json:"id"`
+	Label     string    `json:"label"`
+	DeletedAt time.Time `json:"deleted_at"`
+	// PurgeAt is when the retention job removes the item; null if items are kept until purged
+	PurgeAt *time.Time `json:"purge_at"`
+}
+
+// AuditRecord is one change recorded in the audit log. Before and After hold the full row;
+// Before is null for creations and After for purges.
+type AuditRecord struct {
+	ID         int             `json:"id"`
+	EntityType string          `json:"entity_type"`
+	EntityID   int             `json:"entity_id"`
+	Operation  string          `json:"operation"`
+	Before     json.RawMessage `json:"before"`
+	After      json.RawMessage `json:"after"`
+	ChangedAt  time.Time       `json:"changed_at"`
+}
+
+// UndoResult reports the change that was undone or redone and the remaining steps
+type UndoResult struct {
+	Operation     string        `json:"operation"`
+	Changes       []AuditRecord `json:"changes"`
+	UndoAvailable int           `json:"undo_available"`
+	RedoAvailable int           `json:"redo_available"`
+}
+
+// RecurringTemplate is an entry booked on a schedule. StartTime is a wall-clock time (HH:MM),
+// Schedule an RRULE subset such as FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH counted from StartsOn.
+type RecurringTemplate struct {
+	ID           int      `json:"id"`
+	Task         string   `json:"task"`
+	Description  string   `json:"description"`
+	Category     string   `json:"category"`
+	StartTime    string   `json:"start_time"`
+	Duration     int      `json:"duration"`
+	Schedule     string   `json:"schedule"`
+	StartsOn     string   `json:"starts_on"`
+	EndsOn       string   `json:"ends_on,omitempty"`
+	ExcludeDates []string `json:"exclude_dates"`
+}
+
+// TemplateSkip is a due template that was not booked and the reason
+type TemplateSkip struct {
+	TemplateID int    `json:"template_id"`
+	Task       string `json:"task"`
+	Reason     string `json:"reason"`
+}
+
+// TemplateApplyResult lists the entries created from the templates due on a day
+type TemplateApplyResult struct {
+	Date    string         `json:"date"`
+	Created []TimeEntry    `json:"created"`
+	Skipped []TemplateSkip `json:"skipped"`
+}
+
+type RecurringTemplateRequest struct {
+	Task         string   `json:"task"`
+	Description  string   `json:"description"`
+	Category     string   `json:"category"`
+	StartTime    string   `json:"start_time"`
+	Duration     int      `json:"duration"`
+	Schedule     string   `json:"schedule"`
+	StartsOn     string   `json:"starts_on"`
+	EndsOn       string   `json:"ends_on"`
+	ExcludeDates []string `json:"exclude_dates"`
+}
+
 type CategoryRequest struct {
 	Name  string `json:"name"`
 	Color string `json:"color"`
@@ -40,5 +376,166 @@ type CategoryRequest struct {
 type TaskRequest struct {
 	Name        string `json:"name"`
 	CategoryID  int    `json:"category_id"`
+	ProjectID   int    `json:"project_id"`
 	Description string `json:"description"`
 }
+
+// Rate is the hourly rate of a client, project, category or task from EffectiveFrom
+// (YYYY-MM-DD) on. HourlyRate is a decimal string such as "95.50".
+type Rate struct {
+	ID            int    `json:"id"`
+	Scope         string `json:"scope"`
+	ScopeID       int    `json:"scope_id"`
+	HourlyRate    string `json:"hourly_rate"`
+	Currency      string `json:"currency"`
+	EffectiveFrom string `json:"effective_from"`
+}
+
+// RateRequest creates or updates a rate; HourlyRate may be sent as a JSON number or string
+type RateRequest struct {
+	Scope         string      `json:"scope"`
+	ScopeID       int         `json:"scope_id"`
+	HourlyRate    json.Number `json:"hourly_rate"`
+	Currency      string      `json:"currency"`
+	EffectiveFrom string      `json:"effective_from"`
+}
+
+// BillingLineItem holds the billable time of one task of a project at one rate. Quantity is
+// in hours; Quantity, HourlyRate and Amount are decimal strings.
+type BillingLineItem struct {
+	Client     string `json:"client"`
+	Project    string `json:"project"`
+	Task       string `json:"task"`
+	HourlyRate string `json:"hourly_rate"`
+	Currency   string `json:"currency"`
+	Minutes    int    `json:"minutes"`
+	Quantity   string `json:"quantity"`
+	Amount     string `json:"amount"`
+	EntryCount int    `json:"entry_count"`
+}
+
+// CurrencyTotal sums up the line items of one currency
+type CurrencyTotal struct {
+	Currency string `json:"currency"`
+	Minutes  int    `json:"minutes"`
+	Amount   string `json:"amount"`
+}
+
+// BillingReport is the response of the billing report. Billable entries without an
+// applicable rate are listed in UnratedEntryIDs instead of being billed.
+type BillingReport struct {
+	From            string            `json:"from,omitempty"`
+	To              string            `json:"to,omitempty"`
+	Client          string            `json:"client,omitempty"`
+	Items           []BillingLineItem `json:"items"`
+	Totals          []CurrencyTotal   `json:"totals"`
+	UnratedEntryIDs []int             `json:"unrated_entry_ids"`
+	Rounding        []RoundingRule    `json:"rounding"`
+}
+
+// RoundingRule rounds durations in reports and exports to multiples of Increment minutes,
+// either each entry or the total of a day. Rules without a client are the default.
+type RoundingRule struct {
+	ClientID  int    `json:"client_id,omitempty"`
+	Client    string `json:"client,omitempty"`
+	Mode      string `json:"mode"`
+	Increment int    `json:"increment,omitempty"`
+	Per       string `json:"per,omitempty"`
+}
+
+// WorkSchedule holds the contracted working minutes per weekday. It applies from
+// EffectiveFrom until the next schedule starts.
+type WorkSchedule struct {
+	ID            int    `json:"id"`
+	EffectiveFrom string `json:"effective_from"`
+	Monday        int    `json:"monday"`
+	Tuesday       int    `json:"tuesday"`
+	Wednesday     int    `json:"wednesday"`
+	Thursday      int    `json:"thursday"`
+	Friday        int    `json:"friday"`
+	Saturday      int    `json:"saturday"`
+	Sunday        int    `json:"sunday"`
+	WeeklyMinutes int    `json:"weekly_minutes"`
+}
+
+type WorkScheduleRequest struct {
+	EffectiveFrom string `json:"effective_from"`
+	Monday        int    `json:"monday"`
+	Tuesday       int    `json:"tuesday"`
+	Wednesday     int    `json:"wednesday"`
+	Thursday      int    `json:"thursday"`
+	Friday        int    `json:"friday"`
+	Saturday      int    `json:"saturday"`
+	Sunday        int    `json:"sunday"`
+}
+
+// BalanceDay compares the minutes booked on a day with its target. Public holidays have no
+// target and absences count as booked up to the target.
+type BalanceDay struct {
+	Date           string `json:"date"`
+	Holiday        string `json:"holiday,omitempty"`
+	Absence        string `json:"absence,omitempty"`
+	TargetMinutes  int    `json:"target_minutes"`
+	BookedMinutes  int    `json:"booked_minutes"`
+	AbsenceMinutes int    `json:"absence_minutes"`
+	DeltaMinutes   int    `json:"delta_minutes"`
+	BalanceMinutes int    `json:"balance_minutes"`
+}
+
+// BalanceReport is the response of the balance report. BalanceMinutes is the overtime
+// (positive) or the missing time (negative) accumulated over the period.
+type BalanceReport struct {
+	From           string       `json:"from"`
+	To             string       `json:"to"`
+	Region         string       `json:"region,omitempty"`
+	TargetMinutes  int          `json:"target_minutes"`
+	BookedMinutes  int          `json:"booked_minutes"`
+	AbsenceMinutes int          `json:"absence_minutes"`
+	BalanceMinutes int          `json:"balance_minutes"`
+	Days           []BalanceDay `json:"days"`
+}
+
+// Absence is a vacation, sick leave or other absence from StartDate to EndDate inclusive.
+// Half-day absences cover half of each day.
+type Absence struct {
+	ID        int    `json:"id"`
+	Type      string `json:"type"`
+	StartDate string `json:"start_date"`
+	EndDate   string `json:"end_date"`
+	HalfDay   bool   `json:"half_day"`
+	Note      string `json:"note"`
+}
+
+// AbsenceRequest creates or replaces an absence; EndDate defaults to StartDate
+type AbsenceRequest struct {
+	Type      string `json:"type"`
+	StartDate string `json:"start_date"`
+	EndDate   string `json:"end_date"`
+	HalfDay   bool   `json:"half_day"`
+	Note      string `json:"note"`
+}
+
+// Holiday is a public holiday of a region
+type Holiday struct {
+	Date string `json:"date"`
+	Name string `json:"name"`
+}
+
+// Company holds the details printed in the header of timesheets and invoices
+type Company struct {
+	Name        string `json:"name"`
+	Address     string `json:"address"`
+	Email       string `json:"email"`
+	Phone       string `json:"phone"`
+	TaxID       string `json:"tax_id"`
+	BankDetails string `json:"bank_details"`
+}
+
+type ClientRequest struct {
+	Name string `json:"name"`
+}
+
+type ProjectRequest struct {
+	ClientID int    `json:"client_id"`
+	Name     string `json:"name"`
+}
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	pkgglobal "timesheet/go/global"
 	pkghandler "timesheet/go/handler"
+	pkgimporter "timesheet/go/importer"
 
 	"github.com/gorilla/mux"
 )
@@ -19,25 +20,112 @@ func SetUpRouter() *mux.Router {
 	// API routes
 	r.HandleFunc("/api/entries", pkghandler.GetTimeEntries).Methods("GET")
 	r.HandleFunc("/api/entries", pkghandler.CreateTimeEntry).Methods("POST")
+	r.HandleFunc("/api/entries/conflicts", pkghandler.GetTimeEntryConflicts).Methods("GET")
+	r.HandleFunc("/api/entries/bulk", pkghandler.BulkTimeEntries).Methods("POST")
 	r.HandleFunc("/api/entries/{id}", pkghandler.UpdateTimeEntry).Methods("PUT")
+	r.HandleFunc("/api/entries/{id}", pkghandler.PatchTimeEntry).Methods("PATCH")
 	r.HandleFunc("/api/entries/{id}", pkghandler.DeleteTimeEntry).Methods("DELETE")
+	r.HandleFunc("/api/entries/{id}/history", pkghandler.GetTimeEntryHistory).Methods("GET")
+
+	// Timer API routes
+	r.HandleFunc("/api/timer", pkghandler.GetRunningTimer).Methods("GET")
+	r.HandleFunc("/api/timer/start", pkghandler.StartTimer).Methods("POST")
+	r.HandleFunc("/api/timer/stop", pkghandler.StopTimer).Methods("POST")
+
+	// Report API routes
+	r.HandleFunc("/api/reports/summary", pkghandler.GetSummaryReport).Methods("GET")
+	r.HandleFunc("/api/reports/billing", pkghandler.GetBillingReport).Methods("GET")
+	r.HandleFunc("/api/reports/balance", pkghandler.GetBalanceReport).Methods("GET")
+
+	// Export API routes
+	r.HandleFunc("/api/export", pkghandler.ExportTimeEntries).Methods("GET")
+	r.HandleFunc("/api/export/entries.ics", pkghandler.ExportTimeEntriesICS).Methods("GET")
+
+	// Import API routes
+	r.HandleFunc("/api/import", pkgimporter.ImportTimeEntries).Methods("POST")
+	r.HandleFunc("/api/import/ics", pkgimporter.PreviewCalendarImport).Methods("POST")
+	r.HandleFunc("/api/import/ics/confirm", pkgimporter.ConfirmCalendarImport).Methods("POST")
 
 	// Configuration API routes
 	r.HandleFunc("/api/categories", pkghandler.GetCategories).Methods("GET")
 	r.HandleFunc("/api/categories", pkghandler.CreateCategory).Methods("POST")
 	r.HandleFunc("/api/categories/{id}", pkghandler.UpdateCategory).Methods("PUT")
+	r.HandleFunc("/api/categories/{id}", pkghandler.PatchCategory).Methods("PATCH")
 	r.HandleFunc("/api/categories/{id}", pkghandler.DeleteCategory).Methods("DELETE")
+	r.HandleFunc("/api/categories/{id}/usage", pkghandler.GetCategoryUsage).Methods("GET")
 
 	r.HandleFunc("/api/tasks", pkghandler.GetTasks).Methods("GET")
 	r.HandleFunc("/api/tasks", pkghandler.CreateTask).Methods("POST")
 	r.HandleFunc("/api/tasks/{id}", pkghandler.UpdateTask).Methods("PUT")
+	r.HandleFunc("/api/tasks/{id}", pkghandler.PatchTask).Methods("PATCH")
 	r.HandleFunc("/api/tasks/{id}", pkghandler.DeleteTask).Methods("DELETE")
 
+	r.HandleFunc("/api/clients", pkghandler.GetClients).Methods("GET")
+	r.HandleFunc("/api/clients", pkghandler.CreateClient).Methods("POST")
+	r.HandleFunc("/api/clients/{id}", pkghandler.UpdateClient).Methods("PUT")
+	r.HandleFunc("/api/clients/{id}", pkghandler.DeleteClient).Methods("DELETE")
+	r.HandleFunc("/api/clients/{id}/rounding", pkghandler.UpdateClientRounding).Methods("PUT")
+	r.HandleFunc("/api/clients/{id}/rounding", pkghandler.DeleteClientRounding).Methods("DELETE")
+
+	r.HandleFunc("/api/projects", pkghandler.GetProjects).Methods("GET")
+	r.HandleFunc("/api/projects", pkghandler.CreateProject).Methods("POST")
+	r.HandleFunc("/api/projects/tree", pkghandler.GetProjectTree).Methods("GET")
+	r.HandleFunc("/api/projects/{id}", pkghandler.UpdateProject).Methods("PUT")
+	r.HandleFunc("/api/projects/{id}", pkghandler.DeleteProject).Methods("DELETE")
+
+	r.HandleFunc("/api/rates", pkghandler.GetRates).Methods("GET")
+	r.HandleFunc("/api/rates", pkghandler.CreateRate).Methods("POST")
+	r.HandleFunc("/api/rates/{id}", pkghandler.UpdateRate).Methods("PUT")
+	r.HandleFunc("/api/rates/{id}", pkghandler.DeleteRate).Methods("DELETE")
+
+	r.HandleFunc("/api/schedules", pkghandler.GetSchedules).Methods("GET")
+	r.HandleFunc("/api/schedules", pkghandler.CreateSchedule).Methods("POST")
+	r.HandleFunc("/api/schedules/{id}", pkghandler.UpdateSchedule).Methods("PUT")
+	r.HandleFunc("/api/schedules/{id}", pkghandler.DeleteSchedule).Methods("DELETE")
+
+	r.HandleFunc("/api/absences", pkghandler.GetAbsences).Methods("GET")
+	r.HandleFunc("/api/absences", pkghandler.CreateAbsence).Methods("POST")
+	r.HandleFunc("/api/absences/{id}", pkghandler.UpdateAbsence).Methods("PUT")
+	r.HandleFunc("/api/absences/{id}", pkghandler.DeleteAbsence).Methods("DELETE")
+	r.HandleFunc("/api/holidays", pkghandler.GetHolidays).Methods("GET")
+
+	r.HandleFunc("/api/rounding", pkghandler.GetRoundingRules).Methods("GET")
+	r.HandleFunc("/api/rounding", pkghandler.UpdateDefaultRounding).Methods("PUT")
+
+	r.HandleFunc("/api/tags", pkghandler.GetTags).Methods("GET")
+
+	r.HandleFunc("/api/templates", pkghandler.GetTemplates).Methods("GET")
+	r.HandleFunc("/api/templates", pkghandler.CreateTemplate).Methods("POST")
+	r.HandleFunc("/api/templates/apply", pkghandler.ApplyTemplates).Methods("POST")
+	r.HandleFunc("/api/templates/{id}", pkghandler.UpdateTemplate).Methods("PUT")
+	r.HandleFunc("/api/templates/{id}", pkghandler.DeleteTemplate).Methods("DELETE")
+
+	// Audit API routes
+	r.HandleFunc("/api/audit", pkghandler.GetAuditLog).Methods("GET")
+
+	// Undo API routes
+	r.HandleFunc("/api/undo", pkghandler.Undo).Methods("POST")
+	r.HandleFunc("/api/redo", pkghandler.Redo).Methods("POST")
+
+	// Company details printed on documents
+	r.HandleFunc("/api/company", pkghandler.GetCompany).Methods("GET")
+	r.HandleFunc("/api/company", pkghandler.UpdateCompany).Methods("PUT")
+
+	// Trash API routes
+	r.HandleFunc("/api/trash", pkghandler.GetTrash).Methods("GET")
+	r.HandleFunc("/api/trash", pkghandler.EmptyTrash).Methods("DELETE")
+	r.HandleFunc("/api/trash/{type}/{id}/restore", pkghandler.RestoreTrashItem).Methods("POST")
+	r.HandleFunc("/api/trash/{type}/{id}", pkghandler.PurgeTrashItem).Methods("DELETE")
+
 	// Serve HTML pages
 	r.HandleFunc("/", pkghandler.ServeIndexHtml).Methods("GET")
 	r.HandleFunc("/entries", pkghandler.ServeEntriesHtml).Methods("GET")
 	r.HandleFunc("/config", pkghandler.ServeConfigHtml).Methods("GET")
 
+	// Printable documents
+	r.HandleFunc("/documents/timesheet", pkghandler.GetTimesheetDocument).Methods("GET")
+	r.HandleFunc("/documents/invoice", pkghandler.GetInvoiceDocument).Methods("GET")
+
 	// Serve favicon
 	r.HandleFunc("/favicon.ico", pkghandler.ServeFavicon).Methods("GET")
 
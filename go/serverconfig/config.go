@@ -4,8 +4,11 @@ import "os"
 
 // Config holds application configuration
 type Config struct {
-	DBPath string
-	Port   string
+	DBPath             string
+	Port               string
+	OverlapPolicy      string
+	TrashRetentionDays string
+	HolidayRegion      string
 }
 
 // GetEnvOrDefault returns the value of an environment variable or a default value if not set
@@ -19,7 +22,10 @@ func GetEnvOrDefault(key, defaultValue string) string {
 // ParseConfig creates configuration from environment variables with defaults
 func ParseConfig() *Config {
 	return &Config{
-		DBPath: GetEnvOrDefault("DB_PATH", "./timesheet.db"),
-		Port:   GetEnvOrDefault("PORT", "8080"),
+		DBPath:             GetEnvOrDefault("DB_PATH", "./timesheet.db"),
+		Port:               GetEnvOrDefault("PORT", "8080"),
+		OverlapPolicy:      GetEnvOrDefault("OVERLAP_POLICY", "allow"),
+		TrashRetentionDays: GetEnvOrDefault("TRASH_RETENTION_DAYS", "30"),
+		HolidayRegion:      GetEnvOrDefault("HOLIDAY_REGION", ""),
 	}
 }
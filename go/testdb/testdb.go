@@ -0,0 +1,44 @@
+// Package testdb opens databases with the real schema for tests. Every database is
+// built by the migrations, so tests cannot drift from the schema the server uses.
+package testdb
+
+import (
+	"database/sql"
+	"testing"
+
+	_ "modernc.org/sqlite"
+
+	"timesheet/go/db/migrations"
+	pkgglobal "timesheet/go/global"
+)
+
+// Open creates an in-memory database with all migrations applied. It is closed when
+// the test ends.
+func Open(t testing.TB) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open test database: %v", err)
+	}
+	// A single connection keeps every query on the same in-memory database
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	if err := migrations.Migrate(db, migrations.All); err != nil {
+		t.Fatalf("failed to migrate test database: %v", err)
+	}
+	return db
+}
+
+// OpenGlobal is Open that also makes the database the global connection until the
+// test ends
+func OpenGlobal(t testing.TB) *sql.DB {
+	t.Helper()
+
+	db := Open(t)
+	previous := pkgglobal.Db
+	pkgglobal.SetDB(db)
+	t.Cleanup(func() { pkgglobal.SetDB(previous) })
+	return db
+}
@@ -16,3 +16,10 @@ func FormatTimeForDB(t time.Time) string {
 func GetCurrentDateForDB() string {
 	return time.Now().Format("2006-01-02")
 }
+
+// CurrentWallClockTime returns the current local wall-clock time expressed in UTC,
+// matching the frontend which stores local times with a Z suffix
+func CurrentWallClockTime() time.Time {
+	now := time.Now()
+	return time.Date(now.Year(), now.Month(), now.Day(), now.Hour(), now.Minute(), now.Second(), 0, time.UTC)
+}
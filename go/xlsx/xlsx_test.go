@@ -0,0 +1,52 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readPart(t *testing.T, data []byte, name string) string {
+	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	f, err := r.Open(name)
+	require.NoError(t, err)
+	defer f.Close()
+	content, err := io.ReadAll(f)
+	require.NoError(t, err)
+	return string(content)
+}
+
+func TestWriterProducesWorkbook(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, "Entries & more")
+	require.NoError(t, err)
+
+	require.NoError(t, w.WriteRow([]interface{}{"Task", "Minutes"}))
+	require.NoError(t, w.WriteRow([]interface{}{"Review <PR>", 90, 1.5, nil, "last"}))
+	require.NoError(t, w.Close())
+
+	workbook := readPart(t, buf.Bytes(), "xl/workbook.xml")
+	assert.Contains(t, workbook, `name="Entries &amp; more"`)
+
+	sheet := readPart(t, buf.Bytes(), "xl/worksheets/sheet1.xml")
+	assert.Contains(t, sheet, `<c r="A1" t="inlineStr"><is><t xml:space="preserve">Task</t></is></c>`)
+	assert.Contains(t, sheet, `<t xml:space="preserve">Review &lt;PR&gt;</t>`)
+	assert.Contains(t, sheet, `<c r="B2"><v>90</v></c>`)
+	assert.Contains(t, sheet, `<c r="C2"><v>1.5</v></c>`)
+	assert.NotContains(t, sheet, `r="D2"`)
+	assert.Contains(t, sheet, `<c r="E2" t="inlineStr">`)
+	assert.Contains(t, sheet, `</sheetData></worksheet>`)
+}
+
+func TestColumnName(t *testing.T) {
+	assert.Equal(t, "A", columnName(0))
+	assert.Equal(t, "Z", columnName(25))
+	assert.Equal(t, "AA", columnName(26))
+	assert.Equal(t, "AZ", columnName(51))
+	assert.Equal(t, "BA", columnName(52))
+}
@@ -5,12 +5,17 @@ import (
 	"embed"
 	"flag"
 	"fmt"
+	"io/fs"
 	"log"
 	"net/http"
 	"os"
+	"strings"
+	"time"
 
 	timesheet "timesheet/go"
 	pkgdb "timesheet/go/db"
+	pkghandler "timesheet/go/handler"
+	pkgholiday "timesheet/go/holiday"
 	tserverconfig "timesheet/go/serverconfig"
 
 	pkgglobal "timesheet/go/global"
@@ -18,7 +23,7 @@ import (
 	_ "modernc.org/sqlite"
 )
 
-//go:embed static/*
+//go:embed static/* templates/*
 var mainStaticFiles embed.FS
 
 var mainDb *sql.DB
@@ -27,6 +32,9 @@ func main() {
 	// Define command-line flags with environment variable fallbacks
 	var dbPath = flag.String("db", tserverconfig.GetEnvOrDefault("DB_PATH", "./timesheet.db"), "Path to the SQLite database file")
 	var port = flag.String("port", tserverconfig.GetEnvOrDefault("PORT", "8080"), "Port to run the server on")
+	var overlapPolicy = flag.String("overlap-policy", tserverconfig.GetEnvOrDefault("OVERLAP_POLICY", "allow"), "Policy for overlapping time entries: reject, allow or trim")
+	var trashRetentionDays = flag.String("trash-retention-days", tserverconfig.GetEnvOrDefault("TRASH_RETENTION_DAYS", "30"), "Days deleted items stay in the trash, 0 to keep them until purged")
+	var holidayRegion = flag.String("holiday-region", tserverconfig.GetEnvOrDefault("HOLIDAY_REGION", ""), "Region of the public holidays, e.g. DE or DE-BY; empty for none")
 	var help = flag.Bool("help", false, "Show usage information")
 
 	// Parse command-line flags
@@ -38,8 +46,11 @@ func main() {
 		fmt.Fprintf(os.Stderr, "\nOptions:\n")
 		flag.PrintDefaults()
 		fmt.Fprintf(os.Stderr, "\nEnvironment Variables:\n")
-		fmt.Fprintf(os.Stderr, "  PORT      Port to run the server on (overridden by -port flag)\n")
-		fmt.Fprintf(os.Stderr, "  DB_PATH   Path to the SQLite database file (overridden by -db flag)\n")
+		fmt.Fprintf(os.Stderr, "  PORT            Port to run the server on (overridden by -port flag)\n")
+		fmt.Fprintf(os.Stderr, "  DB_PATH         Path to the SQLite database file (overridden by -db flag)\n")
+		fmt.Fprintf(os.Stderr, "  OVERLAP_POLICY  Policy for overlapping time entries (overridden by -overlap-policy flag)\n")
+		fmt.Fprintf(os.Stderr, "  TRASH_RETENTION_DAYS  Days deleted items stay in the trash (overridden by -trash-retention-days flag)\n")
+		fmt.Fprintf(os.Stderr, "  HOLIDAY_REGION  Region of the public holidays (overridden by -holiday-region flag)\n")
 		fmt.Fprintf(os.Stderr, "\nExamples:\n")
 		fmt.Fprintf(os.Stderr, "  %s                              # Use default database and port\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s -port 8081                   # Use port 8081\n", os.Args[0])
@@ -53,6 +64,18 @@ func main() {
 
 	var err error
 
+	// Validate the overlap policy before touching the database
+	if _, err := pkghandler.ParseOverlapPolicy(*overlapPolicy); err != nil {
+		log.Fatal(err)
+	}
+	trashRetention, err := pkghandler.ParseTrashRetention(*trashRetentionDays)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *holidayRegion != "" && !pkgholiday.ValidRegion(*holidayRegion) {
+		log.Fatalf("invalid holiday region '%s'. Expected one of %s", *holidayRegion, strings.Join(pkgholiday.Regions(), ", "))
+	}
+
 	// Check database version and create backup if needed
 	if err := pkgdb.CheckAndBackupDatabase(*dbPath); err != nil {
 		log.Fatalf("Database backup failed: %v", err)
@@ -66,12 +89,23 @@ func main() {
 
 	// Set shared resources for the timesheet package
 	pkgglobal.SetStaticFiles(mainStaticFiles)
+	templateFiles, err := fs.Sub(mainStaticFiles, "templates")
+	if err != nil {
+		log.Fatal(err)
+	}
+	pkgglobal.SetTemplates(templateFiles)
 	pkgglobal.SetDB(mainDb)
+	pkgglobal.SetOverlapPolicy(*overlapPolicy)
+	pkgglobal.SetTrashRetention(trashRetention)
+	pkgglobal.SetHolidayRegion(*holidayRegion)
 
 	// Initialize database
 	pkgdb.InitDB()
 	defer mainDb.Close()
 
+	// Purge expired items from the trash in the background
+	go pkghandler.RunTrashRetention(mainDb, trashRetention, time.Hour)
+
 	// Setup routes
 	router := timesheet.SetUpRouter()
 
@@ -74,11 +74,26 @@ const API = {
             });
         },
         
-        // DELETE /api/categories/:id
-        async delete(id) {
+        // PATCH /api/categories/:id - only the given fields are changed
+        async patch(id, fields) {
             return API.request(`/categories/${id}`, {
+                method: 'PATCH',
+                headers: { 'Content-Type': 'application/merge-patch+json' },
+                body: JSON.stringify(fields)
+            });
+        },
+        
+        // DELETE /api/categories/:id?reassign_to=
+        async delete(id, reassignTo) {
+            const query = reassignTo ? `?reassign_to=${reassignTo}` : '';
+            return API.request(`/categories/${id}${query}`, {
                 method: 'DELETE'
             });
+        },
+        
+        // GET /api/categories/:id/usage
+        async getUsage(id) {
+            return API.request(`/categories/${id}/usage`);
         }
     },
     
@@ -107,6 +122,15 @@ const API = {
             });
         },
         
+        // PATCH /api/tasks/:id - only the given fields are changed
+        async patch(id, fields) {
+            return API.request(`/tasks/${id}`, {
+                method: 'PATCH',
+                headers: { 'Content-Type': 'application/merge-patch+json' },
+                body: JSON.stringify(fields)
+            });
+        },
+        
         // DELETE /api/tasks/:id
         async delete(id) {
             return API.request(`/tasks/${id}`, {
@@ -119,9 +143,30 @@ const API = {
      * Time Entries API
      */
     entries: {
-        // GET /api/entries
-        async getAll() {
-            return API.request('/entries');
+        // GET /api/entries?from=&to=&category=&task=&q=&limit=&cursor=&sort=
+        async getPage(params = {}) {
+            const query = new URLSearchParams(params).toString();
+            return API.request(query ? `/entries?${query}` : '/entries');
+        },
+        
+        // GET /api/entries - unwraps the page envelope
+        async getAll(params = {}) {
+            const page = await API.entries.getPage(params);
+            return page ? page.entries : [];
+        },
+        
+        // GET /api/entries - follows next_cursor until all matching entries are loaded;
+        // params should hold a limit and a date range to keep the pages bounded
+        async getAllPages(params = {}) {
+            const entries = [];
+            let cursor = '';
+            do {
+                const page = await API.entries.getPage(cursor ? { ...params, cursor } : params);
+                if (!page) break;
+                entries.push(...page.entries);
+                cursor = page.next_cursor || '';
+            } while (cursor);
+            return entries;
         },
         
         // POST /api/entries
@@ -140,11 +185,427 @@ const API = {
             });
         },
         
+        // PATCH /api/entries/:id - only the given fields are changed
+        async patch(id, fields) {
+            return API.request(`/entries/${id}`, {
+                method: 'PATCH',
+                headers: { 'Content-Type': 'application/merge-patch+json' },
+                body: JSON.stringify(fields)
+            });
+        },
+        
         // DELETE /api/entries/:id
         async delete(id) {
             return API.request(`/entries/${id}`, {
                 method: 'DELETE'
             });
+        },
+        
+        // GET /api/entries/:id/history
+        async getHistory(id) {
+            return API.request(`/entries/${id}/history`);
+        },
+        
+        // POST /api/entries/bulk
+        async bulk(request) {
+            return API.request('/entries/bulk', {
+                method: 'POST',
+                body: JSON.stringify(request)
+            });
+        }
+    },
+    
+    /**
+     * Audit log API
+     */
+    audit: {
+        // GET /api/audit?from=&to=&entity_type=
+        async getAll(params = {}) {
+            const query = new URLSearchParams(params).toString();
+            return API.request(query ? `/audit?${query}` : '/audit');
+        }
+    },
+    
+    /**
+     * Timer API
+     */
+    timer: {
+        // GET /api/timer - null when no timer is running
+        async get() {
+            return API.request('/timer');
+        },
+        
+        // POST /api/timer/start
+        async start(timerData) {
+            return API.request('/timer/start', {
+                method: 'POST',
+                body: JSON.stringify(timerData)
+            });
+        },
+        
+        // POST /api/timer/stop
+        async stop() {
+            return API.request('/timer/stop', {
+                method: 'POST'
+            });
+        }
+    },
+    
+    /**
+     * Tags API
+     */
+    tags: {
+        // GET /api/tags
+        async getAll() {
+            return API.request('/tags');
+        }
+    },
+
+    /**
+     * Clients API
+     */
+    clients: {
+        // GET /api/clients
+        async getAll() {
+            return API.request('/clients');
+        },
+
+        // POST /api/clients
+        async create(clientData) {
+            return API.request('/clients', {
+                method: 'POST',
+                body: JSON.stringify(clientData)
+            });
+        },
+
+        // PUT /api/clients/:id
+        async update(id, clientData) {
+            return API.request(`/clients/${id}`, {
+                method: 'PUT',
+                body: JSON.stringify(clientData)
+            });
+        },
+
+        // DELETE /api/clients/:id
+        async delete(id) {
+            return API.request(`/clients/${id}`, {
+                method: 'DELETE'
+            });
+        },
+
+        // PUT /api/clients/:id/rounding
+        async setRounding(id, rule) {
+            return API.request(`/clients/${id}/rounding`, {
+                method: 'PUT',
+                body: JSON.stringify(rule)
+            });
+        },
+
+        // DELETE /api/clients/:id/rounding - the client falls back to the default rule
+        async deleteRounding(id) {
+            return API.request(`/clients/${id}/rounding`, {
+                method: 'DELETE'
+            });
+        }
+    },
+
+    /**
+     * Absences API
+     */
+    absences: {
+        // GET /api/absences?from=&to=
+        async getAll(params = {}) {
+            const query = new URLSearchParams(params).toString();
+            return API.request(query ? `/absences?${query}` : '/absences');
+        },
+
+        // POST /api/absences
+        async create(absenceData) {
+            return API.request('/absences', {
+                method: 'POST',
+                body: JSON.stringify(absenceData)
+            });
+        },
+
+        // PUT /api/absences/:id
+        async update(id, absenceData) {
+            return API.request(`/absences/${id}`, {
+                method: 'PUT',
+                body: JSON.stringify(absenceData)
+            });
+        },
+
+        // DELETE /api/absences/:id
+        async delete(id) {
+            return API.request(`/absences/${id}`, {
+                method: 'DELETE'
+            });
+        }
+    },
+
+    /**
+     * Public holidays API
+     */
+    holidays: {
+        // GET /api/holidays?year=&region=
+        async getAll(params = {}) {
+            const query = new URLSearchParams(params).toString();
+            return API.request(query ? `/holidays?${query}` : '/holidays');
+        }
+    },
+
+    /**
+     * Rounding rules API
+     */
+    rounding: {
+        // GET /api/rounding - the default rule followed by the client rules
+        async getAll() {
+            return API.request('/rounding');
+        },
+
+        // PUT /api/rounding - the default rule
+        async update(rule) {
+            return API.request('/rounding', {
+                method: 'PUT',
+                body: JSON.stringify(rule)
+            });
+        }
+    },
+
+    /**
+     * Projects API
+     */
+    projects: {
+        // GET /api/projects?client_id=
+        async getAll(params = {}) {
+            const query = new URLSearchParams(params).toString();
+            return API.request(query ? `/projects?${query}` : '/projects');
+        },
+
+        // GET /api/projects/tree - clients with their projects and tasks
+        async getTree() {
+            return API.request('/projects/tree');
+        },
+
+        // POST /api/projects
+        async create(projectData) {
+            return API.request('/projects', {
+                method: 'POST',
+                body: JSON.stringify(projectData)
+            });
+        },
+
+        // PUT /api/projects/:id
+        async update(id, projectData) {
+            return API.request(`/projects/${id}`, {
+                method: 'PUT',
+                body: JSON.stringify(projectData)
+            });
+        },
+
+        // DELETE /api/projects/:id
+        async delete(id) {
+            return API.request(`/projects/${id}`, {
+                method: 'DELETE'
+            });
+        }
+    },
+
+    /**
+     * Hourly rates API
+     */
+    rates: {
+        // GET /api/rates?scope=&scope_id=
+        async getAll(params = {}) {
+            const query = new URLSearchParams(params).toString();
+            return API.request(query ? `/rates?${query}` : '/rates');
+        },
+
+        // POST /api/rates
+        async create(rateData) {
+            return API.request('/rates', {
+                method: 'POST',
+                body: JSON.stringify(rateData)
+            });
+        },
+
+        // PUT /api/rates/:id
+        async update(id, rateData) {
+            return API.request(`/rates/${id}`, {
+                method: 'PUT',
+                body: JSON.stringify(rateData)
+            });
+        },
+
+        // DELETE /api/rates/:id
+        async delete(id) {
+            return API.request(`/rates/${id}`, {
+                method: 'DELETE'
+            });
+        }
+    },
+
+    /**
+     * Reports API
+     */
+    reports: {
+        // GET /api/reports/summary?from=&to=&group_by=&tag=&rounding=
+        async summary(params = {}) {
+            const query = new URLSearchParams(params).toString();
+            return API.request(query ? `/reports/summary?${query}` : '/reports/summary');
+        },
+
+        // GET /api/reports/billing?client=&from=&to=&rounding=
+        async billing(params = {}) {
+            const query = new URLSearchParams(params).toString();
+            return API.request(query ? `/reports/billing?${query}` : '/reports/billing');
+        },
+
+        // GET /api/reports/balance?from=&to=&region=
+        async balance(params = {}) {
+            const query = new URLSearchParams(params).toString();
+            return API.request(query ? `/reports/balance?${query}` : '/reports/balance');
+        }
+    },
+
+    /**
+     * Working-hours schedules API
+     */
+    schedules: {
+        // GET /api/schedules
+        async getAll() {
+            return API.request('/schedules');
+        },
+
+        // POST /api/schedules
+        async create(scheduleData) {
+            return API.request('/schedules', {
+                method: 'POST',
+                body: JSON.stringify(scheduleData)
+            });
+        },
+
+        // PUT /api/schedules/:id
+        async update(id, scheduleData) {
+            return API.request(`/schedules/${id}`, {
+                method: 'PUT',
+                body: JSON.stringify(scheduleData)
+            });
+        },
+
+        // DELETE /api/schedules/:id
+        async delete(id) {
+            return API.request(`/schedules/${id}`, {
+                method: 'DELETE'
+            });
+        }
+    },
+
+    /**
+     * Company details API
+     */
+    company: {
+        // GET /api/company
+        async get() {
+            return API.request('/company');
+        },
+
+        // PUT /api/company
+        async update(companyData) {
+            return API.request('/company', {
+                method: 'PUT',
+                body: JSON.stringify(companyData)
+            });
+        }
+    },
+
+    /**
+     * Recurring templates API
+     */
+    templates: {
+        // GET /api/templates
+        async getAll() {
+            return API.request('/templates');
+        },
+        
+        // POST /api/templates
+        async create(templateData) {
+            return API.request('/templates', {
+                method: 'POST',
+                body: JSON.stringify(templateData)
+            });
+        },
+        
+        // PUT /api/templates/:id
+        async update(id, templateData) {
+            return API.request(`/templates/${id}`, {
+                method: 'PUT',
+                body: JSON.stringify(templateData)
+            });
+        },
+        
+        // DELETE /api/templates/:id
+        async delete(id) {
+            return API.request(`/templates/${id}`, {
+                method: 'DELETE'
+            });
+        },
+        
+        // POST /api/templates/apply?date=
+        async apply(date) {
+            return API.request(`/templates/apply?date=${encodeURIComponent(date)}`, {
+                method: 'POST'
+            });
+        }
+    },
+    
+    /**
+     * Undo API - changes are tracked per session cookie
+     */
+    undo: {
+        // POST /api/undo
+        async undo() {
+            return API.request('/undo', {
+                method: 'POST'
+            });
+        },
+        
+        // POST /api/redo
+        async redo() {
+            return API.request('/redo', {
+                method: 'POST'
+            });
+        }
+    },
+    
+    /**
+     * Trash API
+     */
+    trash: {
+        // GET /api/trash
+        async getAll() {
+            return API.request('/trash');
+        },
+        
+        // POST /api/trash/:type/:id/restore
+        async restore(type, id) {
+            return API.request(`/trash/${type}/${id}/restore`, {
+                method: 'POST'
+            });
+        },
+        
+        // DELETE /api/trash/:type/:id
+        async purge(type, id) {
+            return API.request(`/trash/${type}/${id}`, {
+                method: 'DELETE'
+            });
+        },
+        
+        // DELETE /api/trash
+        async empty() {
+            return API.request('/trash', {
+                method: 'DELETE'
+            });
         }
     }
 };
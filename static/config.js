@@ -132,12 +132,30 @@ async function handleCategorySubmit(event) {
 }
 
 async function deleteCategory(id) {
-    if (!confirm('Are you sure you want to delete this category? This action cannot be undone.')) {
-        return;
-    }
-    
     try {
-        await API.categories.delete(id);
+        const usage = await API.categories.getUsage(id);
+        let reassignTo = null;
+        
+        if (usage.entries > 0 || usage.tasks > 0) {
+            // Entries and tasks in use have to be moved to another category first
+            const others = categories.filter(c => c.id !== id);
+            const trashed = usage.trashed > 0 ? `, ${usage.trashed} of them in the trash` : '';
+            const name = prompt(`"${usage.name}" is used by ${usage.entries} time entries (${usage.total_minutes} min) and ${usage.tasks} tasks${trashed}.\n\n` +
+                `Enter the category to move them to:\n${others.map(c => c.name).join(', ')}`);
+            if (!name) {
+                return;
+            }
+            const target = others.find(c => c.name === name.trim());
+            if (!target) {
+                Utils.showError(`Category "${name}" does not exist`);
+                return;
+            }
+            reassignTo = target.id;
+        } else if (!confirm('Are you sure you want to delete this category? This action cannot be undone.')) {
+            return;
+        }
+        
+        await API.categories.delete(id, reassignTo);
         
         await loadCategories();
         Utils.showSuccess('Category deleted successfully!');
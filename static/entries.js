@@ -2,6 +2,14 @@
 let entries = [];
 let categories = [];
 let currentSort = { column: 'date', direction: 'desc' };
+let nextCursor = ''; // Cursor of the next page of entries, empty when all are loaded
+let entriesTotal = 0; // Number of entries matching the filters, including pages not loaded yet
+
+// Number of entries loaded per page
+const ENTRIES_PAGE_SIZE = 100;
+
+// Sort parameter of the listing per sortable column; the server sorts across all pages
+const SORT_PARAMS = { date: 'start_time', time: 'start_time', duration: 'duration' };
 
 // Initialize the application
 document.addEventListener('DOMContentLoaded', function() {
@@ -19,9 +27,9 @@ document.addEventListener('DOMContentLoaded', function() {
     updateSortIndicators();
     
     // Setup filters
-    document.getElementById('categoryFilter').addEventListener('change', filterEntries);
-    document.getElementById('dateFromFilter').addEventListener('change', filterEntries);
-    document.getElementById('dateToFilter').addEventListener('change', filterEntries);
+    document.getElementById('categoryFilter').addEventListener('change', loadEntries);
+    document.getElementById('dateFromFilter').addEventListener('change', loadEntries);
+    document.getElementById('dateToFilter').addEventListener('change', loadEntries);
 });
 
 function setupEventListeners() {
@@ -54,10 +62,27 @@ async function loadCategories() {
     }
 }
 
+// entryFilterParams returns the query of the current filters and sort; the server filters,
+// sorts and pages the entries
+function entryFilterParams() {
+    const sort = SORT_PARAMS[currentSort.column] || 'start_time';
+    const params = { limit: ENTRIES_PAGE_SIZE, sort: currentSort.direction === 'desc' ? `-${sort}` : sort };
+    const category = document.getElementById('categoryFilter').value;
+    const from = document.getElementById('dateFromFilter').value;
+    const to = document.getElementById('dateToFilter').value;
+    if (category) params.category = category;
+    if (from) params.from = from;
+    if (to) params.to = to;
+    return params;
+}
+
 async function loadEntries() {
     try {
         console.log('Loading entries...');
-        entries = await API.entries.getAll() || [];
+        const page = await API.entries.getPage(entryFilterParams());
+        entries = page ? page.entries : [];
+        nextCursor = page && page.next_cursor ? page.next_cursor : '';
+        entriesTotal = page ? page.total : 0;
         console.log('Loaded entries:', entries.length, entries);
         renderEntries();
         updateTotalTime();
@@ -139,9 +164,6 @@ function renderEntries() {
         return;
     }
     
-    // Apply current sort if any, otherwise default to date descending
-    const sortedEntries = applySortToEntries(filteredEntries);
-    
     // Create table
     let html = `
         <table class="entries-data-table">
@@ -150,28 +172,22 @@ function renderEntries() {
                     <th class="sortable" onclick="sortTable('date')" data-sort="date">
                         Date <span class="sort-indicator" id="sort-date"></span>
                     </th>
-                    <th class="sortable" onclick="sortTable('task')" data-sort="task">
-                        Task <span class="sort-indicator" id="sort-task"></span>
-                    </th>
-                    <th class="sortable" onclick="sortTable('category')" data-sort="category">
-                        Category <span class="sort-indicator" id="sort-category"></span>
-                    </th>
+                    <th>Task</th>
+                    <th>Category</th>
                     <th class="sortable" onclick="sortTable('duration')" data-sort="duration">
                         Duration <span class="sort-indicator" id="sort-duration"></span>
                     </th>
                     <th class="sortable" onclick="sortTable('time')" data-sort="time">
                         Time <span class="sort-indicator" id="sort-time"></span>
                     </th>
-                    <th class="sortable" onclick="sortTable('description')" data-sort="description">
-                        Description <span class="sort-indicator" id="sort-description"></span>
-                    </th>
+                    <th>Description</th>
                     <th>Actions</th>
                 </tr>
             </thead>
             <tbody>
     `;
     
-    sortedEntries.forEach(entry => {
+    filteredEntries.forEach(entry => {
         html += renderTableRow(entry);
     });
     
@@ -180,6 +196,15 @@ function renderEntries() {
         </table>
     `;
     
+    if (nextCursor) {
+        html += `
+            <div class="load-more">
+                <span>Showing ${entries.length} of ${entriesTotal} entries</span>
+                <button type="button" class="btn btn-secondary btn-small" onclick="loadMoreEntries()">Load more entries</button>
+            </div>
+        `;
+    }
+    
     entriesTable.innerHTML = html;
 }
 
@@ -228,17 +253,25 @@ function groupEntriesByDate(entries) {
     }, {});
 }
 
-function updateTotalTime() {
+// updateTotalTime shows the time booked today, taken from the summary report so it does not
+// depend on the pages loaded
+async function updateTotalTime() {
     const today = new Date().toISOString().split('T')[0];
     const categoryFilter = document.getElementById('categoryFilter').value;
     
-    let todayEntries = entries.filter(entry => Utils.getEntryDate(entry) === today);
-    if (categoryFilter) {
-        todayEntries = todayEntries.filter(entry => entry.category === categoryFilter);
+    let totalMinutes = 0;
+    try {
+        const report = await API.reports.summary({ from: today, to: today, group_by: 'category', rounding: 'none' });
+        if (report && categoryFilter) {
+            const bucket = report.buckets.find(bucket => bucket.key === categoryFilter);
+            totalMinutes = bucket ? bucket.total_minutes : 0;
+        } else if (report) {
+            totalMinutes = report.total_minutes;
+        }
+    } catch (error) {
+        console.error('Error loading the total time:', error);
     }
     
-    const totalMinutes = todayEntries.reduce((sum, entry) => sum + Utils.getEntryDuration(entry), 0);
-    
     const hours = Math.floor(totalMinutes / 60);
     const minutes = totalMinutes % 60;
     const totalText = hours > 0 ? `${hours}h ${minutes}m` : `${minutes}m`;
@@ -247,9 +280,18 @@ function updateTotalTime() {
     document.getElementById('totalTime').textContent = totalText + filterText;
 }
 
-function filterEntries() {
-    renderEntries();
-    updateTotalTime();
+async function loadMoreEntries() {
+    if (!nextCursor) return;
+    try {
+        const page = await API.entries.getPage({ ...entryFilterParams(), cursor: nextCursor });
+        entries.push(...(page ? page.entries : []));
+        nextCursor = page && page.next_cursor ? page.next_cursor : '';
+        renderEntries();
+        updateTotalTime();
+    } catch (error) {
+        console.error('Error loading more entries:', error);
+        Utils.showError('Failed to load more time entries');
+    }
 }
 
 function sortTable(column) {
@@ -261,67 +303,8 @@ function sortTable(column) {
         currentSort.direction = 'asc';
     }
     
-    renderEntries();
     updateSortIndicators();
-}
-
-function applySortToEntries(entries) {
-    return entries.sort((a, b) => {
-        let aVal, bVal;
-        
-        switch (currentSort.column) {
-            case 'date':
-                aVal = new Date(Utils.getEntryDate(a) || '1970-01-01');
-                bVal = new Date(Utils.getEntryDate(b) || '1970-01-01');
-                break;
-            case 'task':
-                aVal = a.task.toLowerCase();
-                bVal = b.task.toLowerCase();
-                break;
-            case 'category':
-                aVal = (a.category || 'other').toLowerCase();
-                bVal = (b.category || 'other').toLowerCase();
-                break;
-            case 'duration':
-                aVal = Utils.getEntryDuration(a);
-                bVal = Utils.getEntryDuration(b);
-                break;
-            case 'time':
-                // Sort by start_time if available, otherwise put manual entries at end
-                if (a.start_time && b.start_time) {
-                    const aTimeStr = a.start_time.replace('Z', '').replace('T', ' ');
-                    const bTimeStr = b.start_time.replace('Z', '').replace('T', ' ');
-                    aVal = new Date(aTimeStr);
-                    bVal = new Date(bTimeStr);
-                } else if (a.start_time && !b.start_time) {
-                    return currentSort.direction === 'asc' ? -1 : 1;
-                } else if (!a.start_time && b.start_time) {
-                    return currentSort.direction === 'asc' ? 1 : -1;
-                } else {
-                    aVal = a.id;
-                    bVal = b.id;
-                }
-                break;
-            case 'description':
-                aVal = (a.description || '').toLowerCase();
-                bVal = (b.description || '').toLowerCase();
-                break;
-            default:
-                aVal = a.id;
-                bVal = b.id;
-        }
-        
-        let result;
-        if (typeof aVal === 'string') {
-            result = aVal.localeCompare(bVal);
-        } else if (aVal instanceof Date && bVal instanceof Date) {
-            result = aVal - bVal;
-        } else {
-            result = aVal - bVal;
-        }
-        
-        return currentSort.direction === 'asc' ? result : -result;
-    });
+    loadEntries();
 }
 
 function updateSortIndicators() {
@@ -343,7 +326,7 @@ function updateSortIndicators() {
 function clearDateFilter() {
     document.getElementById('dateFromFilter').value = '';
     document.getElementById('dateToFilter').value = '';
-    filterEntries();
+    loadEntries();
 }
 
 async function editEntry(id) {
@@ -429,14 +412,17 @@ function closeEditModal() {
 }
 
 // Excel Export Functionality
-function exportToExcel() {
+async function exportToExcel() {
     try {
         // Get filtered entries (same logic as renderEntries)
         const categoryFilter = document.getElementById('categoryFilter').value;
         const dateFromFilter = document.getElementById('dateFromFilter').value;
         const dateToFilter = document.getElementById('dateToFilter').value;
         
-        let filteredEntries = entries;
+        // The export covers all matching entries, including pages not loaded yet
+        let filteredEntries = nextCursor
+            ? await API.entries.getAllPages({ ...entryFilterParams(), limit: 1000 })
+            : entries;
         
         // Apply category filter
         if (categoryFilter) {
@@ -493,7 +479,7 @@ function exportToExcel() {
         ]);
         
         // Add data rows
-        sortedEntries.forEach(entry => {
+        filteredEntries.forEach(entry => {
             const entryDateStr = Utils.getEntryDate(entry);
             const entryDate = new Date(entryDateStr + 'T00:00:00');
             const weekday = entryDate.toLocaleDateString('en-US', { weekday: 'long' });
@@ -6,6 +6,9 @@ let predefinedTasks = [];
 let date_selected = null; // Track the currently selected date
 let editingEntryId = null; // Track if we're editing an existing entry
 
+// Page size used when loading entries
+const ENTRIES_PAGE_SIZE = 500;
+
 // Initialize the application
 document.addEventListener('DOMContentLoaded', function() {
     // Initialize selected date to today on first load
@@ -34,6 +37,30 @@ function setupEventListeners() {
     
     // Cancel Edit button
     document.getElementById('cancelEdit').addEventListener('click', cancelEdit);
+    
+    // Undo (Ctrl+Z) and redo (Ctrl+Shift+Z or Ctrl+Y) outside of form fields
+    document.addEventListener('keydown', handleUndoShortcut);
+}
+
+async function handleUndoShortcut(event) {
+    if (!(event.ctrlKey || event.metaKey) || event.target.closest('input, textarea, select')) {
+        return;
+    }
+    const key = event.key.toLowerCase();
+    const redo = key === 'y' || (key === 'z' && event.shiftKey);
+    if (key !== 'z' && !redo) {
+        return;
+    }
+    event.preventDefault();
+    
+    try {
+        await (redo ? API.undo.redo() : API.undo.undo());
+        await loadEntries();
+        Utils.showSuccess(`${redo ? 'Redid' : 'Undid'} the last change`);
+    } catch (error) {
+        console.error('Error during undo/redo:', error);
+        Utils.showError(`Failed to ${redo ? 'redo' : 'undo'}: ${error.message}`);
+    }
 }
 
 async function loadCategories() {
@@ -63,10 +90,34 @@ async function loadPredefinedTasks() {
     }
 }
 
+// weekRange returns the first and last day of the seven-day breakdown
+function weekRange() {
+    const end = new Date();
+    const start = new Date(end);
+    start.setDate(start.getDate() - 6);
+    return { from: start.toISOString().split('T')[0], to: end.toISOString().split('T')[0] };
+}
+
+// isInWeek reports whether a date is one of the days of the seven-day breakdown
+function isInWeek(date) {
+    const week = weekRange();
+    return date >= week.from && date <= week.to;
+}
+
+// loadEntryRange loads the entries of the days from through to
+async function loadEntryRange(from, to) {
+    return await API.entries.getAllPages({ from, to, limit: ENTRIES_PAGE_SIZE, sort: '-start_time' }) || [];
+}
+
+// Only the seven-day breakdown and the selected day are loaded
 async function loadEntries() {
     try {
         console.log('Loading entries...');
-        entries = await API.entries.getAll() || [];
+        const week = weekRange();
+        entries = await loadEntryRange(week.from, week.to);
+        if (date_selected && !isInWeek(date_selected)) {
+            entries.push(...await loadEntryRange(date_selected, date_selected));
+        }
         console.log('Loaded entries:', entries.length, entries);
         updateTodayStats();
         loadDayEntries(); // Update time slots after entries are loaded
@@ -76,6 +127,21 @@ async function loadEntries() {
     }
 }
 
+// loadSelectedDay replaces the entries outside the seven-day breakdown with the entries of
+// the selected day
+async function loadSelectedDay() {
+    try {
+        const dayEntries = await loadEntryRange(date_selected, date_selected);
+        entries = entries.filter(entry => isInWeek(Utils.getEntryDate(entry))).concat(dayEntries);
+        loadDayEntries();
+    } catch (error) {
+        console.error('Error loading entries:', error);
+        Utils.showError('Failed to load time entries');
+    }
+}
+
+
+
 function updateCategorySelectors() {
     // Update main category selector
     const categorySelect = document.getElementById('category');
@@ -324,6 +390,12 @@ async function handleFormSubmit(event) {
             Utils.showSuccess('Time entry added successfully!');
         }
         
+        // Neighbouring entries may have been trimmed by the server
+        if (resultEntry.warning) {
+            Utils.showError(resultEntry.warning);
+            await loadEntries();
+        }
+        
         updateTodayStats();
         
         // Refresh time slots for the currently selected date if the entry was added to it
@@ -345,39 +417,25 @@ async function handleFormSubmit(event) {
 async function handleAddDaily() {
     if (!date_selected) return;
     
-    // Check if a "Daily" entry already exists for this date
-    const existingDaily = entries.find(entry => 
-        Utils.getEntryDate(entry) === date_selected && 
-        entry.task.toLowerCase() === 'daily' && 
-        entry.category === 'project support'
-    );
-    
-    if (existingDaily) {
-        Utils.showError('Daily entry already exists for this date');
-        return;
-    }
-    
-    // Create the daily entry data
-    const dailyData = {
-        task: 'Daily',
-        description: '',
-        category: 'project support',
-        start_time: `${date_selected}T09:00:00Z`,
-        end_time: `${date_selected}T09:30:00Z`
-    };
-    
     try {
-        const newEntry = await API.entries.create(dailyData);
-        entries.unshift(newEntry);
+        // Book all recurring templates due on the selected date
+        const result = await API.templates.apply(date_selected);
+        entries.unshift(...result.created);
         updateTodayStats();
         
-        // Refresh time slots since the daily entry was added to the currently selected date
+        // Refresh time slots since the entries were added to the currently selected date
         loadDayEntries();
         
-        Utils.showSuccess('Daily entry added successfully!');
+        if (result.created.length > 0) {
+            Utils.showSuccess(`Added ${result.created.length} recurring ${result.created.length === 1 ? 'entry' : 'entries'}`);
+        } else if (result.skipped.length > 0) {
+            Utils.showError(`No entries added: ${result.skipped.map(skip => `${skip.task} (${skip.reason})`).join(', ')}`);
+        } else {
+            Utils.showError('No recurring entries are scheduled for this date');
+        }
     } catch (error) {
-        console.error('Error creating daily entry:', error);
-        Utils.showError(`Failed to create daily entry: ${error.message}`);
+        console.error('Error applying recurring templates:', error);
+        Utils.showError(`Failed to add recurring entries: ${error.message}`);
     }
 }
 
@@ -766,7 +824,11 @@ function handleDateChange() {
     date_selected = document.getElementById('date').value;
     
     updateSelectedDateDisplay();
-    loadDayEntries();
+    if (date_selected && !isInWeek(date_selected)) {
+        loadSelectedDay();
+    } else {
+        loadDayEntries();
+    }
     clearSelectedSlots();
     
     // Clear time inputs